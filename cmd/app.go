@@ -0,0 +1,228 @@
+package main
+
+import (
+	"GoBlast/configs"
+	"GoBlast/internal/api/middleware"
+	"GoBlast/internal/worker"
+	"GoBlast/pkg/health"
+	"GoBlast/pkg/linktrack"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/metrics"
+	"GoBlast/pkg/notify"
+	"GoBlast/pkg/queue"
+	"GoBlast/pkg/ratelimit"
+	"GoBlast/pkg/storage/db"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// shutdownTimeout — сколько ждать завершения HTTP-запросов при остановке
+const shutdownTimeout = 10 * time.Second
+
+// app — общие зависимости компонентов одного процесса
+type app struct {
+	cfg    *configs.Config
+	db     *gorm.DB
+	nats   *queue.NATSClient // nil, если компоненту NATS не нужен
+	health *health.Checker
+	alerts *notify.Dispatcher
+}
+
+// appOptions — что нужно поднять компоненту
+type appOptions struct {
+	configPath  string
+	autoMigrate bool // применить миграции при старте
+	nats        bool // подключиться к NATS
+	alerts      bool // запустить оповещения администраторов
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("CONFIG_PATH"); path != "" {
+		return path
+	}
+	return "./configs"
+}
+
+// newApp загружает конфигурацию и подключается к зависимостям; при ошибке
+// уже открытые соединения закрываются
+func newApp(opts appOptions) (*app, error) {
+	cfg, err := configs.LoadConfig(opts.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Info("Приложение запущено",
+		zap.String("environment", cfg.App.Environment),
+		zap.Int("port", cfg.App.Port),
+	)
+
+	a := &app{cfg: cfg, health: health.New()}
+	if a.db, err = initDB(cfg, opts.autoMigrate); err != nil {
+		return nil, err
+	}
+	a.health.Add("database", func(ctx context.Context) error {
+		sqlDB, err := a.db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+
+	if opts.nats {
+		if a.nats, err = initQueue(cfg); err != nil {
+			a.close()
+			return nil, err
+		}
+		a.health.Add("nats", func(context.Context) error {
+			if !a.nats.Conn.IsConnected() {
+				return errors.New("nats is not connected")
+			}
+			return nil
+		})
+	}
+
+	middleware.Initialize(cfg)
+	linktrack.Initialize(cfg.Tracking.BaseURL, cfg.Tracking.Secret)
+	metrics.InitMetrics()
+
+	if opts.alerts {
+		a.alerts = initAlerts(cfg.Alerts)
+		a.alerts.Start()
+		worker.SetAlerts(a.alerts, cfg.Alerts.Rules)
+	}
+	return a, nil
+}
+
+// close освобождает соединения в обратном порядке
+func (a *app) close() {
+	if a.alerts != nil {
+		a.alerts.Stop()
+	}
+	if a.nats != nil {
+		a.nats.Conn.Close()
+		logger.Log.Info("Соединение с NATS закрыто")
+	}
+	if a.db != nil {
+		sqlDB, err := a.db.DB()
+		if err != nil {
+			logger.Log.Error("Ошибка получения sql.DB", zap.Error(err))
+			return
+		}
+		if err := sqlDB.Close(); err != nil {
+			logger.Log.Error("Ошибка закрытия соединения с БД", zap.Error(err))
+		}
+	}
+}
+
+func initDB(cfg *configs.Config, migrate bool) (*gorm.DB, error) {
+	dsn := configs.GetDSN(cfg.Database)
+	if err := db.Connect(dsn); err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	logger.Log.Info("Соединение с БД установлено",
+		zap.String("host", cfg.Database.Host), zap.String("name", cfg.Database.Name))
+
+	if migrate {
+		if _, err := db.MigrateUp(db.DB); err != nil {
+			if sqlDB, e := db.DB.DB(); e == nil {
+				_ = sqlDB.Close()
+			}
+			return nil, fmt.Errorf("migrate database: %w", err)
+		}
+	}
+	return db.DB, nil
+}
+
+func initQueue(cfg *configs.Config) (*queue.NATSClient, error) {
+	client, err := queue.NewNatsClient(cfg.Broker.URL)
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats: %w", err)
+	}
+	if !client.Conn.IsConnected() {
+		client.Conn.Close()
+		return nil, errors.New("nats connection is not established")
+	}
+	logger.Log.Info("Соединение с NATS установлено", zap.String("url", cfg.Broker.URL))
+	return client, nil
+}
+
+// initRateLimiter выбирает хранилище бюджетов отправок. Общий лимитер в NATS KV
+// подстрахован локальным: при недоступности NATS рассылка продолжается.
+func initRateLimiter(ctx context.Context, cfg configs.RateLimitConfig, natsClient *queue.NATSClient) ratelimit.Limiter {
+	local := ratelimit.NewLocalLimiter()
+	if cfg.Backend != "nats" {
+		logger.Log.Info("Используются локальные лимиты отправки")
+		return local
+	}
+
+	shared, err := ratelimit.NewNATSLimiter(ctx, natsClient.Conn, cfg.Bucket)
+	if err != nil {
+		logger.Log.Error("Ошибка инициализации общего лимитера, используются локальные лимиты", zap.Error(err))
+		return local
+	}
+	logger.Log.Info("Используются общие лимиты отправки в NATS KV", zap.String("bucket", cfg.Bucket))
+	return ratelimit.NewFallbackLimiter(shared, local)
+}
+
+// initAlerts собирает каналы оповещений администраторов из конфигурации.
+// Без настроенных каналов оповещения только пишутся в лог.
+func initAlerts(cfg configs.AlertsConfig) *notify.Dispatcher {
+	var notifiers []notify.Notifier
+	if cfg.Telegram.BotToken != "" {
+		n, err := notify.NewTelegramNotifier(cfg.Telegram.BotToken, cfg.Telegram.ChatID)
+		if err != nil {
+			logger.Log.Error("Ошибка настройки оповещений в Telegram", zap.Error(err))
+		} else {
+			notifiers = append(notifiers, n)
+		}
+	}
+	if cfg.SMTP.Host != "" && len(cfg.SMTP.To) > 0 {
+		notifiers = append(notifiers, notify.NewSMTPNotifier(cfg.SMTP.Host, cfg.SMTP.Port,
+			cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From, cfg.SMTP.To))
+	}
+	for _, hook := range cfg.Webhooks {
+		if hook.URL != "" {
+			notifiers = append(notifiers, notify.NewWebhookNotifier(hook.URL, hook.Secret))
+		}
+	}
+
+	logger.Log.Info("Оповещения администраторов настроены", zap.Int("channels", len(notifiers)))
+	return notify.NewDispatcher(cfg.Throttle, notifiers...)
+}
+
+// serveHTTP обслуживает handler на addr до отмены ctx, затем дожидается текущих запросов
+func serveHTTP(ctx context.Context, name, addr string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Log.Info("HTTP-сервер запущен", zap.String("server", name), zap.String("addr", addr))
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("%s server: %w", name, err)
+	case <-ctx.Done():
+	}
+
+	logger.Log.Info("HTTP-сервер завершает работу...", zap.String("server", name))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// opsHandler — служебный сервер воркера и планировщика: метрики и пробы
+func (a *app) opsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.MetricsHandler())
+	a.health.Register(mux)
+	return mux
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"GoBlast/internal/api"
+	"GoBlast/internal/sessions"
+	"GoBlast/internal/worker"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/storage/db"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// command — подкоманда goblast со своим набором флагов
+type command struct {
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"api":       {"HTTP API", runAPI},
+	"worker":    {"рассылка задач из NATS", runWorker},
+	"scheduler": {"периодические задания: очистка сессий, проверка токенов ботов, polling обновлений ботов", runScheduler},
+	"migrate":   {"миграции БД: up (по умолчанию), down, status", runMigrate},
+	"all":       {"все компоненты в одном процессе (по умолчанию)", runAll},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Использование: goblast <команда> [флаги]")
+	fmt.Fprintln(os.Stderr, "\nКоманды:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nФлаги команды: goblast <команда> -h")
+}
+
+// commonFlags — флаги, общие для всех команд
+type commonFlags struct {
+	configPath  string
+	autoMigrate bool
+}
+
+func newFlagSet(name string, common *commonFlags, withMigrate bool) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&common.configPath, "config", defaultConfigPath(), "каталог с config.yaml (по умолчанию $CONFIG_PATH или ./configs)")
+	if withMigrate {
+		fs.BoolVar(&common.autoMigrate, "auto-migrate", true, "применять миграции БД при старте")
+	}
+	return fs
+}
+
+func runAPI(ctx context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("api", &common, true)
+	port := fs.Int("port", 0, "порт HTTP API (по умолчанию app.port из конфигурации)")
+	if err := fs.Parse(args); err != nil {
+		return ignoreHelp(err)
+	}
+
+	a, err := newApp(appOptions{configPath: common.configPath, autoMigrate: common.autoMigrate, nats: true})
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	return startAPI(ctx, a, *port)
+}
+
+func runWorker(ctx context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("worker", &common, true)
+	healthAddr := fs.String("health-addr", ":9090", "адрес служебного сервера (/metrics, /healthz, /readyz)")
+	if err := fs.Parse(args); err != nil {
+		return ignoreHelp(err)
+	}
+
+	a, err := newApp(appOptions{configPath: common.configPath, autoMigrate: common.autoMigrate, nats: true, alerts: true})
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	return runComponents(ctx,
+		func(ctx context.Context) error { return serveHTTP(ctx, "ops", *healthAddr, a.opsHandler()) },
+		func(ctx context.Context) error { return startWorker(ctx, a, newWorkerManager(ctx, a)) },
+	)
+}
+
+func runScheduler(ctx context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("scheduler", &common, true)
+	healthAddr := fs.String("health-addr", ":9091", "адрес служебного сервера (/metrics, /healthz, /readyz)")
+	if err := fs.Parse(args); err != nil {
+		return ignoreHelp(err)
+	}
+
+	a, err := newApp(appOptions{configPath: common.configPath, autoMigrate: common.autoMigrate, nats: true, alerts: true})
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	// Воркеров в этом процессе нет: менеджер только отключает ботов в БД
+	// и прерывает их задачи, воркеры остановятся на первом ответе 401
+	manager := worker.NewBotManager(a.db, nil, a.cfg.Worker)
+
+	return runComponents(ctx,
+		func(ctx context.Context) error { return serveHTTP(ctx, "ops", *healthAddr, a.opsHandler()) },
+		func(ctx context.Context) error { return startScheduler(ctx, a, manager) },
+	)
+}
+
+func runMigrate(_ context.Context, args []string) error {
+	// Действие можно указать до или после флагов: migrate up -config ./configs
+	action := "up"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+
+	var common commonFlags
+	fs := newFlagSet("migrate", &common, false)
+	steps := fs.Int("steps", 1, "сколько последних миграций откатить (для down)")
+	dropSchema := fs.Bool("drop-schema", false, "разрешить откат исходной миграции 0001: удаляет все таблицы с данными")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Использование: goblast migrate [up|down|status] [флаги]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return ignoreHelp(err)
+	}
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+	}
+
+	a, err := newApp(appOptions{configPath: common.configPath})
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	switch action {
+	case "up":
+		applied, err := db.MigrateUp(a.db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Применено миграций: %d\n", len(applied))
+	case "down":
+		if *steps < 1 {
+			return fmt.Errorf("steps must be positive")
+		}
+		reverted, err := db.MigrateDown(a.db, *steps, *dropSchema)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Откачено миграций: %d\n", len(reverted))
+	case "status":
+		states, err := db.MigrationStatus(a.db)
+		if err != nil {
+			return err
+		}
+		for _, st := range states {
+			applied := "не применена"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-30s %s\n", st.Version, st.Name, applied)
+		}
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migrate action %q", action)
+	}
+	return nil
+}
+
+func runAll(ctx context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("all", &common, true)
+	port := fs.Int("port", 0, "порт HTTP API (по умолчанию app.port из конфигурации)")
+	healthAddr := fs.String("health-addr", ":9090", "адрес служебного сервера (/metrics, /healthz, /readyz)")
+	if err := fs.Parse(args); err != nil {
+		return ignoreHelp(err)
+	}
+
+	a, err := newApp(appOptions{configPath: common.configPath, autoMigrate: common.autoMigrate, nats: true, alerts: true})
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	// Планировщик отключает ботов через менеджер воркеров этого процесса,
+	// чтобы их рассылка остановилась сразу
+	manager := newWorkerManager(ctx, a)
+
+	return runComponents(ctx,
+		func(ctx context.Context) error { return startAPI(ctx, a, *port) },
+		func(ctx context.Context) error { return serveHTTP(ctx, "ops", *healthAddr, a.opsHandler()) },
+		func(ctx context.Context) error { return startWorker(ctx, a, manager) },
+		func(ctx context.Context) error { return startScheduler(ctx, a, manager) },
+	)
+}
+
+// ignoreHelp не считает ошибкой вывод справки по флагам (-h)
+func ignoreHelp(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+// runComponents запускает компоненты параллельно; ошибка одного останавливает остальные
+func runComponents(ctx context.Context, components ...func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for _, component := range components {
+		wg.Add(1)
+		go func(run func(ctx context.Context) error) {
+			defer wg.Done()
+			if err := run(ctx); err != nil {
+				once.Do(func() { firstErr = err })
+				cancel()
+			}
+		}(component)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func startAPI(ctx context.Context, a *app, port int) error {
+	if port == 0 {
+		port = a.cfg.App.Port
+	}
+
+	router := api.SetupRouter(a.db, a.nats, a.cfg.App)
+	router.GET("/healthz", gin.WrapH(a.health.LiveHandler()))
+	router.GET("/readyz", gin.WrapH(a.health.ReadyHandler()))
+
+	return serveHTTP(ctx, "api", fmt.Sprintf(":%d", port), router)
+}
+
+func newWorkerManager(ctx context.Context, a *app) *worker.BotManager {
+	return worker.NewBotManager(a.db, initRateLimiter(ctx, a.cfg.RateLimit, a.nats), a.cfg.Worker)
+}
+
+// startWorker принимает задачи из NATS до отмены ctx; без подписки на задачи
+// воркер бесполезен, поэтому её ошибка останавливает процесс
+func startWorker(ctx context.Context, a *app, botManager *worker.BotManager) error {
+	// Боты распределяются между экземплярами воркера через аренды в БД
+	router := worker.NewShardRouter(a.nats, a.db, worker.TaskStarter(a.nats, a.db, botManager), botManager.Handoff)
+	router.Start()
+	defer router.Stop()
+
+	// Простаивающие воркеры ботов останавливаются
+	botManager.Start()
+	defer botManager.Stop()
+
+	// Состояние воркеров для GET /api/admin/workers
+	statusSub, err := worker.ServeStatus(a.nats, botManager, router.ID())
+	if err != nil {
+		logger.Log.Error("Ошибка подписки на запросы состояния воркеров", zap.Error(err))
+	} else {
+		defer statusSub.Unsubscribe()
+	}
+
+	// Отменённые через API задачи перестают рассылаться
+	cancelSub, err := worker.SubscribeTaskCancel(a.nats, botManager)
+	if err != nil {
+		logger.Log.Error("Ошибка подписки на отмену задач", zap.Error(err))
+	} else {
+		defer cancelSub.Unsubscribe()
+	}
+
+	// Ограничения ботов, изменённые через API, применяются без перезапуска
+	limitsSub, err := worker.SubscribeLimitUpdates(a.nats, botManager)
+	if err != nil {
+		logger.Log.Error("Ошибка подписки на изменения ограничений ботов", zap.Error(err))
+	} else {
+		defer limitsSub.Unsubscribe()
+	}
+
+	// Подписка на задачи
+	if err := worker.SubscribeTasks(a.nats, router); err != nil {
+		return fmt.Errorf("subscribe to tasks: %w", err)
+	}
+	logger.Log.Info("Воркер запущен", zap.String("instance", router.ID()))
+
+	// Ожидаем завершения контекста
+	<-ctx.Done()
+
+	// После завершения контекста завершаем работу воркера
+	logger.Log.Info("Завершаем работу воркера...")
+	return nil
+}
+
+// startScheduler выполняет периодические задания до отмены ctx.
+// Планировщик запускается в одном экземпляре: Telegram допускает только один
+// getUpdates на токен, поэтому здесь же идёт polling ботов.
+func startScheduler(ctx context.Context, a *app, botManager *worker.BotManager) error {
+	// Сбор подписчиков ботов в режиме polling
+	updatesManager := worker.NewUpdatesManager(a.db)
+	if err := updatesManager.Start(a.nats); err != nil {
+		return fmt.Errorf("start updates polling: %w", err)
+	}
+	defer updatesManager.Stop()
+
+	logger.Log.Info("Планировщик запущен")
+
+	// Периодическая проверка токенов ботов (getMe)
+	healthChecker := worker.NewBotHealthChecker(a.db, botManager, worker.BotHealthInterval)
+	healthChecker.Start()
+	defer healthChecker.Stop()
+
+	startSessionCleanup(ctx, a.db)
+	logger.Log.Info("Планировщик завершает работу...")
+	return nil
+}
+
+// startSessionCleanup периодически удаляет истёкшие refresh-токены и записи об отзыве
+func startSessionCleanup(ctx context.Context, db *gorm.DB) {
+	repo := sessions.NewSessionRepository(db)
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := repo.PurgeExpired(); err != nil {
+				logger.Log.Error("Ошибка очистки истёкших сессий", zap.Error(err))
+			}
+		}
+	}
+}
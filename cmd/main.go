@@ -1,24 +1,14 @@
 package main
 
 import (
-	"GoBlast/configs"
-	"GoBlast/internal/api"
-	"GoBlast/internal/api/middleware"
-	"GoBlast/internal/worker"
 	"GoBlast/pkg/logger"
-	"GoBlast/pkg/metrics"
-	"GoBlast/pkg/queue"
-	"GoBlast/pkg/storage/db"
 	"context"
 	"fmt"
-	"log"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 
 	_ "GoBlast/docs"
 )
@@ -48,116 +38,31 @@ func main() {
 	}
 	defer logger.SyncLogger()
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		configPath = "./configs"
-	}
-	cfg, err := configs.LoadConfig(configPath)
-	if err != nil {
-		logger.Log.Fatal("Ошибка загрузки конфигурации", zap.Error(err))
+	// Без подкоманды запускаются все компоненты в одном процессе, как раньше
+	name, args := "all", os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
 	}
 
-	logger.Log.Info("Приложение запущено",
-		zap.String("environment", cfg.App.Environment),
-		zap.Int("port", cfg.App.Port),
-	)
-
-	dbConn := initDB(cfg)
-	defer func() {
-		sqlDB, err := dbConn.DB()
-		if err != nil {
-			logger.Log.Error("Ошибка получения sql.DB", zap.Error(err))
-			return
-		}
-		if err := sqlDB.Close(); err != nil {
-			logger.Log.Error("Ошибка закрытия соединения с БД", zap.Error(err))
-		}
-	}()
-
-	natsClient := initQueue(cfg)
-	defer func() {
-		natsClient.Conn.Close()
-		logger.Log.Info("Соединение с NATS закрыто")
-	}()
-
-	middleware.Initialize(cfg)
-
-	go startServer(ctx, cfg, dbConn, natsClient)
-	go startMetrics(ctx)
-	startWorker(ctx, dbConn, natsClient)
-
-	logger.Log.Info("Программа завершена")
-}
-
-func initDB(cfg *configs.Config) *gorm.DB {
-	dsn := configs.GetDSN(cfg.Database)
-	err := db.InitDB(dsn)
-	if err != nil {
-		logger.Log.Fatal("Ошибка инициализации БД", zap.Error(err)) // Завершаем выполнение
+	if name == "help" {
+		usage()
+		return
 	}
-	logger.Log.Info("Соединение с БД установлено", zap.String("dsn", dsn))
-	return db.DB
-}
 
-func initQueue(cfg *configs.Config) *queue.NATSClient {
-	client, err := queue.NewNatsClient(cfg.Broker.URL)
-	if err != nil {
-		logger.Log.Fatal("Ошибка подключения к NATS", zap.Error(err))
-	}
-	if !client.Conn.IsConnected() {
-		logger.Log.Fatal("Соединение с NATS не установлено")
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Неизвестная команда %q\n\n", name)
+		usage()
+		os.Exit(2)
 	}
-	logger.Log.Info("Соединение с NATS установлено", zap.String("url", cfg.Broker.URL))
-	return client
-}
 
-func startServer(ctx context.Context, cfg *configs.Config, db *gorm.DB, natsClient *queue.NATSClient) {
-	router := api.SetupRouter(db, natsClient)
-	server := fmt.Sprintf(":%d", cfg.App.Port)
-
-	go func() {
-		if err := router.Run(server); err != nil {
-			logger.Log.Error("Ошибка запуска сервера", zap.Error(err))
-		}
-	}()
-
-	<-ctx.Done()
-	logger.Log.Info("Сервер завершает работу...")
-}
-
-func startWorker(ctx context.Context, db *gorm.DB, natsClient *queue.NATSClient) {
-	// Инициализация BotManager
-	botManager := worker.NewBotManager()
-
-	go func() {
-		logger.Log.Info("Воркер запущен")
-		// Подписка на задачи
-		if err := worker.SubscribeTasks(natsClient, db, botManager); err != nil {
-			logger.Log.Error("Ошибка подписки на задачи", zap.Error(err))
-			return
-		}
-		logger.Log.Info("Подписка на задачи выполнена")
-	}()
-
-	// Ожидаем завершения контекста
-	<-ctx.Done()
-
-	// После завершения контекста завершаем работу воркера
-	logger.Log.Info("Завершаем работу воркера...")
-}
-
-func startMetrics(ctx context.Context) {
-	metrics.InitMetrics()
-	metrics.RegisterMetricsEndpoint()
-
-	go func() {
-		logger.Log.Info("Metrics endpoint available at /metrics")
-		log.Fatal(http.ListenAndServe(":9090", nil))
-	}()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	<-ctx.Done()
-	logger.Log.Info("Метрики завершают работу...")
+	if err := cmd.run(ctx, args); err != nil {
+		logger.Log.Error("Команда завершилась с ошибкой", zap.String("command", name), zap.Error(err))
+		logger.SyncLogger()
+		os.Exit(1)
+	}
+	logger.Log.Info("Программа завершена", zap.String("command", name))
 }
@@ -4,6 +4,7 @@ import (
 	"GoBlast/pkg/logger"
 	"fmt"
 	"go.uber.org/zap"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -13,6 +14,10 @@ type Config struct {
 	Database     DatabaseConfig     `mapstructure:"database"`
 	Broker       NATSConfig         `mapstructure:"broker"`
 	Encricrypted EncricryptedConfig `mapstructure:"encrypted"`
+	Tracking     TrackingConfig     `mapstructure:"tracking"`
+	Alerts       AlertsConfig       `mapstructure:"alerts"`
+	RateLimit    RateLimitConfig    `mapstructure:"ratelimit"`
+	Worker       WorkerConfig       `mapstructure:"worker"`
 }
 
 type AppConfig struct {
@@ -20,6 +25,9 @@ type AppConfig struct {
 	Environment string `mapstructure:"environment"`
 	Port        int    `mapstructure:"port"`
 	JWTSecret   string `mapstructure:"jwt_secret"`
+	PublicURL   string `mapstructure:"public_url"` // внешний адрес API (для вебхуков Telegram)
+	// LegacyTokenLogin разрешает аккаунтам без пароля входить по токену бота (в обход пароля и 2FA)
+	LegacyTokenLogin bool `mapstructure:"legacy_token_login"`
 }
 
 type DatabaseConfig struct {
@@ -39,6 +47,81 @@ type EncricryptedConfig struct {
 	EncryptionKey string `mapstructure:"encryption_key"`
 }
 
+// WorkerConfig — воркеры ботов в одном экземпляре: жизненный цикл и ограничения
+// по умолчанию (переопределяются для отдельного бота через /api/bots/{id}/limits)
+type WorkerConfig struct {
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"` // воркер без задач дольше останавливается
+	MaxActive   int           `mapstructure:"max_active"`   // предел одновременно запущенных воркеров
+	Concurrency int           `mapstructure:"concurrency"`  // горутин отправки на бота
+	QueueSize   int           `mapstructure:"queue_size"`   // буфер очереди получателей воркера
+	Rates       PriorityRates `mapstructure:"rates"`
+	Retry       RetryConfig   `mapstructure:"retry"`
+}
+
+// PriorityRates — скорость отправки бота (msg/sec) по приоритету задачи
+type PriorityRates struct {
+	High   float64 `mapstructure:"high"`
+	Medium float64 `mapstructure:"medium"`
+	Low    float64 `mapstructure:"low"`
+}
+
+// RetryConfig — повтор отправки при временных ошибках Telegram (5xx, 429)
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"` // всего попыток; 1 — без повторов
+	Backoff     time.Duration `mapstructure:"backoff"`      // пауза перед первым повтором, дальше удваивается
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"`
+}
+
+// RateLimitConfig — где хранятся бюджеты отправок ботов
+type RateLimitConfig struct {
+	Backend string `mapstructure:"backend"` // local — в памяти реплики, nats — общий JetStream KV
+	Bucket  string `mapstructure:"bucket"`  // имя KV-бакета для backend=nats
+}
+
+type TrackingConfig struct {
+	BaseURL string `mapstructure:"base_url"` // публичный адрес для ссылок /r/:code
+	Secret  string `mapstructure:"secret"`   // ключ подписи кодов ссылок
+}
+
+// AlertsConfig — каналы и правила оповещений администраторов
+type AlertsConfig struct {
+	Throttle time.Duration        `mapstructure:"throttle"` // окно подавления повторов одного оповещения
+	Telegram TelegramAlertConfig  `mapstructure:"telegram"`
+	SMTP     SMTPAlertConfig      `mapstructure:"smtp"`
+	Webhooks []WebhookAlertConfig `mapstructure:"webhooks"`
+	Rules    AlertRulesConfig     `mapstructure:"rules"`
+}
+
+// TelegramAlertConfig — системный бот и чат администраторов (канал выключен без bot_token)
+type TelegramAlertConfig struct {
+	BotToken string `mapstructure:"bot_token"`
+	ChatID   int64  `mapstructure:"chat_id"`
+}
+
+// SMTPAlertConfig — почта (канал выключен без host)
+type SMTPAlertConfig struct {
+	Host     string   `mapstructure:"host"`
+	Port     int      `mapstructure:"port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+type WebhookAlertConfig struct {
+	URL    string `mapstructure:"url"`
+	Secret string `mapstructure:"secret"` // подпись тела в X-GoBlast-Signature
+}
+
+// AlertRulesConfig — когда оповещать; нулевое значение выключает правило
+type AlertRulesConfig struct {
+	TaskFailedPercent       float64       `mapstructure:"task_failed_percent"`        // доля неудачных отправок задачи, %
+	TaskFailedMinRecipients int64         `mapstructure:"task_failed_min_recipients"` // не оповещать о маленьких задачах
+	BotUnauthorized         bool          `mapstructure:"bot_unauthorized"`
+	DLQThreshold            int           `mapstructure:"dlq_threshold"` // сообщений в tasks.dlq за окно dlq_window
+	DLQWindow               time.Duration `mapstructure:"dlq_window"`
+}
+
 var AppConfigInstance *Config
 
 func LoadConfig(path string) (*Config, error) {
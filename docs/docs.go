@@ -23,32 +23,24 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "Authenticates the user and returns a JWT token",
-                "consumes": [
-                    "application/json"
+        "/admin/workers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Опрашивает все экземпляры воркера через NATS. Показываются только боты организации; общее число воркеров — по всем экземплярам.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
-                ],
-                "parameters": [
-                    {
-                        "description": "User credentials (username and token)",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_api_handlers.LoginInput"
-                        }
-                    }
+                    "Admin"
                 ],
+                "summary": "Состояние воркеров",
                 "responses": {
                     "200": {
-                        "description": "JWT token",
+                        "description": "Состояние воркеров",
                         "schema": {
                             "allOf": [
                                 {
@@ -58,30 +50,27 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object",
-                                            "additionalProperties": {
-                                                "type": "string"
-                                            }
+                                            "$ref": "#/definitions/internal_api_handlers.WorkersStatusResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Invalid input",
+                    "401": {
+                        "description": "Неавторизованный доступ",
                         "schema": {
                             "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
                         }
                     },
-                    "401": {
-                        "description": "Invalid credentials",
+                    "403": {
+                        "description": "Нужна роль admin",
                         "schema": {
                             "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
                         }
                     },
-                    "500": {
-                        "description": "Failed to generate token",
+                    "502": {
+                        "description": "NATS недоступен",
                         "schema": {
                             "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
                         }
@@ -89,44 +78,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/register": {
-            "post": {
-                "description": "Создаёт новый аккаунт пользователя с уникальным именем и Telegram Bot Token.",
-                "consumes": [
-                    "application/json"
+        "/audiences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
-                ],
-                "parameters": [
-                    {
-                        "description": "User registration data",
-                        "name": "register",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_api_handlers.RegisterInput"
-                        }
-                    }
+                    "Audiences"
                 ],
+                "summary": "Список аудиторий",
                 "responses": {
-                    "201": {
-                        "description": "Пользователь успешно зарегистрирован",
-                        "schema": {
-                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Некорректные входные данные",
+                    "200": {
+                        "description": "Аудитории",
                         "schema": {
-                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/GoBlast_pkg_storage_models.Audience"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "409": {
-                        "description": "Имя пользователя уже существует",
+                    "401": {
+                        "description": "Неавторизованный доступ",
                         "schema": {
                             "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
                         }
@@ -138,16 +127,13 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/tasks": {
+            },
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Создаёт новую задачу для отправки сообщений через Telegram.",
                 "consumes": [
                     "application/json"
                 ],
@@ -155,25 +141,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tasks"
+                    "Audiences"
                 ],
-                "summary": "Создать задачу",
+                "summary": "Создать аудиторию",
                 "parameters": [
                     {
-                        "description": "Создание задачи",
-                        "name": "task",
+                        "description": "Аудитория",
+                        "name": "audience",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_api_handlers.TaskRequest"
+                            "$ref": "#/definitions/internal_api_handlers.AudienceInput"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Задача успешно создана",
+                        "description": "Аудитория создана",
                         "schema": {
-                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/GoBlast_pkg_storage_models.Audience"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -197,28 +195,24 @@ const docTemplate = `{
                 }
             }
         },
-        "/tasks/{id}": {
+        "/audiences/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает детали задачи по её ID",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tasks"
+                    "Audiences"
                 ],
-                "summary": "Получить задачу",
+                "summary": "Получить аудиторию",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID задачи",
+                        "type": "integer",
+                        "description": "ID аудитории",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -226,7 +220,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Детали задачи",
+                        "description": "Аудитория",
                         "schema": {
                             "allOf": [
                                 {
@@ -236,124 +230,3260 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/GoBlast_pkg_storage_models.Task"
+                                            "$ref": "#/definitions/GoBlast_pkg_storage_models.Audience"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "401": {
-                        "description": "Неавторизованный доступ",
+                    "404": {
+                        "description": "Аудитория не найдена",
                         "schema": {
                             "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
                         }
-                    },
-                    "404": {
-                        "description": "Задача не найдена",
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audiences"
+                ],
+                "summary": "Удалить аудиторию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID аудитории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Аудитория удалена",
                         "schema": {
                             "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "404": {
+                        "description": "Аудитория не найдена",
                         "schema": {
                             "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "GoBlast_pkg_response.APIResponse": {
-            "type": "object",
-            "properties": {
-                "data": {},
-                "error": {
-                    "type": "string"
-                },
-                "success": {
-                    "type": "boolean"
-                }
-            }
-        },
-        "GoBlast_pkg_storage_models.Task": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
-                "deletedAt": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "messageType": {
-                    "type": "string"
-                },
-                "priority": {
-                    "type": "string"
-                },
-                "schedule": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "string"
-                },
-                "updatedAt": {
-                    "type": "string"
-                },
-                "userID": {
-                    "type": "integer"
-                }
-            }
         },
-        "gorm.DeletedAt": {
-            "type": "object",
-            "properties": {
-                "time": {
-                    "type": "string"
-                },
-                "valid": {
-                    "description": "Valid is true if Time is not NULL",
-                    "type": "boolean"
+        "/audiences/{id}/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "CSV с колонками chat_id и (необязательно) tags, теги разделяются ';'. Строка заголовка пропускается.\nФайл передаётся полем \"file\" (multipart/form-data) или телом запроса (text/csv).",
+                "consumes": [
+                    "multipart/form-data",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audiences"
+                ],
+                "summary": "Импорт участников из CSV",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID аудитории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "CSV-файл",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Участники импортированы",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный CSV",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Аудитория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
                 }
             }
         },
-        "internal_api_handlers.Content": {
-            "type": "object",
-            "required": [
-                "type"
-            ],
-            "properties": {
-                "caption": {
-                    "type": "string"
-                },
-                "media_url": {
-                    "type": "string"
-                },
-                "text": {
-                    "type": "string"
-                },
-                "type": {
-                    "description": "text, photo, video, etc.",
-                    "type": "string"
-                }
+        "/audiences/{id}/members": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет chat ID пачкой. Теги существующих участников объединяются с переданными.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audiences"
+                ],
+                "summary": "Добавить участников",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID аудитории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Участники",
+                        "name": "members",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.MembersInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Участники добавлены",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Аудитория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audiences"
+                ],
+                "summary": "Удалить участников",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID аудитории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Участники",
+                        "name": "members",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.MembersInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Участники удалены",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Аудитория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/audiences/{id}/tags": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audiences"
+                ],
+                "summary": "Изменить теги участников",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID аудитории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Теги",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.TagMembersInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Теги изменены",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Аудитория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Authenticates the user by password (and TOTP code if enabled) and returns a short-lived JWT access token and a refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "parameters": [
+                    {
+                        "description": "User credentials",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.LoginInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Access and refresh tokens",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/internal_api_handlers.SessionTokens"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to generate token",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает текущий access-токен и выданный вместе с ним refresh-токен.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Выйти",
+                "responses": {
+                    "200": {
+                        "description": "Сессия завершена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Выйти на всех устройствах",
+                "responses": {
+                    "200": {
+                        "description": "Все сессии завершены",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Аккаунты, созданные до появления паролей, задают пароль без current_password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Сменить пароль",
+                "parameters": [
+                    {
+                        "description": "Пароли",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.PasswordInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пароль изменён",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный текущий пароль",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Обменивает refresh-токен на новую пару токенов. Старый refresh-токен перестаёт действовать;\nего повторное предъявление считается утечкой и завершает все сессии пользователя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Обновить токены",
+                "parameters": [
+                    {
+                        "description": "Refresh-токен",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.RefreshInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новая пара токенов",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/internal_api_handlers.SessionTokens"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Refresh-токен недействителен",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Создаёт организацию и её администратора с логином и паролем. Токен бота необязателен: ботов можно добавить позже через /bots.\nПереданный токен проверяется через getMe; бот, уже подключённый к другому аккаунту, отклоняется.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "parameters": [
+                    {
+                        "description": "User registration data",
+                        "name": "register",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.RegisterInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Пользователь успешно зарегистрирован",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные или токен бота отклонён Telegram",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя пользователя уже существует или бот уже подключён",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Telegram недоступен",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/totp/disable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Выключить TOTP",
+                "parameters": [
+                    {
+                        "description": "Код",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.TOTPCodeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "TOTP выключен",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный код",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/totp/enable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Включить TOTP",
+                "parameters": [
+                    {
+                        "description": "Код",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.TOTPCodeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "TOTP включён",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный код или секрет не выдан",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/totp/setup": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Генерирует секрет и otpauth-ссылку. Второй фактор включается после подтверждения кодом через /auth/totp/enable.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Подготовить TOTP",
+                "responses": {
+                    "200": {
+                        "description": "Секрет и otpauth-ссылка",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "TOTP уже включён",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/bots": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Вместе со статусом (active, disabled, unauthorized) возвращаются результаты последней проверки getMe.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bots"
+                ],
+                "summary": "Список ботов",
+                "responses": {
+                    "200": {
+                        "description": "Боты",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/GoBlast_pkg_storage_models.Bot"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Проверяет токен через getMe и сохраняет его в зашифрованном виде вместе с ID, username и именем бота.\nБот, уже подключённый к этой или другой организации, повторно не добавляется.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bots"
+                ],
+                "summary": "Добавить бота",
+                "parameters": [
+                    {
+                        "description": "Бот",
+                        "name": "bot",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.BotInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Бот добавлен",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/GoBlast_pkg_storage_models.Bot"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные или токен отклонён Telegram",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Бот уже подключён",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Telegram недоступен",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/bots/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bots"
+                ],
+                "summary": "Получить бота",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID бота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Бот",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/GoBlast_pkg_storage_models.Bot"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Бот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bots"
+                ],
+                "summary": "Удалить бота",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID бота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Бот удалён",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Бот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "status=disabled запрещает создавать задачи от имени бота.\nНовый токен проверяется через getMe и должен принадлежать тому же боту;\nбот в статусе unauthorized (токен отозван) активируется только вместе с новым токеном.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bots"
+                ],
+                "summary": "Изменить бота",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID бота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменения",
+                        "name": "bot",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.BotUpdateInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Бот изменён",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/GoBlast_pkg_storage_models.Bot"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные или токен отклонён Telegram",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Бот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Токен принадлежит другому боту",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/bots/{id}/limits": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Конкурентность, скорости по приоритетам, размер очереди и число попыток: переопределения бота и действующие значения.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bots"
+                ],
+                "summary": "Ограничения бота",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID бота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ограничения",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/internal_api_handlers.BotLimitsResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Бот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Заменяет переопределения бота целиком. Работающие воркеры применяют конкурентность, скорости и повторы сразу, размер очереди — при следующем запуске.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bots"
+                ],
+                "summary": "Изменить ограничения бота",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID бота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Переопределения",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.BotLimitsInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ограничения сохранены",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/internal_api_handlers.BotLimitsResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Недопустимые значения",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нужна роль admin",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Бот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bots"
+                ],
+                "summary": "Сбросить ограничения бота",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID бота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Действуют значения из конфигурации",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/internal_api_handlers.BotLimitsResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Нужна роль admin",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Бот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Список API-ключей",
+                "responses": {
+                    "200": {
+                        "description": "Ключи (без секретов)",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/GoBlast_pkg_storage_models.APIKey"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ключ действует от имени текущего пользователя с его ролью и показывается только в этом ответе.\nПередаётся в заголовке X-API-Key или Authorization: Bearer gb_...",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Выпустить API-ключ",
+                "parameters": [
+                    {
+                        "description": "Ключ",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.APIKeyInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Ключ выпущен",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Отозвать API-ключ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ключ отозван",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден или уже отозван",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/org": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Организация",
+                "responses": {
+                    "200": {
+                        "description": "Организация и пользователи",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/org/users": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Добавить пользователя",
+                "parameters": [
+                    {
+                        "description": "Пользователь",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.MemberInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Пользователь добавлен",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/GoBlast_pkg_storage_models.AuthUser"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нужна роль admin",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя пользователя уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/org/users/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Удалить пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь удалён",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нужна роль admin",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Изменить роль",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Роль",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.RoleInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роль изменена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нужна роль admin",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/r/{code}": {
+            "get": {
+                "description": "Проверяет подпись кода, записывает клик получателя и делает редирект на исходный URL.",
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Переход по отслеживаемой ссылке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Код ссылки",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Редирект на исходную ссылку"
+                    },
+                    "404": {
+                        "description": "Ссылка не найдена"
+                    }
+                }
+            }
+        },
+        "/reports": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сводка по завершённым и идущим рассылкам пользователя и его организации: отправлено и ошибок,\nразбивка по кодам ошибок, средняя скорость отправки (сообщений в секунду), средняя задержка\nот создания задачи до окончания рассылки (секунды) и доля получателей, заблокировавших бота.\nДни и недели считаются по UTC, задачи попадают в период по дате создания.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Отчёт по рассылкам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало периода YYYY-MM-DD (по умолчанию — 7 дней до to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода YYYY-MM-DD включительно (по умолчанию — сегодня)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Измерения через запятую: day, week, bot, content_type (по умолчанию day)",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (по умолчанию) или csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отчёт",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/GoBlast_internal_reports.Report"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscribers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Подписчики, собранные из обновлений ботов пользователя и его организации, и их количество по статусам.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscribers"
+                ],
+                "summary": "Список подписчиков",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "active, stopped, blocked",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 100, максимум 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписчики",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscribers/mode": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Для бота из /api/bots (bot_id) или, без bot_id, бота по умолчанию аккаунта. polling — планировщик (goblast scheduler) опрашивает Telegram (getUpdates), webhook — Telegram присылает обновления на /webhook/bots/{bot_id} (бот по умолчанию — на /webhook/{user_id}), off — сбор выключен.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscribers"
+                ],
+                "summary": "Режим получения обновлений бота",
+                "parameters": [
+                    {
+                        "description": "Режим",
+                        "name": "mode",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.UpdatesModeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Режим изменён",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Бот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Telegram отклонил настройку вебхука",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт новую задачу для отправки сообщений через Telegram.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Создать задачу",
+                "parameters": [
+                    {
+                        "description": "Создание задачи",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.TaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Задача успешно создана",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает детали задачи по её ID вместе со статистикой доставки (объект stats)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Получить задачу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Детали задачи",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/GoBlast_pkg_storage_models.Task"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переводит задачу в cancelled; воркер пропускает получателей, которым сообщение ещё не отправлено.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Отменить рассылку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Задача отменена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Задача уже завершена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/edit": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт задачу, которая меняет текст, подпись или кнопки у всех отправленных сообщений задачи, включая рассылку победителя A/B-теста. Без buttons кнопки исходной рассылки сохраняются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Отредактировать рассылку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое содержимое",
+                        "name": "edit",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.EditTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Задача редактирования создана",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Рассылка ещё не завершена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Смены статуса задачи по времени: из какого в какой, кто изменил (user:\u003cid\u003e, worker или system) и почему",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "История статусов задачи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "История статусов",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/GoBlast_pkg_storage_models.TaskStatusHistory"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/recall": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт задачу, которая удаляет все отправленные сообщения задачи, включая рассылку победителя A/B-теста.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Отозвать рассылку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Задача удаления создана",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Рассылка ещё не завершена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/recipients": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Принимает Telegram Chat ID по одному в строке (text/plain) для задачи, созданной с upload_recipients=true.\nТело читается потоком, получатели публикуются в NATS частями по мере чтения. Пустые строки пропускаются, некорректные — считаются в invalid_lines.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Загрузить получателей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Chat ID по одному в строке",
+                        "name": "recipients",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Получатели поставлены в очередь",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Нет корректных получателей или ошибка чтения",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Получатели уже загружены",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/winner": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отправляет выбранный (или лучший по доставке) вариант получателям, не попавшим в тестовую выборку.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Отправить победителя A/B-теста",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Вариант-победитель",
+                        "name": "winner",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.WinnerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Задача рассылки победителя создана",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизованный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Тест ещё не завершён или победитель уже отправлен",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/GoBlast_pkg_response.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook/bots/{bot_id}": {
+            "post": {
+                "description": "Endpoint, который GoBlast регистрирует в Telegram в режиме webhook. Проверяет X-Telegram-Bot-Api-Secret-Token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscribers"
+                ],
+                "summary": "Вебхук Telegram (бот организации)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID бота",
+                        "name": "bot_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновление принято"
+                    },
+                    "401": {
+                        "description": "Неверный секрет"
+                    }
+                }
+            }
+        },
+        "/webhook/{user_id}": {
+            "post": {
+                "description": "Endpoint, который GoBlast регистрирует в Telegram в режиме webhook. Проверяет X-Telegram-Bot-Api-Secret-Token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscribers"
+                ],
+                "summary": "Вебхук Telegram (бот по умолчанию)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновление принято"
+                    },
+                    "401": {
+                        "description": "Неверный секрет"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "GoBlast_internal_bots.Limits": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "type": "integer"
+                },
+                "queue_size": {
+                    "type": "integer"
+                },
+                "rate_high": {
+                    "type": "number"
+                },
+                "rate_low": {
+                    "type": "number"
+                },
+                "rate_medium": {
+                    "type": "number"
+                },
+                "retry_max_attempts": {
+                    "type": "integer"
+                }
+            }
+        },
+        "GoBlast_internal_reports.Report": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/GoBlast_internal_reports.Row"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                },
+                "totals": {
+                    "$ref": "#/definitions/GoBlast_internal_reports.Row"
+                }
+            }
+        },
+        "GoBlast_internal_reports.Row": {
+            "type": "object",
+            "properties": {
+                "avg_latency": {
+                    "description": "секунд от создания задачи до завершения рассылки",
+                    "type": "number"
+                },
+                "avg_throughput": {
+                    "description": "сообщений в секунду",
+                    "type": "number"
+                },
+                "block_rate": {
+                    "description": "blocked / (sent + failed)",
+                    "type": "number"
+                },
+                "blocked": {
+                    "description": "ошибки «бот заблокирован получателем»",
+                    "type": "integer"
+                },
+                "bot_id": {
+                    "description": "0 — бот по умолчанию пользователя",
+                    "type": "integer"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "day": {
+                    "type": "string"
+                },
+                "error_counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "expected": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "failure_rate": {
+                    "description": "failed / (sent + failed)",
+                    "type": "number"
+                },
+                "retried": {
+                    "type": "integer"
+                },
+                "sent": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "tasks": {
+                    "type": "integer"
+                },
+                "week": {
+                    "type": "string"
+                }
+            }
+        },
+        "GoBlast_internal_worker.ManagerStatus": {
+            "type": "object",
+            "properties": {
+                "active_workers": {
+                    "type": "integer"
+                },
+                "idle_timeout": {
+                    "type": "string"
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "max_workers": {
+                    "type": "integer"
+                },
+                "workers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/GoBlast_internal_worker.WorkerStatus"
+                    }
+                }
+            }
+        },
+        "GoBlast_internal_worker.WorkerStatus": {
+            "type": "object",
+            "properties": {
+                "bot": {
+                    "description": "bot:\u003cid\u003e или user:\u003cid\u003e",
+                    "type": "string"
+                },
+                "bot_id": {
+                    "type": "integer"
+                },
+                "goroutines": {
+                    "type": "integer"
+                },
+                "in_flight_tasks": {
+                    "type": "integer"
+                },
+                "last_active": {
+                    "type": "string"
+                },
+                "queue_depth": {
+                    "description": "получателей, ещё не обработанных в текущих задачах",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "GoBlast_pkg_response.APIResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "GoBlast_pkg_storage_models.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "org_id": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "description": "видимая часть ключа: gb_\u003cprefix\u003e_...",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "через пробел, например \"tasks:read tasks:write\"",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "от чьего имени действует ключ",
+                    "type": "integer"
+                }
+            }
+        },
+        "GoBlast_pkg_storage_models.Audience": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "member_count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "org_id": {
+                    "description": "организация автора: аудиторию видят все её участники",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "GoBlast_pkg_storage_models.AuthUser": {
+            "type": "object",
+            "properties": {
+                "bot_name": {
+                    "type": "string"
+                },
+                "bot_status": {
+                    "description": "active, unauthorized",
+                    "type": "string"
+                },
+                "bot_telegram_id": {
+                    "description": "Данные бота по умолчанию из getMe",
+                    "type": "integer"
+                },
+                "bot_username": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "Время создания записи",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "org_id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "description": "admin, editor, viewer",
+                    "type": "string"
+                },
+                "totp_enabled": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "description": "Время последнего обновления",
+                    "type": "string"
+                },
+                "updates_mode": {
+                    "description": "off, polling, webhook",
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Уникальное имя пользователя",
+                    "type": "string"
+                }
+            }
+        },
+        "GoBlast_pkg_storage_models.Bot": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_check_error": {
+                    "description": "сбой связи с Telegram при последней проверке",
+                    "type": "string"
+                },
+                "last_checked_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "org_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "status_reason": {
+                    "description": "Результаты проверки здоровья (getMe)",
+                    "type": "string"
+                },
+                "telegram_id": {
+                    "description": "Данные из getMe: по TelegramID обнаруживается один бот, добавленный дважды.\nСреди неудалённых ботов TelegramID уникален (индекс idx_bots_telegram_id_active в миграции 0006).",
+                    "type": "integer"
+                },
+                "telegram_name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "updates_mode": {
+                    "description": "Сбор подписчиков из обновлений бота",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "кто добавил бота",
+                    "type": "integer"
+                },
+                "username": {
+                    "description": "@username из getMe",
+                    "type": "string"
+                }
+            }
+        },
+        "GoBlast_pkg_storage_models.BotLimit": {
+            "type": "object",
+            "properties": {
+                "bot_id": {
+                    "type": "integer"
+                },
+                "concurrency": {
+                    "description": "горутин отправки",
+                    "type": "integer"
+                },
+                "queue_size": {
+                    "description": "буфер очереди получателей",
+                    "type": "integer"
+                },
+                "rate_high": {
+                    "description": "msg/sec при приоритете high",
+                    "type": "number"
+                },
+                "rate_low": {
+                    "description": "msg/sec при приоритете low",
+                    "type": "number"
+                },
+                "rate_medium": {
+                    "description": "msg/sec при приоритете medium",
+                    "type": "number"
+                },
+                "retry_max_attempts": {
+                    "description": "попыток отправки одному получателю",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "GoBlast_pkg_storage_models.ClickStats": {
+            "type": "object",
+            "properties": {
+                "by_url": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "by_variant": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "total_clicks": {
+                    "type": "integer"
+                },
+                "unique_recipients": {
+                    "type": "integer"
+                }
+            }
+        },
+        "GoBlast_pkg_storage_models.Stats": {
+            "type": "object",
+            "properties": {
+                "by_content_type": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "by_variant": {
+                    "description": "для A/B-тестов",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/GoBlast_pkg_storage_models.VariantStats"
+                    }
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "error_counts": {
+                    "description": "по кодам ошибок Telegram",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "expected_count": {
+                    "description": "получателей выложено в очередь",
+                    "type": "integer"
+                },
+                "processed_count": {
+                    "description": "из них обработано (отправлено или с ошибкой)",
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "throughput": {
+                    "description": "отправлено сообщений в секунду",
+                    "type": "number"
+                },
+                "time_spent": {
+                    "description": "секунды",
+                    "type": "number"
+                },
+                "total_failed": {
+                    "type": "integer"
+                },
+                "total_retried": {
+                    "description": "повторных попыток отправки",
+                    "type": "integer"
+                },
+                "total_sent": {
+                    "type": "integer"
+                },
+                "total_skipped": {
+                    "description": "остановили или заблокировали бота",
+                    "type": "integer"
+                }
+            }
+        },
+        "GoBlast_pkg_storage_models.Task": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "send, edit, recall",
+                    "type": "string"
+                },
+                "audience_id": {
+                    "description": "получатели из аудитории",
+                    "type": "integer"
+                },
+                "bot_id": {
+                    "description": "бот рассылки; nil — бот из AuthUser.Token",
+                    "type": "integer"
+                },
+                "clicks": {
+                    "description": "заполняется при чтении, если отслеживались клики",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/GoBlast_pkg_storage_models.ClickStats"
+                        }
+                    ]
+                },
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "language_code": {
+                    "type": "string"
+                },
+                "messageType": {
+                    "type": "string"
+                },
+                "org_id": {
+                    "description": "организация автора: задачу видят все её участники",
+                    "type": "integer"
+                },
+                "parentID": {
+                    "description": "для edit/recall и победителя A/B-теста: исходная задача",
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "type": "string"
+                },
+                "stats": {
+                    "$ref": "#/definitions/GoBlast_pkg_storage_models.Stats"
+                },
+                "status": {
+                    "$ref": "#/definitions/GoBlast_pkg_storage_models.TaskStatus"
+                },
+                "status_reason": {
+                    "description": "почему задача прервана",
+                    "type": "string"
+                },
+                "tag_expr": {
+                    "type": "string"
+                },
+                "to_subscribers": {
+                    "description": "получатели — подписчики бота",
+                    "type": "boolean"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "integer"
+                },
+                "variants": {
+                    "description": "варианты A/B-теста",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "растёт с каждой сменой статуса",
+                    "type": "integer"
+                }
+            }
+        },
+        "GoBlast_pkg_storage_models.TaskStatus": {
+            "type": "string",
+            "enum": [
+                "draft",
+                "scheduled",
+                "queued",
+                "running",
+                "cancelled",
+                "complete",
+                "partially_failed",
+                "failed"
+            ],
+            "x-enum-comments": {
+                "TaskCancelled": "отменена пользователем (POST /tasks/{id}/cancel)",
+                "TaskDraft": "создана, получатели ещё загружаются",
+                "TaskFailed": "причина в status_reason",
+                "TaskPartiallyFailed": "часть получателей не получила сообщение",
+                "TaskQueued": "опубликована в NATS",
+                "TaskRunning": "воркер рассылает сообщения",
+                "TaskScheduled": "принята, ещё не передана воркерам"
+            },
+            "x-enum-varnames": [
+                "TaskDraft",
+                "TaskScheduled",
+                "TaskQueued",
+                "TaskRunning",
+                "TaskCancelled",
+                "TaskComplete",
+                "TaskPartiallyFailed",
+                "TaskFailed"
+            ]
+        },
+        "GoBlast_pkg_storage_models.TaskStatusHistory": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "user:\u003cid\u003e, worker или system",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "from_status": {
+                    "description": "пусто — задача создана",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/GoBlast_pkg_storage_models.TaskStatus"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "string"
+                },
+                "to_status": {
+                    "$ref": "#/definitions/GoBlast_pkg_storage_models.TaskStatus"
+                }
+            }
+        },
+        "GoBlast_pkg_storage_models.VariantStats": {
+            "type": "object",
+            "properties": {
+                "error_counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "sent": {
+                    "type": "integer"
+                }
+            }
+        },
+        "gorm.DeletedAt": {
+            "type": "object",
+            "properties": {
+                "time": {
+                    "type": "string"
+                },
+                "valid": {
+                    "description": "Valid is true if Time is not NULL",
+                    "type": "boolean"
+                }
+            }
+        },
+        "internal_api_handlers.APIKeyInput": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "description": "0 — бессрочный",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "tasks:read, tasks:write",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_api_handlers.AudienceInput": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.BotInput": {
+            "type": "object",
+            "required": [
+                "name",
+                "token"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Telegram Bot Token",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.BotLimitsInput": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "type": "integer"
+                },
+                "queue_size": {
+                    "type": "integer"
+                },
+                "rate_high": {
+                    "type": "number"
+                },
+                "rate_low": {
+                    "type": "number"
+                },
+                "rate_medium": {
+                    "type": "number"
+                },
+                "retry_max_attempts": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_api_handlers.BotLimitsResponse": {
+            "type": "object",
+            "properties": {
+                "effective": {
+                    "$ref": "#/definitions/GoBlast_internal_bots.Limits"
+                },
+                "override": {
+                    "description": "null — переопределений нет",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/GoBlast_pkg_storage_models.BotLimit"
+                        }
+                    ]
+                }
+            }
+        },
+        "internal_api_handlers.BotUpdateInput": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "active, disabled",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "новый токен того же бота (после /revoke в @BotFather)",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.Button": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.Contact": {
+            "type": "object",
+            "properties": {
+                "first_name": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.Content": {
+            "type": "object",
+            "properties": {
+                "buttons": {
+                    "description": "inline-кнопки со ссылками (кроме type=\"forward\")",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/internal_api_handlers.Button"
+                        }
+                    }
+                },
+                "caption": {
+                    "description": "подпись",
+                    "type": "string"
+                },
+                "contact": {
+                    "description": "если type=\"contact\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_api_handlers.Contact"
+                        }
+                    ]
+                },
+                "emoji": {
+                    "description": "если type=\"dice\": 🎲, 🎯, 🏀, ⚽, 🎰, 🎳",
+                    "type": "string"
+                },
+                "from_chat_id": {
+                    "description": "если type=\"copy\"/\"forward\": чат с исходным сообщением",
+                    "type": "integer"
+                },
+                "location": {
+                    "description": "если type=\"location\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_api_handlers.Location"
+                        }
+                    ]
+                },
+                "media_id": {
+                    "description": "если передается media_id (в т. ч. для type=\"sticker\")",
+                    "type": "string"
+                },
+                "media_url": {
+                    "description": "если передается URL",
+                    "type": "string"
+                },
+                "message_id": {
+                    "description": "если type=\"copy\"/\"forward\": ID исходного сообщения",
+                    "type": "integer"
+                },
+                "poll": {
+                    "description": "если type=\"poll\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_api_handlers.Poll"
+                        }
+                    ]
+                },
+                "text": {
+                    "description": "если type=\"text\"",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "\"text\", \"photo\", \"video\", \"poll\", \"location\", ...",
+                    "type": "string"
+                },
+                "venue": {
+                    "description": "если type=\"venue\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_api_handlers.Venue"
+                        }
+                    ]
+                }
+            }
+        },
+        "internal_api_handlers.EditTaskRequest": {
+            "type": "object",
+            "properties": {
+                "buttons": {
+                    "description": "пусто — остаются кнопки исходной рассылки",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/internal_api_handlers.Button"
+                        }
+                    }
+                },
+                "caption": {
+                    "description": "для рассылок с медиа",
+                    "type": "string"
+                },
+                "text": {
+                    "description": "для текстовых рассылок",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.Location": {
+            "type": "object",
+            "properties": {
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                }
             }
         },
         "internal_api_handlers.LoginInput": {
             "type": "object",
             "required": [
-                "token",
                 "username"
             ],
             "properties": {
+                "password": {
+                    "type": "string"
+                },
                 "token": {
+                    "description": "Токен бота — только для аккаунтов, созданных до появления паролей, и только\nпри app.legacy_token_login; после входа задайте пароль через POST /auth/password",
+                    "type": "string"
+                },
+                "totp_code": {
+                    "description": "обязателен, если включена двухфакторная аутентификация",
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.MemberInput": {
+            "type": "object",
+            "required": [
+                "password",
+                "role",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "admin, editor, viewer",
                     "type": "string"
                 },
                 "username": {
@@ -361,14 +3491,101 @@ const docTemplate = `{
                 }
             }
         },
+        "internal_api_handlers.MembersInput": {
+            "type": "object",
+            "required": [
+                "chat_ids"
+            ],
+            "properties": {
+                "chat_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "tags": {
+                    "description": "теги для добавляемых участников",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_api_handlers.PasswordInput": {
+            "type": "object",
+            "required": [
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "description": "не нужен, если пароль ещё не задан",
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.Poll": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "description": "по умолчанию true",
+                    "type": "boolean"
+                },
+                "correct_option": {
+                    "description": "индекс правильного ответа, обязателен для quiz",
+                    "type": "integer"
+                },
+                "explanation": {
+                    "type": "string"
+                },
+                "multiple_answers": {
+                    "type": "boolean"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "question": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "regular (по умолчанию) или quiz",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.RefreshInput": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
         "internal_api_handlers.RegisterInput": {
             "type": "object",
             "required": [
-                "token",
+                "password",
                 "username"
             ],
             "properties": {
+                "organization": {
+                    "description": "по умолчанию — имя пользователя",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
                 "token": {
+                    "description": "Telegram Bot Token бота по умолчанию (необязателен)",
                     "type": "string"
                 },
                 "username": {
@@ -376,15 +3593,92 @@ const docTemplate = `{
                 }
             }
         },
-        "internal_api_handlers.TaskRequest": {
+        "internal_api_handlers.RoleInput": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.SessionTokens": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "срок жизни access-токена, секунд",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "description": "одноразовый: при обновлении выдаётся новый",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "access-токен (JWT)",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.TOTPCodeInput": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.TagMembersInput": {
             "type": "object",
             "required": [
-                "content",
-                "recipients"
+                "chat_ids"
             ],
             "properties": {
+                "add": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "chat_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "remove": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_api_handlers.TaskRequest": {
+            "type": "object",
+            "properties": {
+                "audience_id": {
+                    "description": "Аудитория вместо списка recipients; tag_expr фильтрует участников,\nнапример \"vip AND (ru OR en) AND NOT churned\"",
+                    "type": "integer"
+                },
+                "bot_id": {
+                    "description": "Бот из /api/bots, от имени которого идёт рассылка;\nесли не задан — бот, указанный при регистрации",
+                    "type": "integer"
+                },
                 "content": {
-                    "$ref": "#/definitions/internal_api_handlers.Content"
+                    "description": "обязателен, если не заданы variants",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/internal_api_handlers.Content"
+                        }
+                    ]
+                },
+                "language_code": {
+                    "type": "string"
                 },
                 "priority": {
                     "description": "high, medium, low",
@@ -400,6 +3694,114 @@ const docTemplate = `{
                 "schedule": {
                     "description": "RFC3339",
                     "type": "string"
+                },
+                "tag_expr": {
+                    "type": "string"
+                },
+                "test_percent": {
+                    "description": "Процент получателей для тестовой выборки (1-99, 0 — без выборки). Остальным позже\nотправляется вариант-победитель через POST /tasks/{id}/winner.",
+                    "type": "integer"
+                },
+                "to_subscribers": {
+                    "description": "Подписчики бота (status active) вместо recipients и audience_id;\nlanguage_code оставляет только подписчиков с этим языком",
+                    "type": "boolean"
+                },
+                "track_clicks": {
+                    "description": "Отслеживать клики: ссылки в тексте, подписи и кнопках заменяются\nна персональные редиректы /r/{code}",
+                    "type": "boolean"
+                },
+                "upload_recipients": {
+                    "description": "Получатели загружаются отдельно потоком через POST /tasks/{id}/recipients\n(для списков, которые неудобно передавать в JSON)",
+                    "type": "boolean"
+                },
+                "variants": {
+                    "description": "A/B-тест: варианты контента вместо Content. Получатель попадает в вариант\nдетерминированно (по хешу получателя и задачи) пропорционально весам.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api_handlers.Variant"
+                    }
+                }
+            }
+        },
+        "internal_api_handlers.UpdatesModeInput": {
+            "type": "object",
+            "required": [
+                "mode"
+            ],
+            "properties": {
+                "bot_id": {
+                    "description": "бот из /api/bots; не задан — бот по умолчанию аккаунта",
+                    "type": "integer"
+                },
+                "mode": {
+                    "description": "off, polling, webhook",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.Variant": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "$ref": "#/definitions/internal_api_handlers.Content"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_api_handlers.Venue": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.WinnerRequest": {
+            "type": "object",
+            "properties": {
+                "variant": {
+                    "description": "если пусто — вариант с лучшей доставкой",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.WorkersStatusResponse": {
+            "type": "object",
+            "properties": {
+                "active_bots": {
+                    "description": "ботов организации с запущенным воркером",
+                    "type": "integer"
+                },
+                "active_workers": {
+                    "description": "всего по экземплярам, включая чужие организации",
+                    "type": "integer"
+                },
+                "in_flight_tasks": {
+                    "type": "integer"
+                },
+                "instances": {
+                    "description": "в Workers только боты организации",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/GoBlast_internal_worker.ManagerStatus"
+                    }
+                },
+                "queue_depth": {
+                    "type": "integer"
                 }
             }
         }
@@ -3,29 +3,39 @@
 package handlers
 
 import (
-	"GoBlast/internal/api/middleware"
-	"encoding/base64"
+	"crypto/subtle"
+	"errors"
 	"log"
 	"net/http"
+	"time"
 
-	"GoBlast/pkg/encryption"
+	"GoBlast/internal/bots"
 	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"GoBlast/pkg/totp"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 )
 
+var errPasswordTooShort = errors.New("password must be at least 8 characters")
+
 // LoginInput описывает входные данные для входа пользователя
 type LoginInput struct {
 	Username string `json:"username" binding:"required"`
-	Token    string `json:"token" binding:"required"`
+	Password string `json:"password,omitempty"`
+	// Токен бота — только для аккаунтов, созданных до появления паролей, и только
+	// при app.legacy_token_login; после входа задайте пароль через POST /auth/password
+	Token    string `json:"token,omitempty"`
+	TOTPCode string `json:"totp_code,omitempty"` // обязателен, если включена двухфакторная аутентификация
 }
 
 // LoginHandler @Summary User login
-// @Description Authenticates the user and returns a JWT token
+// @Description Authenticates the user by password (and TOTP code if enabled) and returns a short-lived JWT access token and a refresh token
 // @Tags Authentication
 // @Accept json
 // @Produce json
-// @Param input body LoginInput true "User credentials (username and token)"
-// @Success 200 {object} response.APIResponse{data=map[string]string} "JWT token"
+// @Param input body LoginInput true "User credentials"
+// @Success 200 {object} response.APIResponse{data=SessionTokens} "Access and refresh tokens"
 // @Failure 400 {object} response.APIResponse "Invalid input"
 // @Failure 401 {object} response.APIResponse "Invalid credentials"
 // @Failure 500 {object} response.APIResponse "Failed to generate token"
@@ -34,7 +44,8 @@ type LoginInput struct {
 //
 //	{
 //	  "username": "sokrat",
-//	  "token": "your_telegram_bot_token_here"
+//	  "password": "correct horse battery staple",
+//	  "totp_code": "123456"
 //	}
 //
 // @example Response (200):
@@ -42,7 +53,9 @@ type LoginInput struct {
 //	{
 //	  "success": true,
 //	  "data": {
-//	    "token": "your_jwt_token_here"
+//	    "token": "your_jwt_token_here",
+//	    "refresh_token": "your_refresh_token_here",
+//	    "expires_in": 900
 //	  }
 //	}
 func (h *AuthHandler) LoginHandler(c *gin.Context) {
@@ -59,38 +72,57 @@ func (h *AuthHandler) LoginHandler(c *gin.Context) {
 		return
 	}
 
-	// Декодирование base64 строки обратно в []byte
-	encryptedToken, err := base64.StdEncoding.DecodeString(user.Token)
-	if err != nil {
-		log.Printf("Error decoding token for user %s: %v", input.Username, err)
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to decode token"))
+	if !checkCredentials(user, input, h.legacyTokenLogin) {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Invalid credentials"))
 		return
 	}
 
-	// Дешифрование токена
-	decryptedTokenBytes, err := encryption.Decrypt(encryptedToken, []byte(middleware.EncryptionKey))
-	if err != nil {
-		log.Printf("Error decrypting token for user %s: %v", input.Username, err)
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to decrypt token"))
-		return
+	// Второй фактор
+	if user.TOTPEnabled {
+		if input.TOTPCode == "" {
+			c.JSON(http.StatusUnauthorized, response.ErrorResponse("TOTP code required"))
+			return
+		}
+		secret, err := h.userTOTPSecret(user)
+		if err != nil {
+			log.Printf("Error decrypting TOTP secret for user %s: %v", input.Username, err)
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to read TOTP secret"))
+			return
+		}
+		if !totp.Validate(secret, input.TOTPCode, time.Now()) {
+			c.JSON(http.StatusUnauthorized, response.ErrorResponse("Invalid TOTP code"))
+			return
+		}
 	}
-	decryptedToken := string(decryptedTokenBytes)
 
-	// Сравнение введённого токена с хранимым
-	if decryptedToken != input.Token {
-		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Invalid token"))
+	// Аккаунты, созданные до появления организаций, получают личную организацию
+	if err := h.repo.EnsureOrganization(user); err != nil {
+		log.Printf("Error creating organization for user %s: %v", input.Username, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to prepare organization"))
 		return
 	}
 
-	// Генерация JWT-токена
-	jwtToken, err := middleware.GenerateToken(user.ID)
-	if err != nil {
-		log.Printf("Error generating JWT token for user %s: %v", input.Username, err)
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to generate token"))
-		return
+	h.issueSession(c, user)
+}
+
+// checkCredentials сверяет пароль. Аккаунты без пароля входят по токену бота, как раньше,
+// только если это разрешено legacyTokenLogin.
+func checkCredentials(user *models.AuthUser, input LoginInput, legacyTokenLogin bool) bool {
+	if user.PasswordHash != "" {
+		return input.Password != "" &&
+			bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)) == nil
+	}
+	if !legacyTokenLogin {
+		return false
 	}
 
-	c.JSON(http.StatusOK, response.SuccessResponse(map[string]string{
-		"token": jwtToken,
-	}))
+	if input.Token == "" || user.Token == "" {
+		return false
+	}
+	botToken, err := bots.DecryptToken(user.Token)
+	if err != nil {
+		log.Printf("Error decrypting token for user %s: %v", user.Username, err)
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(botToken), []byte(input.Token)) == 1
 }
@@ -3,49 +3,74 @@
 package handlers
 
 import (
-	"GoBlast/internal/api/middleware"
-	"encoding/base64"
 	"log"
 	"net/http"
+	"strings"
 
+	"GoBlast/internal/bots"
+	"GoBlast/internal/sessions"
 	"GoBlast/internal/users"
-	"GoBlast/pkg/encryption"
 	"GoBlast/pkg/response"
 	"GoBlast/pkg/storage/models"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// minPasswordLength — минимальная длина пароля
+const minPasswordLength = 8
+
 // RegisterInput описывает входные данные для регистрации пользователя
 type RegisterInput struct {
-	Username string `json:"username" binding:"required"`
-	Token    string `json:"token" binding:"required"`
+	Username     string `json:"username" binding:"required"`
+	Password     string `json:"password" binding:"required"`
+	Organization string `json:"organization,omitempty"` // по умолчанию — имя пользователя
+	Token        string `json:"token,omitempty"`        // Telegram Bot Token бота по умолчанию (необязателен)
 }
 
 type AuthHandler struct {
-	repo *users.AuthUserRepository
+	repo        *users.AuthUserRepository
+	sessionRepo *sessions.SessionRepository
+	botRepo     *bots.BotRepository
+
+	legacyTokenLogin bool // вход по токену бота для аккаунтов без пароля (app.legacy_token_login)
 }
 
-func NewAuthHandler(repo *users.AuthUserRepository) *AuthHandler {
-	return &AuthHandler{repo: repo}
+func NewAuthHandler(repo *users.AuthUserRepository, sessionRepo *sessions.SessionRepository, botRepo *bots.BotRepository, legacyTokenLogin bool) *AuthHandler {
+	return &AuthHandler{repo: repo, sessionRepo: sessionRepo, botRepo: botRepo, legacyTokenLogin: legacyTokenLogin}
+}
+
+// hashPassword проверяет длину пароля и возвращает его bcrypt-хеш
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", errPasswordTooShort
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
 }
 
 // RegisterHandler @Summary Зарегистрировать пользователя
-// @Description Создаёт новый аккаунт пользователя с уникальным именем и Telegram Bot Token.
+// @Description Создаёт организацию и её администратора с логином и паролем. Токен бота необязателен: ботов можно добавить позже через /bots.
+// @Description Переданный токен проверяется через getMe; бот, уже подключённый к другому аккаунту, отклоняется.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param register body RegisterInput true "User registration data"
 // @Success 201 {object} response.APIResponse "Пользователь успешно зарегистрирован"
-// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
-// @Failure 409 {object} response.APIResponse "Имя пользователя уже существует"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные или токен бота отклонён Telegram"
+// @Failure 409 {object} response.APIResponse "Имя пользователя уже существует или бот уже подключён"
+// @Failure 502 {object} response.APIResponse "Telegram недоступен"
 // @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
 // @Router /auth/register [post]
 // @example Request:
 //
 //	{
 //	  "username": "sokrat",
-//	  "token": "your_telegram_bot_token_here"
+//	  "password": "correct horse battery staple",
+//	  "organization": "GoBlast"
 //	}
 //
 // @example Response (201):
@@ -69,25 +94,49 @@ func (h *AuthHandler) RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	// Шифрование токена
-	encryptedToken, err := encryption.Encrypt([]byte(input.Token), []byte(middleware.EncryptionKey))
+	passwordHash, err := hashPassword(input.Password)
 	if err != nil {
-		log.Printf("Error encrypting token for user %s: %v", input.Username, err)
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to encrypt token"))
+		if err == errPasswordTooShort {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+			return
+		}
+		log.Printf("Error hashing password for user %s: %v", input.Username, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to hash password"))
 		return
 	}
 
-	// Кодирование зашифрованного токена в base64 строку
-	encodedToken := base64.StdEncoding.EncodeToString(encryptedToken)
+	// Проверка и шифрование токена бота по умолчанию, если он передан
+	var encodedToken string
+	identity := &bots.Identity{}
+	if input.Token != "" {
+		var ok bool
+		identity, ok = verifyBotToken(c, h.botRepo, h.repo, input.Token, 0, 0)
+		if !ok {
+			return
+		}
+		encodedToken, err = bots.EncryptToken(input.Token)
+		if err != nil {
+			log.Printf("Error encrypting token for user %s: %v", input.Username, err)
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to encrypt token"))
+			return
+		}
+	}
 
-	// Создание нового пользователя
-	newUser := &models.AuthUser{
-		Username: input.Username,
-		Token:    encodedToken, // Хранение зашифрованного токена как строки
+	orgName := strings.TrimSpace(input.Organization)
+	if orgName == "" {
+		orgName = input.Username
 	}
 
-	// Сохранение пользователя в БД
-	if err := h.repo.Create(newUser); err != nil {
+	// Создание организации и её администратора
+	newUser := &models.AuthUser{
+		Username:      input.Username,
+		PasswordHash:  passwordHash,
+		Token:         encodedToken, // Хранение зашифрованного токена как строки
+		BotTelegramID: identity.TelegramID,
+		BotUsername:   identity.Username,
+		BotName:       identity.Name,
+	}
+	if err := h.repo.CreateWithOrganization(&models.Organization{Name: orgName}, newUser); err != nil {
 		log.Printf("Error saving user %s: %v", input.Username, err)
 		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to register user"))
 		return
@@ -0,0 +1,162 @@
+// api/handlers/AuthSession.go
+
+package handlers
+
+import (
+	"GoBlast/internal/api/middleware"
+	"GoBlast/internal/sessions"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SessionTokens — пара токенов сессии
+type SessionTokens struct {
+	Token        string `json:"token"`         // access-токен (JWT)
+	RefreshToken string `json:"refresh_token"` // одноразовый: при обновлении выдаётся новый
+	ExpiresIn    int    `json:"expires_in"`    // срок жизни access-токена, секунд
+}
+
+// RefreshInput — refresh-токен для продления сессии
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// issueSession выпускает access- и refresh-токены новой сессии и пишет их в ответ
+func (h *AuthHandler) issueSession(c *gin.Context, user *models.AuthUser) {
+	accessToken, jti, err := middleware.GenerateToken(user.ID, *user.OrgID, user.Role)
+	if err != nil {
+		logger.Log.Error("Ошибка генерации JWT", zap.Uint("user_id", user.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to generate token"))
+		return
+	}
+	refreshToken, err := h.sessionRepo.Issue(user.ID, jti)
+	if err != nil {
+		logger.Log.Error("Ошибка сохранения refresh-токена", zap.Uint("user_id", user.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to generate token"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.SuccessResponse(SessionTokens{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(middleware.AccessTokenTTL / time.Second),
+	}))
+}
+
+// RefreshHandler Продлевает сессию
+// @Summary Обновить токены
+// @Description Обменивает refresh-токен на новую пару токенов. Старый refresh-токен перестаёт действовать;
+// @Description его повторное предъявление считается утечкой и завершает все сессии пользователя.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body RefreshInput true "Refresh-токен"
+// @Success 200 {object} response.APIResponse{data=SessionTokens} "Новая пара токенов"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 401 {object} response.APIResponse "Refresh-токен недействителен"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) RefreshHandler(c *gin.Context) {
+	var input RefreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+
+	old, err := h.sessionRepo.Lookup(input.RefreshToken)
+	if err != nil {
+		h.refreshFailed(c, err)
+		return
+	}
+
+	// Роль и организация берутся заново: изменения применяются при следующем обновлении
+	user, err := h.repo.FindByID(old.UserID)
+	if err != nil || user.OrgID == nil {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("User not found"))
+		return
+	}
+
+	accessToken, jti, err := middleware.GenerateToken(user.ID, *user.OrgID, user.Role)
+	if err != nil {
+		logger.Log.Error("Ошибка генерации JWT", zap.Uint("user_id", user.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to generate token"))
+		return
+	}
+	refreshToken, err := h.sessionRepo.Rotate(old, jti)
+	if err != nil {
+		h.refreshFailed(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.SuccessResponse(SessionTokens{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(middleware.AccessTokenTTL / time.Second),
+	}))
+}
+
+func (h *AuthHandler) refreshFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sessions.ErrRefreshReused):
+		logger.Log.Warn("Повторное использование refresh-токена, сессии пользователя отозваны")
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Refresh token reuse detected, all sessions revoked"))
+	case errors.Is(err, sessions.ErrInvalidRefresh):
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Invalid refresh token"))
+	default:
+		logger.Log.Error("Ошибка обновления сессии", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to refresh session"))
+	}
+}
+
+// LogoutHandler Завершает текущую сессию
+// @Summary Выйти
+// @Description Отзывает текущий access-токен и выданный вместе с ним refresh-токен.
+// @Tags Authentication
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.APIResponse "Сессия завершена"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Router /auth/logout [post]
+func (h *AuthHandler) LogoutHandler(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok || claims.Id == "" {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	if err := h.sessionRepo.Logout(claims.UserID, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		logger.Log.Error("Ошибка завершения сессии", zap.Uint("user_id", claims.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to log out"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse("Logged out"))
+}
+
+// LogoutAllHandler Завершает все сессии пользователя
+// @Summary Выйти на всех устройствах
+// @Tags Authentication
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.APIResponse "Все сессии завершены"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAllHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	if err := h.sessionRepo.RevokeAll(userID); err != nil {
+		logger.Log.Error("Ошибка завершения сессий", zap.Uint("user_id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to log out"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse("All sessions revoked"))
+}
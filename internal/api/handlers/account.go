@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"GoBlast/internal/api/middleware"
+	"GoBlast/pkg/encryption"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"GoBlast/pkg/totp"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// totpIssuer — название сервиса в приложении-аутентификаторе
+const totpIssuer = "GoBlast"
+
+// legacyTOTPSecretLen — предельная длина незашифрованного секрета, сохранённого до
+// появления шифрования (сам секрет — 32 символа base32, зашифрованный — 80 символов base64)
+const legacyTOTPSecretLen = 64
+
+// sealTOTPSecret шифрует секрет TOTP тем же ключом, что и токены ботов
+func sealTOTPSecret(secret string) (string, error) {
+	encrypted, err := encryption.Encrypt([]byte(secret), []byte(middleware.EncryptionKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// openTOTPSecret расшифровывает сохранённый секрет TOTP. legacy сообщает, что секрет
+// хранится открытым текстом и его нужно перешифровать.
+func openTOTPSecret(stored string) (secret string, legacy bool, err error) {
+	if decoded, decodeErr := base64.StdEncoding.DecodeString(stored); decodeErr == nil {
+		if plain, decryptErr := encryption.Decrypt(decoded, []byte(middleware.EncryptionKey)); decryptErr == nil {
+			return string(plain), false, nil
+		}
+	}
+	if len(stored) <= legacyTOTPSecretLen {
+		return stored, true, nil
+	}
+	return "", false, fmt.Errorf("failed to decrypt totp secret")
+}
+
+// userTOTPSecret возвращает расшифрованный секрет TOTP пользователя. Секреты, сохранённые
+// открытым текстом, попутно перешифровываются.
+func (h *AuthHandler) userTOTPSecret(user *models.AuthUser) (string, error) {
+	secret, legacy, err := openTOTPSecret(user.TOTPSecret)
+	if err != nil || !legacy {
+		return secret, err
+	}
+
+	sealed, err := sealTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	if err := h.repo.UpdateTOTP(user.ID, sealed, user.TOTPEnabled); err != nil {
+		// Не мешаем входу: перешифруем при следующем обращении
+		logger.Log.Warn("Не удалось перешифровать секрет TOTP", zap.Uint("user_id", user.ID), zap.Error(err))
+		return secret, nil
+	}
+	user.TOTPSecret = sealed
+	return secret, nil
+}
+
+// PasswordInput — смена пароля
+type PasswordInput struct {
+	CurrentPassword string `json:"current_password,omitempty"` // не нужен, если пароль ещё не задан
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// TOTPCodeInput — код из приложения-аутентификатора
+type TOTPCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ChangePassword Задаёт или меняет пароль
+// @Summary Сменить пароль
+// @Description Аккаунты, созданные до появления паролей, задают пароль без current_password.
+// @Tags Authentication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body PasswordInput true "Пароли"
+// @Success 200 {object} response.APIResponse "Пароль изменён"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 401 {object} response.APIResponse "Неверный текущий пароль"
+// @Router /auth/password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var input PasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+	user, err := h.repo.FindByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("User not found"))
+		return
+	}
+
+	if user.PasswordHash != "" &&
+		bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)) != nil {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Invalid current password"))
+		return
+	}
+
+	passwordHash, err := hashPassword(input.NewPassword)
+	if err != nil {
+		if err == errPasswordTooShort {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+			return
+		}
+		logger.Log.Error("Ошибка хеширования пароля", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to hash password"))
+		return
+	}
+	if err := h.repo.UpdatePassword(userID, passwordHash); err != nil {
+		logger.Log.Error("Ошибка сохранения пароля", zap.Uint("user_id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to save password"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse("Password updated"))
+}
+
+// SetupTOTP Выдаёт новый секрет TOTP
+// @Summary Подготовить TOTP
+// @Description Генерирует секрет и otpauth-ссылку. Второй фактор включается после подтверждения кодом через /auth/totp/enable.
+// @Tags Authentication
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.APIResponse{data=map[string]string} "Секрет и otpauth-ссылка"
+// @Failure 409 {object} response.APIResponse "TOTP уже включён"
+// @Router /auth/totp/setup [post]
+func (h *AuthHandler) SetupTOTP(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+	user, err := h.repo.FindByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("User not found"))
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, response.ErrorResponse("TOTP is already enabled"))
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		logger.Log.Error("Ошибка генерации секрета TOTP", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to generate secret"))
+		return
+	}
+	sealed, err := sealTOTPSecret(secret)
+	if err != nil {
+		logger.Log.Error("Ошибка шифрования секрета TOTP", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to save secret"))
+		return
+	}
+	if err := h.repo.UpdateTOTP(userID, sealed, false); err != nil {
+		logger.Log.Error("Ошибка сохранения секрета TOTP", zap.Uint("user_id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to save secret"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]string{
+		"secret": secret,
+		"url":    totp.URL(totpIssuer, user.Username, secret),
+	}))
+}
+
+// EnableTOTP Включает TOTP после проверки кода
+// @Summary Включить TOTP
+// @Tags Authentication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body TOTPCodeInput true "Код"
+// @Success 200 {object} response.APIResponse "TOTP включён"
+// @Failure 400 {object} response.APIResponse "Неверный код или секрет не выдан"
+// @Router /auth/totp/enable [post]
+func (h *AuthHandler) EnableTOTP(c *gin.Context) {
+	h.switchTOTP(c, true)
+}
+
+// DisableTOTP Выключает TOTP после проверки кода
+// @Summary Выключить TOTP
+// @Tags Authentication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body TOTPCodeInput true "Код"
+// @Success 200 {object} response.APIResponse "TOTP выключен"
+// @Failure 400 {object} response.APIResponse "Неверный код"
+// @Router /auth/totp/disable [post]
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	h.switchTOTP(c, false)
+}
+
+// switchTOTP проверяет код текущим секретом и включает или выключает второй фактор
+func (h *AuthHandler) switchTOTP(c *gin.Context, enable bool) {
+	var input TOTPCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+	user, err := h.repo.FindByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("User not found"))
+		return
+	}
+	if user.TOTPSecret == "" || user.TOTPEnabled == enable {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("TOTP is not pending this change"))
+		return
+	}
+	current, err := h.userTOTPSecret(user)
+	if err != nil {
+		logger.Log.Error("Ошибка дешифрования секрета TOTP", zap.Uint("user_id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to read TOTP secret"))
+		return
+	}
+	if !totp.Validate(current, input.Code, time.Now()) {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid TOTP code"))
+		return
+	}
+
+	secret := user.TOTPSecret
+	if !enable {
+		secret = ""
+	}
+	if err := h.repo.UpdateTOTP(userID, secret, enable); err != nil {
+		logger.Log.Error("Ошибка сохранения TOTP", zap.Uint("user_id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to update TOTP"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]bool{
+		"totp_enabled": enable,
+	}))
+}
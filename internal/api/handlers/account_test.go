@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"GoBlast/internal/api/middleware"
+	"GoBlast/internal/bots"
+	"GoBlast/pkg/encryption"
+	"GoBlast/pkg/storage/models"
+	"encoding/base64"
+	"testing"
+)
+
+func TestTOTPSecretEncryption(t *testing.T) {
+	middleware.EncryptionKey = "0123456789abcdef0123456789abcdef"
+
+	sealed, err := sealTOTPSecret("JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed == "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP" || len(sealed) > 255 {
+		t.Fatalf("sealed secret %q is not encrypted or does not fit the column", sealed)
+	}
+
+	secret, legacy, err := openTOTPSecret(sealed)
+	if err != nil || legacy || secret != "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP" {
+		t.Fatalf("open sealed = %q, %v, %v", secret, legacy, err)
+	}
+
+	// Секрет, сохранённый до появления шифрования
+	secret, legacy, err = openTOTPSecret("JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP")
+	if err != nil || !legacy || secret != "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP" {
+		t.Fatalf("open legacy = %q, %v, %v", secret, legacy, err)
+	}
+
+	// Зашифрованный другим ключом секрет не выдаётся за открытый текст
+	other, err := encryption.Encrypt([]byte("JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"), []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := openTOTPSecret(base64.StdEncoding.EncodeToString(other)); err == nil {
+		t.Fatal("secret sealed with another key opened without error")
+	}
+}
+
+func TestCheckCredentialsLegacyToken(t *testing.T) {
+	middleware.EncryptionKey = "0123456789abcdef0123456789abcdef"
+
+	token, err := bots.EncryptToken("123:ABC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.AuthUser{Username: "old", Token: token}
+	input := LoginInput{Username: "old", Token: "123:ABC"}
+
+	if checkCredentials(user, input, false) {
+		t.Fatal("bot token accepted with legacy token login disabled")
+	}
+	if !checkCredentials(user, input, true) {
+		t.Fatal("bot token rejected with legacy token login enabled")
+	}
+	if checkCredentials(user, LoginInput{Username: "old", Token: "123:XYZ"}, true) {
+		t.Fatal("wrong bot token accepted")
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"GoBlast/internal/apikeys"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// knownScopes — scopes, которые можно выдать ключу
+var knownScopes = map[string]bool{
+	models.ScopeTasksRead:  true,
+	models.ScopeTasksWrite: true,
+}
+
+// APIKeyHandler управляет API-ключами организации
+type APIKeyHandler struct {
+	repo *apikeys.APIKeyRepository
+}
+
+func NewAPIKeyHandler(repo *apikeys.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{repo: repo}
+}
+
+// APIKeyInput — данные для выпуска ключа
+type APIKeyInput struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"` // tasks:read, tasks:write
+	ExpiresInDays int      `json:"expires_in_days,omitempty"` // 0 — бессрочный
+}
+
+// CreateAPIKey Выпускает API-ключ
+// @Summary Выпустить API-ключ
+// @Description Ключ действует от имени текущего пользователя с его ролью и показывается только в этом ответе.
+// @Description Передаётся в заголовке X-API-Key или Authorization: Bearer gb_...
+// @Tags API Keys
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param key body APIKeyInput true "Ключ"
+// @Success 201 {object} response.APIResponse "Ключ выпущен"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Router /keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var input APIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Name) == "" || len(input.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+	for _, scope := range input.Scopes {
+		if !knownScopes[scope] {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse("unknown scope: "+scope))
+			return
+		}
+	}
+	if input.ExpiresInDays < 0 {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("expires_in_days must not be negative"))
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok || claims.OrgID == 0 {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	raw, prefix, hash, err := apikeys.Generate()
+	if err != nil {
+		logger.Log.Error("Ошибка генерации API-ключа", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to generate key"))
+		return
+	}
+
+	key := &models.APIKey{
+		UserID: claims.UserID,
+		OrgID:  claims.OrgID,
+		Name:   strings.TrimSpace(input.Name),
+		Prefix: prefix,
+		Hash:   hash,
+		Scopes: strings.Join(input.Scopes, " "),
+	}
+	if input.ExpiresInDays > 0 {
+		expires := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		key.ExpiresAt = &expires
+	}
+	if err := h.repo.Create(key); err != nil {
+		logger.Log.Error("Ошибка сохранения API-ключа", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to save key"))
+		return
+	}
+
+	logger.Log.Info("Выпущен API-ключ",
+		zap.Uint("key_id", key.ID),
+		zap.String("prefix", prefix),
+		zap.Uint("user_id", claims.UserID))
+
+	c.JSON(http.StatusCreated, response.SuccessResponse(map[string]interface{}{
+		"key":     raw,
+		"api_key": key,
+	}))
+}
+
+// ListAPIKeys Возвращает API-ключи организации
+// @Summary Список API-ключей
+// @Tags API Keys
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.APIResponse{data=[]models.APIKey} "Ключи (без секретов)"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Router /keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	list, err := h.repo.List(orgID)
+	if err != nil {
+		logger.Log.Error("Ошибка получения API-ключей", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to list keys"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(list))
+}
+
+// RevokeAPIKey Отзывает API-ключ
+// @Summary Отозвать API-ключ
+// @Tags API Keys
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID ключа"
+// @Success 200 {object} response.APIResponse "Ключ отозван"
+// @Failure 404 {object} response.APIResponse "Ключ не найден или уже отозван"
+// @Router /keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid key id"))
+		return
+	}
+
+	if err := h.repo.Revoke(orgID, uint(id)); err != nil {
+		if errors.Is(err, apikeys.ErrNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse("Key not found"))
+			return
+		}
+		logger.Log.Error("Ошибка отзыва API-ключа", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to revoke key"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse("Key revoked"))
+}
@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"GoBlast/internal/audiences"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// importBatchSize — сколько строк CSV сохраняется за один запрос к БД
+const importBatchSize = 1000
+
+// AudienceHandler управляет аудиториями пользователя
+type AudienceHandler struct {
+	repo *audiences.AudienceRepository
+}
+
+func NewAudienceHandler(repo *audiences.AudienceRepository) *AudienceHandler {
+	return &AudienceHandler{repo: repo}
+}
+
+// AudienceInput — данные для создания аудитории
+type AudienceInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MembersInput — участники для добавления или удаления
+type MembersInput struct {
+	ChatIDs []int64  `json:"chat_ids" binding:"required"`
+	Tags    []string `json:"tags,omitempty"` // теги для добавляемых участников
+}
+
+// TagMembersInput — изменение тегов участников
+type TagMembersInput struct {
+	ChatIDs []int64  `json:"chat_ids" binding:"required"`
+	Add     []string `json:"add,omitempty"`
+	Remove  []string `json:"remove,omitempty"`
+}
+
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if err := audiences.ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadAudience находит аудиторию текущего пользователя или его организации по :id.
+// При ошибке сам пишет ответ и возвращает false.
+func (h *AudienceHandler) loadAudience(c *gin.Context) (*models.Audience, bool) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid audience id"))
+		return nil, false
+	}
+
+	audience, err := h.repo.Get(claims.UserID, claims.OrgID, uint(id))
+	if err != nil {
+		if errors.Is(err, audiences.ErrNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse("Audience not found"))
+		} else {
+			logger.Log.Error("Ошибка получения аудитории", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to load audience"))
+		}
+		return nil, false
+	}
+	return audience, true
+}
+
+// CreateAudience Создаёт аудиторию
+// @Summary Создать аудиторию
+// @Tags Audiences
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param audience body AudienceInput true "Аудитория"
+// @Success 201 {object} response.APIResponse{data=models.Audience} "Аудитория создана"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /audiences [post]
+func (h *AudienceHandler) CreateAudience(c *gin.Context) {
+	var input AudienceInput
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Name) == "" {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	audience := &models.Audience{UserID: claims.UserID, Name: strings.TrimSpace(input.Name)}
+	if claims.OrgID != 0 {
+		orgID := claims.OrgID
+		audience.OrgID = &orgID
+	}
+	if err := h.repo.Create(audience); err != nil {
+		logger.Log.Error("Ошибка создания аудитории", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to create audience"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.SuccessResponse(audience))
+}
+
+// ListAudiences Возвращает аудитории пользователя и его организации
+// @Summary Список аудиторий
+// @Tags Audiences
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.APIResponse{data=[]models.Audience} "Аудитории"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /audiences [get]
+func (h *AudienceHandler) ListAudiences(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	list, err := h.repo.List(claims.UserID, claims.OrgID)
+	if err != nil {
+		logger.Log.Error("Ошибка получения аудиторий", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to list audiences"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(list))
+}
+
+// GetAudience Возвращает аудиторию
+// @Summary Получить аудиторию
+// @Tags Audiences
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID аудитории"
+// @Success 200 {object} response.APIResponse{data=models.Audience} "Аудитория"
+// @Failure 404 {object} response.APIResponse "Аудитория не найдена"
+// @Router /audiences/{id} [get]
+func (h *AudienceHandler) GetAudience(c *gin.Context) {
+	audience, ok := h.loadAudience(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(audience))
+}
+
+// DeleteAudience Удаляет аудиторию вместе с участниками
+// @Summary Удалить аудиторию
+// @Tags Audiences
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID аудитории"
+// @Success 200 {object} response.APIResponse "Аудитория удалена"
+// @Failure 404 {object} response.APIResponse "Аудитория не найдена"
+// @Router /audiences/{id} [delete]
+func (h *AudienceHandler) DeleteAudience(c *gin.Context) {
+	audience, ok := h.loadAudience(c)
+	if !ok {
+		return
+	}
+
+	// Доступ уже проверен в loadAudience: удаляем от имени автора аудитории
+	if err := h.repo.Delete(audience.UserID, 0, audience.ID); err != nil {
+		logger.Log.Error("Ошибка удаления аудитории", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to delete audience"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse("Audience deleted"))
+}
+
+// AddMembers Добавляет участников в аудиторию
+// @Summary Добавить участников
+// @Description Добавляет chat ID пачкой. Теги существующих участников объединяются с переданными.
+// @Tags Audiences
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID аудитории"
+// @Param members body MembersInput true "Участники"
+// @Success 200 {object} response.APIResponse "Участники добавлены"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 404 {object} response.APIResponse "Аудитория не найдена"
+// @Router /audiences/{id}/members [post]
+func (h *AudienceHandler) AddMembers(c *gin.Context) {
+	var input MembersInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+	if err := validateTags(input.Tags); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+		return
+	}
+
+	audience, ok := h.loadAudience(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.AddMembers(audience.ID, input.ChatIDs, input.Tags); err != nil {
+		logger.Log.Error("Ошибка добавления участников", zap.Uint("audience_id", audience.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to add members"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]interface{}{
+		"added": len(input.ChatIDs),
+	}))
+}
+
+// RemoveMembers Удаляет участников из аудитории
+// @Summary Удалить участников
+// @Tags Audiences
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID аудитории"
+// @Param members body MembersInput true "Участники"
+// @Success 200 {object} response.APIResponse "Участники удалены"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 404 {object} response.APIResponse "Аудитория не найдена"
+// @Router /audiences/{id}/members [delete]
+func (h *AudienceHandler) RemoveMembers(c *gin.Context) {
+	var input MembersInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+
+	audience, ok := h.loadAudience(c)
+	if !ok {
+		return
+	}
+
+	removed, err := h.repo.RemoveMembers(audience.ID, input.ChatIDs)
+	if err != nil {
+		logger.Log.Error("Ошибка удаления участников", zap.Uint("audience_id", audience.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to remove members"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]interface{}{
+		"removed": removed,
+	}))
+}
+
+// TagMembers Добавляет и снимает теги участников
+// @Summary Изменить теги участников
+// @Tags Audiences
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID аудитории"
+// @Param tags body TagMembersInput true "Теги"
+// @Success 200 {object} response.APIResponse "Теги изменены"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 404 {object} response.APIResponse "Аудитория не найдена"
+// @Router /audiences/{id}/tags [post]
+func (h *AudienceHandler) TagMembers(c *gin.Context) {
+	var input TagMembersInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+	if err := validateTags(append(append([]string{}, input.Add...), input.Remove...)); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+		return
+	}
+
+	audience, ok := h.loadAudience(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.repo.TagMembers(audience.ID, input.ChatIDs, input.Add, input.Remove)
+	if err != nil {
+		logger.Log.Error("Ошибка изменения тегов", zap.Uint("audience_id", audience.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to tag members"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]interface{}{
+		"updated": updated,
+	}))
+}
+
+// ImportMembers Импортирует участников из CSV
+// @Summary Импорт участников из CSV
+// @Description CSV с колонками chat_id и (необязательно) tags, теги разделяются ';'. Строка заголовка пропускается.
+// @Description Файл передаётся полем "file" (multipart/form-data) или телом запроса (text/csv).
+// @Tags Audiences
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Accept text/csv
+// @Produce json
+// @Param id path int true "ID аудитории"
+// @Param file formData file false "CSV-файл"
+// @Success 200 {object} response.APIResponse "Участники импортированы"
+// @Failure 400 {object} response.APIResponse "Некорректный CSV"
+// @Failure 404 {object} response.APIResponse "Аудитория не найдена"
+// @Router /audiences/{id}/import [post]
+func (h *AudienceHandler) ImportMembers(c *gin.Context) {
+	audience, ok := h.loadAudience(c)
+	if !ok {
+		return
+	}
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse("CSV file is required in field 'file'"))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	imported, err := h.importCSV(audience.ID, body)
+	if err != nil {
+		logger.Log.Error("Ошибка импорта CSV", zap.Uint("audience_id", audience.ID), zap.Error(err))
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]interface{}{
+		"imported": imported,
+	}))
+}
+
+// importCSV читает CSV построчно и сохраняет участников пачками, сгруппированными по набору тегов
+func (h *AudienceHandler) importCSV(audienceID uint, body io.Reader) (int, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var (
+		imported int
+		line     int
+		batches  = make(map[string][]int64)
+		tagSets  = make(map[string][]string)
+	)
+	flush := func(key string) error {
+		if err := h.repo.AddMembers(audienceID, batches[key], tagSets[key]); err != nil {
+			return err
+		}
+		imported += len(batches[key])
+		delete(batches, key)
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return imported, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		chatID, err := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+		if err != nil {
+			if line == 1 {
+				continue // заголовок
+			}
+			return imported, fmt.Errorf("line %d: invalid chat_id %q", line, record[0])
+		}
+
+		var tags []string
+		if len(record) > 1 {
+			for _, tag := range strings.Split(record[1], ";") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+		}
+		if err := validateTags(tags); err != nil {
+			return imported, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		key := strings.Join(tags, ";")
+		tagSets[key] = tags
+		batches[key] = append(batches[key], chatID)
+		if len(batches[key]) >= importBatchSize {
+			if err := flush(key); err != nil {
+				return imported, err
+			}
+		}
+	}
+
+	for key := range batches {
+		if err := flush(key); err != nil {
+			return imported, err
+		}
+	}
+	return imported, nil
+}
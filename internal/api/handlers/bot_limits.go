@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"GoBlast/configs"
+	"GoBlast/internal/bots"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// subjectBotLimits — уведомление воркеров об изменении ограничений бота (worker.SubjectBotLimits)
+const subjectBotLimits = "bots.limits.updated"
+
+// BotLimitsInput — переопределения ограничений бота; отсутствующее поле берётся из конфигурации
+type BotLimitsInput struct {
+	Concurrency      *int     `json:"concurrency,omitempty"`
+	QueueSize        *int     `json:"queue_size,omitempty"`
+	RateHigh         *float64 `json:"rate_high,omitempty"`
+	RateMedium       *float64 `json:"rate_medium,omitempty"`
+	RateLow          *float64 `json:"rate_low,omitempty"`
+	RetryMaxAttempts *int     `json:"retry_max_attempts,omitempty"`
+}
+
+// BotLimitsResponse — переопределения бота и действующие с ними ограничения
+type BotLimitsResponse struct {
+	Override  *models.BotLimit `json:"override"` // null — переопределений нет
+	Effective bots.Limits      `json:"effective"`
+}
+
+// GetBotLimits Возвращает ограничения воркера бота
+// @Summary Ограничения бота
+// @Description Конкурентность, скорости по приоритетам, размер очереди и число попыток: переопределения бота и действующие значения.
+// @Tags Bots
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID бота"
+// @Success 200 {object} response.APIResponse{data=BotLimitsResponse} "Ограничения"
+// @Failure 404 {object} response.APIResponse "Бот не найден"
+// @Router /bots/{id}/limits [get]
+func (h *BotHandler) GetBotLimits(c *gin.Context) {
+	bot, ok := h.loadBot(c)
+	if !ok {
+		return
+	}
+
+	override, err := h.repo.GetLimit(bot.ID)
+	if err != nil {
+		logger.Log.Error("Ошибка получения ограничений бота", zap.Uint("bot_id", bot.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to load bot limits"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(limitsResponse(override)))
+}
+
+// UpdateBotLimits Задаёт ограничения воркера бота
+// @Summary Изменить ограничения бота
+// @Description Заменяет переопределения бота целиком. Работающие воркеры применяют конкурентность, скорости и повторы сразу, размер очереди — при следующем запуске.
+// @Tags Bots
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID бота"
+// @Param input body BotLimitsInput true "Переопределения"
+// @Success 200 {object} response.APIResponse{data=BotLimitsResponse} "Ограничения сохранены"
+// @Failure 400 {object} response.APIResponse "Недопустимые значения"
+// @Failure 403 {object} response.APIResponse "Нужна роль admin"
+// @Failure 404 {object} response.APIResponse "Бот не найден"
+// @Router /bots/{id}/limits [put]
+func (h *BotHandler) UpdateBotLimits(c *gin.Context) {
+	bot, ok := h.loadBot(c)
+	if !ok {
+		return
+	}
+
+	var input BotLimitsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input: "+err.Error()))
+		return
+	}
+
+	limit := &models.BotLimit{
+		BotID:            bot.ID,
+		Concurrency:      input.Concurrency,
+		QueueSize:        input.QueueSize,
+		RateHigh:         input.RateHigh,
+		RateMedium:       input.RateMedium,
+		RateLow:          input.RateLow,
+		RetryMaxAttempts: input.RetryMaxAttempts,
+	}
+	if err := bots.ValidateLimit(limit); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+		return
+	}
+
+	if err := h.repo.SaveLimit(limit); err != nil {
+		logger.Log.Error("Ошибка сохранения ограничений бота", zap.Uint("bot_id", bot.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to save bot limits"))
+		return
+	}
+	h.notifyLimits(bot.ID)
+
+	c.JSON(http.StatusOK, response.SuccessResponse(limitsResponse(limit)))
+}
+
+// DeleteBotLimits Сбрасывает ограничения бота к конфигурации
+// @Summary Сбросить ограничения бота
+// @Tags Bots
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID бота"
+// @Success 200 {object} response.APIResponse{data=BotLimitsResponse} "Действуют значения из конфигурации"
+// @Failure 403 {object} response.APIResponse "Нужна роль admin"
+// @Failure 404 {object} response.APIResponse "Бот не найден"
+// @Router /bots/{id}/limits [delete]
+func (h *BotHandler) DeleteBotLimits(c *gin.Context) {
+	bot, ok := h.loadBot(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteLimit(bot.ID); err != nil {
+		logger.Log.Error("Ошибка сброса ограничений бота", zap.Uint("bot_id", bot.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to reset bot limits"))
+		return
+	}
+	h.notifyLimits(bot.ID)
+
+	c.JSON(http.StatusOK, response.SuccessResponse(limitsResponse(nil)))
+}
+
+// limitsResponse накладывает переопределения на секцию worker конфигурации API
+func limitsResponse(override *models.BotLimit) BotLimitsResponse {
+	var cfg configs.WorkerConfig
+	if configs.AppConfigInstance != nil {
+		cfg = configs.AppConfigInstance.Worker
+	}
+	return BotLimitsResponse{Override: override, Effective: bots.ResolveLimits(cfg, override)}
+}
+
+// notifyLimits сообщает воркерам об изменении ограничений. Ошибка не откатывает
+// сохранение: воркер прочитает ограничения из БД при следующем запуске.
+func (h *BotHandler) notifyLimits(botID uint) {
+	if h.natsClient == nil {
+		return
+	}
+	payload, err := json.Marshal(map[string]uint{"bot_id": botID})
+	if err == nil {
+		err = h.natsClient.Conn.Publish(subjectBotLimits, payload)
+	}
+	if err != nil {
+		logger.Log.Error("Ошибка уведомления воркеров об ограничениях бота",
+			zap.Uint("bot_id", botID), zap.Error(err))
+	}
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"GoBlast/internal/bots"
+	"GoBlast/internal/users"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/queue"
+	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// BotHandler управляет ботами организации
+type BotHandler struct {
+	repo       *bots.BotRepository
+	userRepo   *users.AuthUserRepository
+	natsClient *queue.NATSClient // уведомления воркеров об изменении ограничений
+}
+
+func NewBotHandler(repo *bots.BotRepository, userRepo *users.AuthUserRepository, natsClient *queue.NATSClient) *BotHandler {
+	return &BotHandler{repo: repo, userRepo: userRepo, natsClient: natsClient}
+}
+
+// BotInput — данные для добавления бота
+type BotInput struct {
+	Name  string `json:"name" binding:"required"`
+	Token string `json:"token" binding:"required"` // Telegram Bot Token
+}
+
+// BotUpdateInput — изменение бота; пустые поля не меняются
+type BotUpdateInput struct {
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status,omitempty"` // active, disabled
+	Token  string `json:"token,omitempty"`  // новый токен того же бота (после /revoke в @BotFather)
+}
+
+var (
+	errBotAlreadyAdded = errors.New("bot is already added to this organization")
+	errBotTaken        = errors.New("bot is already registered by another account")
+)
+
+// checkDuplicateBot проверяет, не подключён ли бот с тем же Telegram ID
+// к другому боту или аккаунту. skipBotID исключает изменяемого бота;
+// orgID = 0 — новая организация (регистрация).
+func checkDuplicateBot(botRepo *bots.BotRepository, userRepo *users.AuthUserRepository, telegramID int64, orgID, skipBotID uint) error {
+	existing, err := botRepo.FindByTelegramID(telegramID)
+	if err != nil {
+		return err
+	}
+	for _, bot := range existing {
+		if bot.ID == skipBotID {
+			continue
+		}
+		if orgID != 0 && bot.OrgID == orgID {
+			return errBotAlreadyAdded
+		}
+		return errBotTaken
+	}
+
+	owners, err := userRepo.FindByBotTelegramID(telegramID)
+	if err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		if orgID != 0 && owner.OrgID != nil && *owner.OrgID == orgID {
+			return errBotAlreadyAdded
+		}
+		return errBotTaken
+	}
+	return nil
+}
+
+// verifyBotToken проверяет токен через getMe и отсутствие дубликатов.
+// При ошибке сам пишет ответ и возвращает false.
+func verifyBotToken(c *gin.Context, botRepo *bots.BotRepository, userRepo *users.AuthUserRepository, token string, orgID, skipBotID uint) (*bots.Identity, bool) {
+	identity, err := bots.GetMe(token)
+	if err != nil {
+		if errors.Is(err, bots.ErrInvalidToken) {
+			logger.Log.Warn("Токен бота отклонён", zap.Error(err))
+			c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid bot token: Telegram did not accept it, check the token issued by @BotFather"))
+		} else {
+			logger.Log.Error("Ошибка проверки токена бота через getMe", zap.Error(err))
+			c.JSON(http.StatusBadGateway, response.ErrorResponse("Failed to validate bot token with Telegram, try again later"))
+		}
+		return nil, false
+	}
+
+	if err := checkDuplicateBot(botRepo, userRepo, identity.TelegramID, orgID, skipBotID); err != nil {
+		if errors.Is(err, errBotAlreadyAdded) || errors.Is(err, errBotTaken) {
+			logger.Log.Warn("Попытка повторно подключить бота",
+				zap.Int64("telegram_id", identity.TelegramID), zap.Uint("org_id", orgID))
+			c.JSON(http.StatusConflict, response.ErrorResponse(fmt.Sprintf("@%s: %s", identity.Username, err.Error())))
+		} else {
+			logger.Log.Error("Ошибка поиска дубликатов бота", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to validate bot token"))
+		}
+		return nil, false
+	}
+	return identity, true
+}
+
+// loadBot находит бота организации текущего пользователя по :id.
+// При ошибке сам пишет ответ и возвращает false.
+func (h *BotHandler) loadBot(c *gin.Context) (*models.Bot, bool) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid bot id"))
+		return nil, false
+	}
+
+	bot, err := h.repo.Get(orgID, uint(id))
+	if err != nil {
+		if errors.Is(err, bots.ErrNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse("Bot not found"))
+		} else {
+			logger.Log.Error("Ошибка получения бота", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to load bot"))
+		}
+		return nil, false
+	}
+	return bot, true
+}
+
+// CreateBot Добавляет бота
+// @Summary Добавить бота
+// @Description Проверяет токен через getMe и сохраняет его в зашифрованном виде вместе с ID, username и именем бота.
+// @Description Бот, уже подключённый к этой или другой организации, повторно не добавляется.
+// @Tags Bots
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param bot body BotInput true "Бот"
+// @Success 201 {object} response.APIResponse{data=models.Bot} "Бот добавлен"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные или токен отклонён Telegram"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 409 {object} response.APIResponse "Бот уже подключён"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Failure 502 {object} response.APIResponse "Telegram недоступен"
+// @Router /bots [post]
+func (h *BotHandler) CreateBot(c *gin.Context) {
+	var input BotInput
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Name) == "" {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok || claims.OrgID == 0 {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+	userID := claims.UserID
+
+	identity, ok := verifyBotToken(c, h.repo, h.userRepo, input.Token, claims.OrgID, 0)
+	if !ok {
+		return
+	}
+
+	encodedToken, err := bots.EncryptToken(input.Token)
+	if err != nil {
+		logger.Log.Error("Ошибка шифрования токена бота", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to encrypt token"))
+		return
+	}
+
+	bot := &models.Bot{
+		UserID:       userID,
+		OrgID:        claims.OrgID,
+		Name:         strings.TrimSpace(input.Name),
+		Token:        encodedToken,
+		Username:     identity.Username,
+		TelegramID:   identity.TelegramID,
+		TelegramName: identity.Name,
+		Status:       models.BotActive,
+	}
+	if err := h.repo.Create(bot); err != nil {
+		if errors.Is(err, bots.ErrDuplicate) {
+			c.JSON(http.StatusConflict, response.ErrorResponse(fmt.Sprintf("@%s: %s", identity.Username, err.Error())))
+			return
+		}
+		logger.Log.Error("Ошибка сохранения бота", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to save bot"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.SuccessResponse(bot))
+}
+
+// ListBots Возвращает ботов организации
+// @Summary Список ботов
+// @Description Вместе со статусом (active, disabled, unauthorized) возвращаются результаты последней проверки getMe.
+// @Tags Bots
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.APIResponse{data=[]models.Bot} "Боты"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /bots [get]
+func (h *BotHandler) ListBots(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	list, err := h.repo.List(orgID)
+	if err != nil {
+		logger.Log.Error("Ошибка получения ботов", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to list bots"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(list))
+}
+
+// GetBot Возвращает бота
+// @Summary Получить бота
+// @Tags Bots
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID бота"
+// @Success 200 {object} response.APIResponse{data=models.Bot} "Бот"
+// @Failure 404 {object} response.APIResponse "Бот не найден"
+// @Router /bots/{id} [get]
+func (h *BotHandler) GetBot(c *gin.Context) {
+	bot, ok := h.loadBot(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(bot))
+}
+
+// UpdateBot Меняет имя, статус или токен бота
+// @Summary Изменить бота
+// @Description status=disabled запрещает создавать задачи от имени бота.
+// @Description Новый токен проверяется через getMe и должен принадлежать тому же боту;
+// @Description бот в статусе unauthorized (токен отозван) активируется только вместе с новым токеном.
+// @Tags Bots
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID бота"
+// @Param bot body BotUpdateInput true "Изменения"
+// @Success 200 {object} response.APIResponse{data=models.Bot} "Бот изменён"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные или токен отклонён Telegram"
+// @Failure 404 {object} response.APIResponse "Бот не найден"
+// @Failure 409 {object} response.APIResponse "Токен принадлежит другому боту"
+// @Router /bots/{id} [patch]
+func (h *BotHandler) UpdateBot(c *gin.Context) {
+	var input BotUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+	if input.Status != "" && input.Status != models.BotActive && input.Status != models.BotDisabled {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("status must be one of: active, disabled"))
+		return
+	}
+
+	bot, ok := h.loadBot(c)
+	if !ok {
+		return
+	}
+
+	fields := make(map[string]interface{})
+	if name := strings.TrimSpace(input.Name); name != "" {
+		fields["name"] = name
+	}
+	if input.Status != "" {
+		fields["status"] = input.Status
+	}
+	if bot.Status == models.BotUnauthorized && input.Status == models.BotActive && input.Token == "" {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Bot token was rejected by Telegram; provide a new token to reactivate the bot"))
+		return
+	}
+	if input.Token != "" {
+		identity, ok := verifyBotToken(c, h.repo, h.userRepo, input.Token, bot.OrgID, bot.ID)
+		if !ok {
+			return
+		}
+		if bot.TelegramID != 0 && identity.TelegramID != bot.TelegramID {
+			c.JSON(http.StatusConflict, response.ErrorResponse("Token belongs to a different bot; add it as a new bot instead"))
+			return
+		}
+		encodedToken, err := bots.EncryptToken(input.Token)
+		if err != nil {
+			logger.Log.Error("Ошибка шифрования токена бота", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to encrypt token"))
+			return
+		}
+		fields["token"] = encodedToken
+		fields["username"] = identity.Username
+		fields["telegram_id"] = identity.TelegramID
+		fields["telegram_name"] = identity.Name
+		// Новый токен проверен: бот, отключённый из-за отозванного токена, снова активен
+		if bot.Status == models.BotUnauthorized && input.Status == "" {
+			fields["status"] = models.BotActive
+		}
+		fields["status_reason"] = ""
+		fields["last_check_error"] = ""
+	}
+	if len(fields) == 0 {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Nothing to update"))
+		return
+	}
+
+	if err := h.repo.Update(bot, fields); err != nil {
+		if errors.Is(err, bots.ErrDuplicate) {
+			c.JSON(http.StatusConflict, response.ErrorResponse(err.Error()))
+			return
+		}
+		logger.Log.Error("Ошибка изменения бота", zap.Uint("bot_id", bot.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to update bot"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(bot))
+}
+
+// DeleteBot Удаляет бота
+// @Summary Удалить бота
+// @Tags Bots
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID бота"
+// @Success 200 {object} response.APIResponse "Бот удалён"
+// @Failure 404 {object} response.APIResponse "Бот не найден"
+// @Router /bots/{id} [delete]
+func (h *BotHandler) DeleteBot(c *gin.Context) {
+	bot, ok := h.loadBot(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(bot.OrgID, bot.ID); err != nil {
+		logger.Log.Error("Ошибка удаления бота", zap.Uint("bot_id", bot.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to delete bot"))
+		return
+	}
+	// Удалённый бот больше не опрашивается планировщиком
+	if bot.UpdatesMode == UpdatesPolling {
+		publishUpdatesMode(h.natsClient, BotUpdatesEvent{BotID: bot.ID, Mode: UpdatesOff})
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse("Bot deleted"))
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"GoBlast/internal/bots"
+	"GoBlast/internal/users"
+	"GoBlast/pkg/storage/db/dbtest"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"testing"
+)
+
+func TestCheckDuplicateBot(t *testing.T) {
+	conn := dbtest.Open(t)
+	botRepo := bots.NewBotRepository(conn)
+	userRepo := users.NewAuthUserRepository(conn)
+
+	own := &models.Organization{Name: "own"}
+	other := &models.Organization{Name: "other"}
+	for _, org := range []*models.Organization{own, other} {
+		if err := conn.Create(org).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	bot := &models.Bot{UserID: 1, OrgID: own.ID, Name: "news", Token: "enc", TelegramID: 100, Status: models.BotActive}
+	if err := botRepo.Create(bot); err != nil {
+		t.Fatal(err)
+	}
+	// Бот по умолчанию участника другой организации
+	owner := &models.AuthUser{Username: "owner", Token: "enc", BotTelegramID: 200, OrgID: &other.ID}
+	if err := userRepo.Create(owner); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		telegramID int64
+		orgID      uint
+		skipBotID  uint
+		want       error
+	}{
+		{"same organization", 100, own.ID, 0, errBotAlreadyAdded},
+		{"other organization", 100, other.ID, 0, errBotTaken},
+		{"registration", 100, 0, 0, errBotTaken},
+		{"token rotation of the same bot", 100, own.ID, bot.ID, nil},
+		{"default bot of another account", 200, own.ID, 0, errBotTaken},
+		{"default bot of a member", 200, other.ID, 0, errBotAlreadyAdded},
+		{"new bot", 300, own.ID, 0, nil},
+	}
+	for _, tc := range cases {
+		if err := checkDuplicateBot(botRepo, userRepo, tc.telegramID, tc.orgID, tc.skipBotID); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// Удалённого бота можно подключить снова
+	if err := botRepo.Delete(own.ID, bot.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkDuplicateBot(botRepo, userRepo, 100, other.ID, 0); err != nil {
+		t.Fatalf("deleted bot: got %v, want nil", err)
+	}
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"GoBlast/internal/sessions"
+	"GoBlast/internal/users"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OrgHandler управляет организацией и её пользователями
+type OrgHandler struct {
+	repo        *users.AuthUserRepository
+	sessionRepo *sessions.SessionRepository
+}
+
+func NewOrgHandler(repo *users.AuthUserRepository, sessionRepo *sessions.SessionRepository) *OrgHandler {
+	return &OrgHandler{repo: repo, sessionRepo: sessionRepo}
+}
+
+// MemberInput — новый пользователь организации
+type MemberInput struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role" binding:"required"` // admin, editor, viewer
+}
+
+// RoleInput — новая роль пользователя
+type RoleInput struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// loadMember находит пользователя организации текущего администратора по :id.
+// Менять собственную запись через эти методы нельзя. При ошибке сам пишет ответ.
+func (h *OrgHandler) loadMember(c *gin.Context) (*models.AuthUser, bool) {
+	claims, ok := currentClaims(c)
+	if !ok || claims.OrgID == 0 {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid user id"))
+		return nil, false
+	}
+	if uint(id) == claims.UserID {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Cannot change your own membership"))
+		return nil, false
+	}
+
+	member, err := h.repo.FindByID(uint(id))
+	if err != nil || member.OrgID == nil || *member.OrgID != claims.OrgID {
+		c.JSON(http.StatusNotFound, response.ErrorResponse("User not found"))
+		return nil, false
+	}
+	return member, true
+}
+
+// GetOrganization Возвращает организацию и её пользователей
+// @Summary Организация
+// @Tags Organization
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.APIResponse "Организация и пользователи"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Router /org [get]
+func (h *OrgHandler) GetOrganization(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	org, err := h.repo.FindOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.ErrorResponse("Organization not found"))
+		return
+	}
+	members, err := h.repo.ListByOrganization(orgID)
+	if err != nil {
+		logger.Log.Error("Ошибка получения пользователей организации", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to list users"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]interface{}{
+		"organization": org,
+		"users":        members,
+	}))
+}
+
+// CreateMember Добавляет пользователя в организацию
+// @Summary Добавить пользователя
+// @Tags Organization
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param user body MemberInput true "Пользователь"
+// @Success 201 {object} response.APIResponse{data=models.AuthUser} "Пользователь добавлен"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 403 {object} response.APIResponse "Нужна роль admin"
+// @Failure 409 {object} response.APIResponse "Имя пользователя уже существует"
+// @Router /org/users [post]
+func (h *OrgHandler) CreateMember(c *gin.Context) {
+	var input MemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+	if !models.ValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("role must be one of: admin, editor, viewer"))
+		return
+	}
+
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	if _, err := h.repo.FindByUsername(input.Username); err == nil {
+		c.JSON(http.StatusConflict, response.ErrorResponse("Username already exists"))
+		return
+	}
+
+	passwordHash, err := hashPassword(input.Password)
+	if err != nil {
+		if err == errPasswordTooShort {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+			return
+		}
+		logger.Log.Error("Ошибка хеширования пароля", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to hash password"))
+		return
+	}
+
+	member := &models.AuthUser{
+		Username:     input.Username,
+		PasswordHash: passwordHash,
+		OrgID:        &orgID,
+		Role:         input.Role,
+	}
+	if err := h.repo.Create(member); err != nil {
+		logger.Log.Error("Ошибка создания пользователя", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to create user"))
+		return
+	}
+	c.JSON(http.StatusCreated, response.SuccessResponse(member))
+}
+
+// UpdateMemberRole Меняет роль пользователя
+// @Summary Изменить роль
+// @Tags Organization
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param role body RoleInput true "Роль"
+// @Success 200 {object} response.APIResponse "Роль изменена"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 403 {object} response.APIResponse "Нужна роль admin"
+// @Failure 404 {object} response.APIResponse "Пользователь не найден"
+// @Router /org/users/{id} [patch]
+func (h *OrgHandler) UpdateMemberRole(c *gin.Context) {
+	var input RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil || !models.ValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("role must be one of: admin, editor, viewer"))
+		return
+	}
+
+	member, ok := h.loadMember(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.UpdateRole(member.ID, input.Role); err != nil {
+		logger.Log.Error("Ошибка изменения роли", zap.Uint("user_id", member.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to update role"))
+		return
+	}
+	// Токены со старой ролью больше не принимаются
+	if err := h.sessionRepo.RevokeAll(member.ID); err != nil {
+		logger.Log.Error("Ошибка отзыва сессий", zap.Uint("user_id", member.ID), zap.Error(err))
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]interface{}{
+		"user_id": member.ID,
+		"role":    input.Role,
+	}))
+}
+
+// DeleteMember Удаляет пользователя из организации
+// @Summary Удалить пользователя
+// @Tags Organization
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} response.APIResponse "Пользователь удалён"
+// @Failure 403 {object} response.APIResponse "Нужна роль admin"
+// @Failure 404 {object} response.APIResponse "Пользователь не найден"
+// @Router /org/users/{id} [delete]
+func (h *OrgHandler) DeleteMember(c *gin.Context) {
+	member, ok := h.loadMember(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(*member.OrgID, member.ID); err != nil {
+		logger.Log.Error("Ошибка удаления пользователя", zap.Uint("user_id", member.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to delete user"))
+		return
+	}
+	if err := h.sessionRepo.RevokeAll(member.ID); err != nil {
+		logger.Log.Error("Ошибка отзыва сессий", zap.Uint("user_id", member.ID), zap.Error(err))
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse("User deleted"))
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"GoBlast/internal/links"
+	"GoBlast/pkg/linktrack"
+	"GoBlast/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LinkHandler обслуживает редиректы отслеживаемых ссылок
+type LinkHandler struct {
+	repo *links.LinksRepository
+}
+
+func NewLinkHandler(repo *links.LinksRepository) *LinkHandler {
+	return &LinkHandler{repo: repo}
+}
+
+// Redirect Записывает клик и перенаправляет на исходную ссылку
+// @Summary Переход по отслеживаемой ссылке
+// @Description Проверяет подпись кода, записывает клик получателя и делает редирект на исходный URL.
+// @Tags Links
+// @Param code path string true "Код ссылки"
+// @Success 302 "Редирект на исходную ссылку"
+// @Failure 404 "Ссылка не найдена"
+// @Router /r/{code} [get]
+func (h *LinkHandler) Redirect(c *gin.Context) {
+	linkID, recipient, err := linktrack.Decode(c.Param("code"))
+	if err != nil {
+		c.String(http.StatusNotFound, "Link not found")
+		return
+	}
+
+	link, err := h.repo.FindByID(linkID)
+	if err != nil {
+		c.String(http.StatusNotFound, "Link not found")
+		return
+	}
+
+	// Ошибка записи клика не должна ломать переход пользователя
+	if err := h.repo.RecordClick(link, recipient); err != nil {
+		logger.Log.Error("Ошибка записи клика",
+			zap.Uint("link_id", link.ID),
+			zap.String("task_id", link.TaskID),
+			zap.Error(err))
+	}
+
+	c.Redirect(http.StatusFound, link.URL)
+}
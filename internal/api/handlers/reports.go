@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"GoBlast/internal/reports"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/response"
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// reportDefaultDays — период отчёта, если from не задан
+	reportDefaultDays = 7
+	// reportMaxDays — самый длинный период отчёта
+	reportMaxDays = 366
+)
+
+// ReportHandler отдаёт отчёты по рассылкам
+type ReportHandler struct {
+	repo *reports.ReportsRepository
+}
+
+// NewReportHandler создаёт новый ReportHandler
+func NewReportHandler(repo *reports.ReportsRepository) *ReportHandler {
+	return &ReportHandler{repo: repo}
+}
+
+// GetReport Возвращает отчёт по рассылкам за период
+// @Summary Отчёт по рассылкам
+// @Description Сводка по завершённым и идущим рассылкам пользователя и его организации: отправлено и ошибок,
+// @Description разбивка по кодам ошибок, средняя скорость отправки (сообщений в секунду), средняя задержка
+// @Description от создания задачи до окончания рассылки (секунды) и доля получателей, заблокировавших бота.
+// @Description Дни и недели считаются по UTC, задачи попадают в период по дате создания.
+// @Tags Reports
+// @Security BearerAuth
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "Начало периода YYYY-MM-DD (по умолчанию — 7 дней до to)"
+// @Param to query string false "Конец периода YYYY-MM-DD включительно (по умолчанию — сегодня)"
+// @Param group_by query string false "Измерения через запятую: day, week, bot, content_type (по умолчанию day)"
+// @Param format query string false "json (по умолчанию) или csv"
+// @Success 200 {object} response.APIResponse{data=reports.Report} "Отчёт"
+// @Failure 400 {object} response.APIResponse "Некорректные параметры"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /reports [get]
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	filter, err := reportFilter(c, time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+		return
+	}
+	filter.UserID = claims.UserID
+	filter.OrgID = claims.OrgID
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("format must be one of: json, csv"))
+		return
+	}
+
+	report, err := h.repo.Build(filter)
+	if err != nil {
+		logger.Log.Error("Ошибка построения отчёта", zap.Uint("user_id", claims.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to build report"))
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, response.SuccessResponse(report))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := reports.WriteCSV(&buf, report); err != nil {
+		logger.Log.Error("Ошибка формирования CSV отчёта", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to build report"))
+		return
+	}
+	filename := fmt.Sprintf("report_%s_%s.csv",
+		filter.From.Format("2006-01-02"), filter.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// reportFilter разбирает период и группировку; to включается в период целиком
+func reportFilter(c *gin.Context, now time.Time) (reports.Filter, error) {
+	var filter reports.Filter
+
+	to := now.Truncate(24 * time.Hour)
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, fmt.Errorf("to must be a date in YYYY-MM-DD format")
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -(reportDefaultDays - 1))
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, fmt.Errorf("from must be a date in YYYY-MM-DD format")
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		return filter, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) >= reportMaxDays*24*time.Hour {
+		return filter, fmt.Errorf("report period must not exceed %d days", reportMaxDays)
+	}
+
+	groupBy, err := reports.ParseGroupBy(c.DefaultQuery("group_by", reports.GroupDay))
+	if err != nil {
+		return filter, err
+	}
+
+	filter.From = from
+	filter.To = to.AddDate(0, 0, 1)
+	filter.GroupBy = groupBy
+	return filter, nil
+}
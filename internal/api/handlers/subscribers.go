@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"GoBlast/internal/bots"
+	"GoBlast/internal/subscribers"
+	"GoBlast/internal/users"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/queue"
+	"GoBlast/pkg/response"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	tele "gopkg.in/telebot.v4"
+)
+
+// Режимы получения обновлений бота
+const (
+	UpdatesOff     = "off"
+	UpdatesPolling = "polling"
+	UpdatesWebhook = "webhook"
+)
+
+// SubscriberHandler управляет сбором подписчиков из обновлений бота
+type SubscriberHandler struct {
+	repo       *subscribers.SubscriberRepository
+	userRepo   *users.AuthUserRepository
+	botRepo    *bots.BotRepository
+	natsClient *queue.NATSClient
+	publicURL  string
+}
+
+func NewSubscriberHandler(repo *subscribers.SubscriberRepository, userRepo *users.AuthUserRepository,
+	botRepo *bots.BotRepository, natsClient *queue.NATSClient, publicURL string) *SubscriberHandler {
+	return &SubscriberHandler{
+		repo:       repo,
+		userRepo:   userRepo,
+		botRepo:    botRepo,
+		natsClient: natsClient,
+		publicURL:  strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// UpdatesModeInput — режим получения обновлений бота
+type UpdatesModeInput struct {
+	Mode  string `json:"mode" binding:"required"` // off, polling, webhook
+	BotID uint   `json:"bot_id,omitempty"`        // бот из /api/bots; не задан — бот по умолчанию аккаунта
+}
+
+// BotUpdatesEvent — событие смены режима обновлений (subject "bots.updates").
+// Задан BotID (бот из /api/bots) или UserID (бот по умолчанию аккаунта).
+type BotUpdatesEvent struct {
+	UserID uint   `json:"user_id,omitempty"`
+	BotID  uint   `json:"bot_id,omitempty"`
+	Mode   string `json:"mode"`
+}
+
+// ListSubscribers Возвращает подписчиков бота
+// @Summary Список подписчиков
+// @Description Подписчики, собранные из обновлений ботов пользователя и его организации, и их количество по статусам.
+// @Tags Subscribers
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "active, stopped, blocked"
+// @Param limit query int false "Размер страницы (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} response.APIResponse "Подписчики"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /subscribers [get]
+func (h *SubscriberHandler) ListSubscribers(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	list, err := h.repo.List(claims.UserID, claims.OrgID, c.Query("status"), limit, offset)
+	if err != nil {
+		logger.Log.Error("Ошибка получения подписчиков", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to list subscribers"))
+		return
+	}
+	counts, err := h.repo.CountByStatus(claims.UserID, claims.OrgID)
+	if err != nil {
+		logger.Log.Error("Ошибка подсчёта подписчиков", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to count subscribers"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]interface{}{
+		"subscribers": list,
+		"counts":      counts,
+	}))
+}
+
+// SetUpdatesMode Включает или выключает сбор подписчиков
+// @Summary Режим получения обновлений бота
+// @Description Для бота из /api/bots (bot_id) или, без bot_id, бота по умолчанию аккаунта. polling — планировщик (goblast scheduler) опрашивает Telegram (getUpdates), webhook — Telegram присылает обновления на /webhook/bots/{bot_id} (бот по умолчанию — на /webhook/{user_id}), off — сбор выключен.
+// @Tags Subscribers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param mode body UpdatesModeInput true "Режим"
+// @Success 200 {object} response.APIResponse "Режим изменён"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 404 {object} response.APIResponse "Бот не найден"
+// @Failure 502 {object} response.APIResponse "Telegram отклонил настройку вебхука"
+// @Router /subscribers/mode [put]
+func (h *SubscriberHandler) SetUpdatesMode(c *gin.Context) {
+	var input UpdatesModeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid input"))
+		return
+	}
+	if input.Mode != UpdatesOff && input.Mode != UpdatesPolling && input.Mode != UpdatesWebhook {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("mode must be one of: off, polling, webhook"))
+		return
+	}
+	if input.Mode == UpdatesWebhook && !strings.HasPrefix(h.publicURL, "https://") {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("webhook mode requires app.public_url with https"))
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	// Бот из /api/bots или бот по умолчанию аккаунта: его токен, адрес вебхука и где хранится режим
+	var (
+		encryptedToken string
+		webhookPath    string
+		event          BotUpdatesEvent
+		saveMode       func(mode, secret string) error
+	)
+	if input.BotID != 0 {
+		bot, err := h.botRepo.Get(claims.OrgID, input.BotID)
+		if err != nil {
+			if errors.Is(err, bots.ErrNotFound) {
+				c.JSON(http.StatusNotFound, response.ErrorResponse("Bot not found"))
+			} else {
+				logger.Log.Error("Ошибка получения бота", zap.Uint("bot_id", input.BotID), zap.Error(err))
+				c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to load bot"))
+			}
+			return
+		}
+		encryptedToken = bot.Token
+		webhookPath = fmt.Sprintf("/webhook/bots/%d", bot.ID)
+		event = BotUpdatesEvent{BotID: bot.ID, Mode: input.Mode}
+		saveMode = func(mode, secret string) error { return h.botRepo.UpdateUpdatesMode(bot.ID, mode, secret) }
+	} else {
+		user, err := h.userRepo.FindByID(claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, response.ErrorResponse("User not found"))
+			return
+		}
+		if user.Token == "" {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse("Account has no default bot, pass bot_id"))
+			return
+		}
+		encryptedToken = user.Token
+		webhookPath = fmt.Sprintf("/webhook/%d", user.ID)
+		event = BotUpdatesEvent{UserID: user.ID, Mode: input.Mode}
+		saveMode = func(mode, secret string) error { return h.userRepo.UpdateUpdatesMode(user.ID, mode, secret) }
+	}
+	logFields := []zap.Field{zap.Uint("user_id", event.UserID), zap.Uint("bot_id", event.BotID)}
+
+	botToken, err := bots.DecryptToken(encryptedToken)
+	if err != nil {
+		logger.Log.Error("Ошибка дешифрования токена", append(logFields, zap.Error(err))...)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to decrypt bot token"))
+		return
+	}
+	bot, err := tele.NewBot(tele.Settings{Token: botToken, Offline: true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to create bot"))
+		return
+	}
+
+	// Telegram не отдаёт getUpdates, пока установлен вебхук, поэтому
+	// при переходе на polling/off вебхук снимается
+	var secret string
+	if input.Mode == UpdatesWebhook {
+		secret, err = randomSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to generate webhook secret"))
+			return
+		}
+		err = bot.SetWebhook(&tele.Webhook{
+			AllowedUpdates: subscribers.AllowedUpdates,
+			SecretToken:    secret,
+			Endpoint:       &tele.WebhookEndpoint{PublicURL: h.publicURL + webhookPath},
+		})
+	} else {
+		err = bot.RemoveWebhook()
+	}
+	if err != nil {
+		logger.Log.Error("Ошибка настройки вебхука", append(logFields, zap.Error(err))...)
+		c.JSON(http.StatusBadGateway, response.ErrorResponse("Telegram rejected webhook settings: "+err.Error()))
+		return
+	}
+
+	if err := saveMode(input.Mode, secret); err != nil {
+		logger.Log.Error("Ошибка сохранения режима обновлений", append(logFields, zap.Error(err))...)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to save updates mode"))
+		return
+	}
+
+	// Планировщик запускает или останавливает long polling для этого бота
+	publishUpdatesMode(h.natsClient, event)
+
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]string{
+		"mode": input.Mode,
+	}))
+}
+
+// publishUpdatesMode сообщает планировщику о смене режима обновлений бота
+func publishUpdatesMode(natsClient *queue.NATSClient, event BotUpdatesEvent) {
+	data, _ := json.Marshal(event)
+	if err := natsClient.Conn.Publish("bots.updates", data); err != nil {
+		logger.Log.Error("Ошибка публикации в bots.updates", zap.Error(err))
+	}
+}
+
+// Webhook Принимает обновления Telegram для бота по умолчанию пользователя
+// @Summary Вебхук Telegram (бот по умолчанию)
+// @Description Endpoint, который GoBlast регистрирует в Telegram в режиме webhook. Проверяет X-Telegram-Bot-Api-Secret-Token.
+// @Tags Subscribers
+// @Accept json
+// @Param user_id path int true "ID пользователя"
+// @Success 200 "Обновление принято"
+// @Failure 401 "Неверный секрет"
+// @Router /webhook/{user_id} [post]
+func (h *SubscriberHandler) Webhook(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	user, err := h.userRepo.FindByID(uint(userID))
+	if err != nil || user.UpdatesMode != UpdatesWebhook || user.WebhookSecret == "" {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	h.ingestWebhook(c, user.WebhookSecret, user.ID, 0)
+}
+
+// BotWebhook Принимает обновления Telegram для бота из /api/bots
+// @Summary Вебхук Telegram (бот организации)
+// @Description Endpoint, который GoBlast регистрирует в Telegram в режиме webhook. Проверяет X-Telegram-Bot-Api-Secret-Token.
+// @Tags Subscribers
+// @Accept json
+// @Param bot_id path int true "ID бота"
+// @Success 200 "Обновление принято"
+// @Failure 401 "Неверный секрет"
+// @Router /webhook/bots/{bot_id} [post]
+func (h *SubscriberHandler) BotWebhook(c *gin.Context) {
+	botID, err := strconv.ParseUint(c.Param("bot_id"), 10, 64)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	bot, err := h.botRepo.FindByID(uint(botID))
+	if err != nil || bot.UpdatesMode != UpdatesWebhook || bot.WebhookSecret == "" {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	h.ingestWebhook(c, bot.WebhookSecret, 0, bot.ID)
+}
+
+// ingestWebhook проверяет секрет вебхука и учитывает подписчика из обновления
+func (h *SubscriberHandler) ingestWebhook(c *gin.Context, webhookSecret string, userID, botID uint) {
+	secret := c.GetHeader("X-Telegram-Bot-Api-Secret-Token")
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(webhookSecret)) != 1 {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	var upd tele.Update
+	if err := c.ShouldBindJSON(&upd); err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	// Telegram повторяет доставку при ошибке, поэтому сбой записи отдаём как 500
+	if err := h.repo.Ingest(userID, botID, upd); err != nil {
+		logger.Log.Error("Ошибка учёта подписчика", zap.Uint("user_id", userID), zap.Uint("bot_id", botID), zap.Error(err))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func randomSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"GoBlast/internal/api/middleware"
+	"GoBlast/internal/tasks"
+	"GoBlast/internal/worker"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// EditTaskRequest — новые текст, подпись и/или кнопки для уже отправленной рассылки
+type EditTaskRequest struct {
+	Text    string     `json:"text,omitempty"`    // для текстовых рассылок
+	Caption string     `json:"caption,omitempty"` // для рассылок с медиа
+	Buttons [][]Button `json:"buttons,omitempty"` // пусто — остаются кнопки исходной рассылки
+}
+
+// mediaWithCaption — типы контента, у которых можно поменять подпись
+var mediaWithCaption = map[string]bool{
+	"photo": true, "video": true, "animation": true, "audio": true, "document": true,
+}
+
+func validateEdit(parentType string, req EditTaskRequest) error {
+	if req.Text == "" && req.Caption == "" && len(req.Buttons) == 0 {
+		return fmt.Errorf("text, caption or buttons is required")
+	}
+	if req.Text != "" && req.Caption != "" {
+		return fmt.Errorf("text and caption are mutually exclusive")
+	}
+	switch {
+	case req.Text != "" && parentType != "text" && parentType != "copy":
+		return fmt.Errorf("text can only be edited for 'text' tasks")
+	case req.Caption != "" && !mediaWithCaption[parentType] && parentType != "copy":
+		return fmt.Errorf("caption cannot be edited for '%s' tasks", parentType)
+	}
+	return validateButtons(req.Buttons)
+}
+
+// currentClaims извлекает claims, положенные JWTMiddleware
+func currentClaims(c *gin.Context) (*middleware.Claims, bool) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		logger.Log.Error("Ошибка авторизации: claims отсутствуют в контексте")
+		return nil, false
+	}
+
+	userClaims, ok := claims.(*middleware.Claims)
+	if !ok {
+		logger.Log.Error("Ошибка авторизации: claims неверного формата")
+		return nil, false
+	}
+	return userClaims, true
+}
+
+// currentUserID извлекает user_id из claims, положенных JWTMiddleware
+func currentUserID(c *gin.Context) (uint, bool) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return 0, false
+	}
+	return claims.UserID, true
+}
+
+// requestActor — кто меняет статус задачи в текущем запросе (для истории статусов)
+func requestActor(c *gin.Context) string {
+	if userID, ok := currentUserID(c); ok {
+		return tasks.UserActor(userID)
+	}
+	return tasks.ActorSystem
+}
+
+// currentOrgID извлекает org_id из claims, положенных JWTMiddleware
+func currentOrgID(c *gin.Context) (uint, bool) {
+	claims, ok := currentClaims(c)
+	if !ok || claims.OrgID == 0 {
+		return 0, false
+	}
+	return claims.OrgID, true
+}
+
+// canAccessTask сообщает, что задача создана текущим пользователем или участником его организации
+func canAccessTask(claims *middleware.Claims, task *models.Task) bool {
+	if task.UserID == claims.UserID {
+		return true
+	}
+	return task.OrgID != nil && claims.OrgID != 0 && *task.OrgID == claims.OrgID
+}
+
+// EditTask Редактирует уже отправленную рассылку у всех получателей
+// @Summary Отредактировать рассылку
+// @Description Создаёт задачу, которая меняет текст, подпись или кнопки у всех отправленных сообщений задачи, включая рассылку победителя A/B-теста. Без buttons кнопки исходной рассылки сохраняются.
+// @Tags Tasks
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Param edit body EditTaskRequest true "Новое содержимое"
+// @Success 201 {object} response.APIResponse "Задача редактирования создана"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 404 {object} response.APIResponse "Задача не найдена"
+// @Failure 409 {object} response.APIResponse "Рассылка ещё не завершена"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /tasks/{id}/edit [post]
+func (h *TaskHandler) EditTask(c *gin.Context) {
+	var req EditTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Log.Error("Ошибка привязки JSON", zap.Error(err))
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid request payload"))
+		return
+	}
+
+	parent, ok := h.loadParentTask(c)
+	if !ok {
+		return
+	}
+
+	if err := validateEdit(parent.MessageType, req); err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+		return
+	}
+
+	content := Content{
+		Type:    parent.MessageType,
+		Text:    req.Text,
+		Caption: req.Caption,
+		Buttons: req.Buttons,
+	}
+	// Telegram убирает inline-клавиатуру у сообщения, отредактированного без reply_markup,
+	// поэтому без новых кнопок передаём кнопки исходной рассылки (с их отслеживанием кликов)
+	if len(content.Buttons) == 0 {
+		var original Content
+		if err := json.Unmarshal([]byte(parent.Content), &original); err != nil {
+			logger.Log.Error("Ошибка десериализации контента", zap.String("task_id", parent.ID), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to read task content"))
+			return
+		}
+		content.Buttons = original.Buttons
+		content.TrackedLinks = original.TrackedLinks
+	}
+
+	h.createFollowUpTask(c, parent, "edit", content, nil)
+}
+
+// RecallTask Удаляет уже отправленную рассылку у всех получателей
+// @Summary Отозвать рассылку
+// @Description Создаёт задачу, которая удаляет все отправленные сообщения задачи, включая рассылку победителя A/B-теста.
+// @Tags Tasks
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Success 201 {object} response.APIResponse "Задача удаления создана"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 404 {object} response.APIResponse "Задача не найдена"
+// @Failure 409 {object} response.APIResponse "Рассылка ещё не завершена"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /tasks/{id}/recall [post]
+func (h *TaskHandler) RecallTask(c *gin.Context) {
+	parent, ok := h.loadParentTask(c)
+	if !ok {
+		return
+	}
+
+	h.createFollowUpTask(c, parent, "recall", Content{Type: parent.MessageType}, nil)
+}
+
+// CancelTask Отменяет незавершённую рассылку
+// @Summary Отменить рассылку
+// @Description Переводит задачу в cancelled; воркер пропускает получателей, которым сообщение ещё не отправлено.
+// @Tags Tasks
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Success 200 {object} response.APIResponse "Задача отменена"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 404 {object} response.APIResponse "Задача не найдена"
+// @Failure 409 {object} response.APIResponse "Задача уже завершена"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /tasks/{id}/cancel [post]
+func (h *TaskHandler) CancelTask(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	task, err := h.repo.GetTaskByID(c.Param("id"))
+	if err != nil || !canAccessTask(claims, task) {
+		c.JSON(http.StatusNotFound, response.ErrorResponse("Task not found"))
+		return
+	}
+
+	change := tasks.StatusChange{Actor: requestActor(c), Reason: "cancelled by user"}
+	if err := h.repo.ChangeStatus(task.ID, models.TaskCancelled, change); err != nil {
+		if errors.Is(err, tasks.ErrInvalidTransition) {
+			c.JSON(http.StatusConflict, response.ErrorResponse("Task has already finished"))
+			return
+		}
+		logger.Log.Error("Ошибка отмены задачи", zap.String("task_id", task.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to cancel task"))
+		return
+	}
+
+	h.stopTaskWorkers(task.ID)
+
+	logger.Log.Info("Задача отменена", zap.String("task_id", task.ID))
+	c.JSON(http.StatusOK, response.SuccessResponse(map[string]interface{}{
+		"task_id": task.ID,
+		"status":  models.TaskCancelled,
+	}))
+}
+
+// stopTaskWorkers сообщает воркерам, что задача завершена без них (отменена или не опубликована
+// целиком): принятые части перестают рассылаться. Части, ещё не принятые воркером, он
+// пропустит сам — задача уже в завершённом статусе.
+func (h *TaskHandler) stopTaskWorkers(taskID string) {
+	payload, err := json.Marshal(worker.TaskCancelEvent{TaskID: taskID})
+	if err == nil {
+		err = h.natsClient.Conn.Publish(worker.SubjectTaskCancel, payload)
+	}
+	if err != nil {
+		logger.Log.Error("Ошибка уведомления воркеров об остановке задачи",
+			zap.String("task_id", taskID), zap.Error(err))
+	}
+}
+
+// WinnerRequest — выбор варианта-победителя A/B-теста
+type WinnerRequest struct {
+	Variant string `json:"variant,omitempty"` // если пусто — вариант с лучшей доставкой
+}
+
+// SendWinner Отправляет вариант-победитель A/B-теста остальным получателям
+// @Summary Отправить победителя A/B-теста
+// @Description Отправляет выбранный (или лучший по доставке) вариант получателям, не попавшим в тестовую выборку.
+// @Tags Tasks
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Param winner body WinnerRequest false "Вариант-победитель"
+// @Success 201 {object} response.APIResponse "Задача рассылки победителя создана"
+// @Failure 400 {object} response.APIResponse "Некорректные входные данные"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 404 {object} response.APIResponse "Задача не найдена"
+// @Failure 409 {object} response.APIResponse "Тест ещё не завершён или победитель уже отправлен"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /tasks/{id}/winner [post]
+func (h *TaskHandler) SendWinner(c *gin.Context) {
+	var req WinnerRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse("Invalid request payload"))
+			return
+		}
+	}
+
+	parent, ok := h.loadParentTask(c)
+	if !ok {
+		return
+	}
+	if parent.Variants == nil || parent.Holdout == nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Task has no variants awaiting a winner"))
+		return
+	}
+
+	var variants []Variant
+	if err := json.Unmarshal([]byte(*parent.Variants), &variants); err != nil {
+		logger.Log.Error("Ошибка десериализации вариантов", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to read variants"))
+		return
+	}
+
+	winner, err := pickWinner(variants, parent.Stats, req.Variant)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+		return
+	}
+
+	task, err := newFollowUpTask(parent, "send", winner.Content)
+	if err != nil {
+		logger.Log.Error("Ошибка сериализации контента", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to serialize content"))
+		return
+	}
+
+	// Забираем отложенных получателей в одной транзакции с сохранением задачи
+	// победителя, чтобы он ушёл только один раз
+	actor := requestActor(c)
+	holdout, err := h.repo.SaveWinnerTask(parent.ID, task, actor)
+	if err != nil {
+		logger.Log.Error("Ошибка сохранения задачи победителя", zap.String("task_id", parent.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to save task"))
+		return
+	}
+	if len(holdout) == 0 {
+		c.JSON(http.StatusConflict, response.ErrorResponse("Winner has already been sent"))
+		return
+	}
+
+	logger.Log.Info("Выбран победитель A/B-теста",
+		zap.String("task_id", parent.ID),
+		zap.String("variant", winner.Name))
+
+	if err := h.dispatchFollowUpTask(c, parent, task, winner.Content, holdout, actor); err != nil {
+		// Задача победителя не ушла воркеру: возвращаем получателей для повторной попытки
+		if err := h.repo.RestoreHoldout(parent.ID, holdout); err != nil {
+			logger.Log.Error("Ошибка возврата отложенных получателей", zap.String("task_id", parent.ID), zap.Error(err))
+		}
+	}
+}
+
+// pickWinner возвращает вариант по имени, либо вариант с лучшей долей доставленных сообщений
+func pickWinner(variants []Variant, stats *models.Stats, name string) (*Variant, error) {
+	if name != "" {
+		for i := range variants {
+			if variants[i].Name == name {
+				return &variants[i], nil
+			}
+		}
+		return nil, fmt.Errorf("unknown variant: %s", name)
+	}
+
+	if stats == nil {
+		stats = &models.Stats{}
+	}
+
+	var (
+		best     *Variant
+		bestRate = -1.0
+	)
+	for i := range variants {
+		vs := stats.ByVariant[variants[i].Name]
+		if vs == nil || vs.Sent+vs.Failed == 0 {
+			continue
+		}
+		rate := float64(vs.Sent) / float64(vs.Sent+vs.Failed)
+		if rate > bestRate {
+			best, bestRate = &variants[i], rate
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no variant stats to pick a winner, specify variant explicitly")
+	}
+	return best, nil
+}
+
+// loadParentTask находит рассылку текущего пользователя, которую хотят изменить.
+// При ошибке сам пишет ответ и возвращает false.
+func (h *TaskHandler) loadParentTask(c *gin.Context) (*models.Task, bool) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return nil, false
+	}
+
+	parent, err := h.repo.GetTaskByID(c.Param("id"))
+	if err != nil || !canAccessTask(claims, parent) {
+		c.JSON(http.StatusNotFound, response.ErrorResponse("Task not found"))
+		return nil, false
+	}
+	if parent.Action != "send" {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("Only broadcast tasks can be edited or recalled"))
+		return nil, false
+	}
+	if parent.Status != models.TaskComplete && parent.Status != models.TaskPartiallyFailed {
+		c.JSON(http.StatusConflict, response.ErrorResponse("Task has not finished sending yet"))
+		return nil, false
+	}
+	return parent, true
+}
+
+// taskBotID возвращает бот задачи для сообщения NATS (0 — бот из AuthUser.Token)
+func taskBotID(task *models.Task) uint {
+	if task.BotID == nil {
+		return 0
+	}
+	return *task.BotID
+}
+
+// createFollowUpTask сохраняет задачу, порождённую parent, и отправляет её воркеру через NATS.
+// У такой задачи свой ID, статус и статистика. Для edit/recall получатели берутся из
+// сообщений родителя, для send (победитель A/B-теста) — из recipients.
+func (h *TaskHandler) createFollowUpTask(c *gin.Context, parent *models.Task, action string, content Content, recipients []int64) {
+	task, err := newFollowUpTask(parent, action, content)
+	if err != nil {
+		logger.Log.Error("Ошибка сериализации контента", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to serialize content"))
+		return
+	}
+
+	actor := requestActor(c)
+	if err := h.repo.SaveTask(task, actor); err != nil {
+		logger.Log.Error("Ошибка сохранения задачи в БД", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to save task"))
+		return
+	}
+
+	_ = h.dispatchFollowUpTask(c, parent, task, content, recipients, actor)
+}
+
+// newFollowUpTask собирает ещё не сохранённую задачу action, порождённую parent
+func newFollowUpTask(parent *models.Task, action string, content Content) (*models.Task, error) {
+	contentJSON, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Task{
+		ID:          uuid.New().String(),
+		UserID:      parent.UserID,
+		OrgID:       parent.OrgID,
+		BotID:       parent.BotID,
+		Action:      action,
+		ParentID:    &parent.ID,
+		MessageType: parent.MessageType,
+		Content:     string(contentJSON),
+		Priority:    parent.Priority,
+		Status:      models.TaskScheduled,
+	}, nil
+}
+
+// dispatchFollowUpTask передаёт сохранённую задачу воркеру и пишет ответ.
+// Возвращает ошибку, если задача не ушла в NATS.
+func (h *TaskHandler) dispatchFollowUpTask(c *gin.Context, parent, task *models.Task, content Content, recipients []int64, actor string) error {
+	err := h.dispatchTask(TaskNATSMessage{
+		TaskID:     task.ID,
+		UserID:     parent.UserID,
+		BotID:      taskBotID(parent),
+		Action:     task.Action,
+		ParentID:   parent.ID,
+		Recipients: recipients,
+		Content:    content,
+		Priority:   parent.Priority,
+	}, actor)
+	if err != nil {
+		logger.Log.Error("Ошибка публикации в NATS", zap.String("task_id", task.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to publish to NATS: "+err.Error()))
+		return err
+	}
+
+	logger.Log.Info("Задача успешно создана",
+		zap.String("task_id", task.ID),
+		zap.String("parent_id", parent.ID),
+		zap.String("action", task.Action))
+
+	c.JSON(http.StatusCreated, response.SuccessResponse(map[string]interface{}{
+		"task_id":   task.ID,
+		"parent_id": parent.ID,
+		"action":    task.Action,
+		"status":    models.TaskQueued,
+	}))
+	return nil
+}
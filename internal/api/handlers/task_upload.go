@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"GoBlast/internal/tasks"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// recipientsChunkSize — сколько получателей помещается в одно сообщение NATS
+// (~200 КБ при лимите NATS по умолчанию 1 МБ)
+const recipientsChunkSize = 10000
+
+// publishTask публикует задачу в NATS, разбивая список получателей на части
+// по recipientsChunkSize с общим TaskID и номером части
+func (h *TaskHandler) publishTask(msg TaskNATSMessage) error {
+	recipients := msg.Recipients
+	for index := 0; ; index++ {
+		chunk := msg
+		chunk.ChunkIndex = index
+		if len(recipients) > recipientsChunkSize {
+			chunk.Recipients = recipients[:recipientsChunkSize]
+			chunk.MoreChunks = true
+		} else {
+			chunk.Recipients = recipients
+		}
+		if err := h.publish(chunk); err != nil {
+			return err
+		}
+		if !chunk.MoreChunks {
+			return nil
+		}
+		recipients = recipients[recipientsChunkSize:]
+	}
+}
+
+// dispatchTask переводит сохранённую задачу в queued и публикует её в NATS.
+// Если опубликовать не удалось, задача помечается failed.
+func (h *TaskHandler) dispatchTask(msg TaskNATSMessage, actor string) error {
+	if err := h.repo.ChangeStatus(msg.TaskID, models.TaskQueued, tasks.StatusChange{Actor: actor}); err != nil {
+		return fmt.Errorf("queue task: %w", err)
+	}
+	if err := h.publishTask(msg); err != nil {
+		h.failPublished(msg.TaskID, actor, err)
+		return err
+	}
+	return nil
+}
+
+// failPublished помечает задачу failed после ошибки публикации в NATS. Части, опубликованные
+// до ошибки, воркеры не рассылают.
+func (h *TaskHandler) failPublished(taskID, actor string, cause error) {
+	change := tasks.StatusChange{Actor: actor, Reason: "failed to publish to NATS: " + cause.Error()}
+	if err := h.repo.ChangeStatus(taskID, models.TaskFailed, change); err != nil {
+		logger.Log.Error("Ошибка смены статуса задачи", zap.String("task_id", taskID), zap.Error(err))
+		return
+	}
+	h.stopTaskWorkers(taskID)
+}
+
+// publishChunk публикует одну часть задачи, заранее проверяя лимит размера сообщения NATS
+func (h *TaskHandler) publishChunk(msg TaskNATSMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal chunk %d: %w", msg.ChunkIndex, err)
+	}
+	if limit := h.natsClient.Conn.MaxPayload(); limit > 0 && int64(len(payload)) > limit {
+		return fmt.Errorf("chunk %d is %d bytes, NATS max_payload is %d bytes", msg.ChunkIndex, len(payload), limit)
+	}
+	if err := h.natsClient.Conn.Publish("tasks.create", payload); err != nil {
+		return fmt.Errorf("publish chunk %d: %w", msg.ChunkIndex, err)
+	}
+	return nil
+}
+
+// UploadRecipients Загружает получателей задачи потоком
+// @Summary Загрузить получателей
+// @Description Принимает Telegram Chat ID по одному в строке (text/plain) для задачи, созданной с upload_recipients=true.
+// @Description Тело читается потоком, получатели публикуются в NATS частями по мере чтения. Пустые строки пропускаются, некорректные — считаются в invalid_lines.
+// @Tags Tasks
+// @Security BearerAuth
+// @Accept plain
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Param recipients body string true "Chat ID по одному в строке"
+// @Success 202 {object} response.APIResponse "Получатели поставлены в очередь"
+// @Failure 400 {object} response.APIResponse "Нет корректных получателей или ошибка чтения"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 404 {object} response.APIResponse "Задача не найдена"
+// @Failure 409 {object} response.APIResponse "Получатели уже загружены"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /tasks/{id}/recipients [post]
+func (h *TaskHandler) UploadRecipients(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	task, err := h.repo.GetTaskByID(c.Param("id"))
+	if err != nil || !canAccessTask(claims, task) {
+		c.JSON(http.StatusNotFound, response.ErrorResponse("Task not found"))
+		return
+	}
+	if task.Status != models.TaskDraft {
+		c.JSON(http.StatusConflict, response.ErrorResponse("Task is not awaiting recipients"))
+		return
+	}
+
+	var msg TaskNATSMessage
+	if err := json.Unmarshal([]byte(task.Content), &msg.Content); err != nil {
+		logger.Log.Error("Ошибка десериализации контента", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to read task content"))
+		return
+	}
+	if task.Variants != nil {
+		if err := json.Unmarshal([]byte(*task.Variants), &msg.Variants); err != nil {
+			logger.Log.Error("Ошибка десериализации вариантов", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to read variants"))
+			return
+		}
+	}
+	msg.TaskID = task.ID
+	msg.UserID = task.UserID
+	msg.BotID = taskBotID(task)
+	msg.Action = "send"
+	msg.Priority = task.Priority
+
+	// Захватываем задачу, чтобы параллельная загрузка не отправила её второй раз
+	actor := tasks.UserActor(claims.UserID)
+	claimed, err := h.repo.TransitionStatus(task.ID, models.TaskDraft, models.TaskScheduled, actor)
+	if err != nil {
+		logger.Log.Error("Ошибка смены статуса задачи", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to update task"))
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusConflict, response.ErrorResponse("Task is not awaiting recipients"))
+		return
+	}
+
+	chunks := 0
+	upload, err := streamRecipients(c.Request.Body, func(recipients []int64, more bool) error {
+		if chunks == 0 {
+			if err := h.repo.ChangeStatus(task.ID, models.TaskQueued, tasks.StatusChange{Actor: actor}); err != nil {
+				return fmt.Errorf("queue task: %w", err)
+			}
+		}
+		chunk := msg
+		chunk.Recipients = recipients
+		chunk.ChunkIndex = chunks
+		chunk.MoreChunks = more
+		if err := h.publish(chunk); err != nil {
+			return err
+		}
+		chunks++
+		return nil
+	})
+	if err != nil {
+		h.failUpload(c, task.ID, actor, chunks, err)
+		return
+	}
+	if chunks == 0 {
+		// Ничего не опубликовано — задача снова ждёт загрузки
+		if _, err := h.repo.TransitionStatus(task.ID, models.TaskScheduled, models.TaskDraft, actor); err != nil {
+			logger.Log.Error("Ошибка смены статуса задачи", zap.Error(err))
+		}
+		if upload.readErr != nil {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse("Failed to read recipients: "+upload.readErr.Error()))
+			return
+		}
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("No valid recipients"))
+		return
+	}
+
+	logger.Log.Info("Получатели задачи загружены",
+		zap.String("task_id", task.ID),
+		zap.Int("recipients", upload.total),
+		zap.Int("chunks", chunks),
+		zap.Int("invalid_lines", upload.invalid))
+
+	if upload.readErr != nil {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(fmt.Sprintf(
+			"Upload interrupted after %d recipients (queued): %v", upload.total, upload.readErr)))
+		return
+	}
+	c.JSON(http.StatusAccepted, response.SuccessResponse(map[string]interface{}{
+		"task_id":       task.ID,
+		"status":        models.TaskQueued,
+		"recipients":    upload.total,
+		"chunks":        chunks,
+		"invalid_lines": upload.invalid,
+		"invalid_at":    upload.invalidLines,
+	}))
+}
+
+// recipientsUpload — итог чтения загружаемых получателей
+type recipientsUpload struct {
+	total        int   // корректных получателей
+	invalid      int   // некорректных строк
+	invalidLines []int // номера первых 10 некорректных строк
+	readErr      error // чтение тела оборвалось
+}
+
+// streamRecipients читает Chat ID по одному в строке и отдаёт их в publish частями
+// по recipientsChunkSize. Последняя часть неизвестна, пока тело не дочитано, поэтому
+// одна заполненная часть придерживается и уходит, когда начинается следующая.
+// Последняя часть (more=false) публикуется и после обрыва чтения: уже опубликованные
+// части без неё никогда не завершатся. Ошибка publish прерывает чтение.
+func streamRecipients(body io.Reader, publish func(recipients []int64, more bool) error) (recipientsUpload, error) {
+	var (
+		upload  recipientsUpload
+		pending []int64
+		current = make([]int64, 0, recipientsChunkSize)
+	)
+
+	reader := &errRecorder{r: body}
+	scanner := bufio.NewScanner(reader)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		// Строка, оборванная ошибкой чтения, может быть обрезанным Chat ID
+		if atEOF && reader.err != nil && bytes.IndexByte(data, '\n') < 0 {
+			return len(data), nil, nil
+		}
+		return bufio.ScanLines(data, atEOF)
+	})
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			upload.invalid++
+			if len(upload.invalidLines) < 10 {
+				upload.invalidLines = append(upload.invalidLines, line)
+			}
+			continue
+		}
+		current = append(current, id)
+		upload.total++
+		if len(current) < recipientsChunkSize {
+			continue
+		}
+		if pending != nil {
+			if err := publish(pending, true); err != nil {
+				return upload, err
+			}
+		}
+		pending, current = current, make([]int64, 0, recipientsChunkSize)
+	}
+	upload.readErr = scanner.Err()
+
+	if len(current) > 0 {
+		if pending != nil {
+			if err := publish(pending, true); err != nil {
+				return upload, err
+			}
+		}
+		pending = current
+	}
+	if pending == nil {
+		return upload, nil
+	}
+	return upload, publish(pending, false)
+}
+
+// errRecorder запоминает ошибку чтения, отличную от io.EOF
+type errRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errRecorder) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF {
+		e.err = err
+	}
+	return n, err
+}
+
+// failUpload сообщает об ошибке публикации посреди загрузки и прерывает задачу:
+// уже опубликованные части воркер пропустит
+func (h *TaskHandler) failUpload(c *gin.Context, taskID, actor string, published int, err error) {
+	logger.Log.Error("Ошибка публикации получателей в NATS",
+		zap.String("task_id", taskID),
+		zap.Int("published_chunks", published),
+		zap.Error(err))
+	h.failPublished(taskID, actor, err)
+	c.JSON(http.StatusInternalServerError, response.ErrorResponse(fmt.Sprintf(
+		"Failed to publish to NATS after %d chunks: %v", published, err)))
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// recipientLines возвращает тело загрузки с Chat ID от 1 до n
+func recipientLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "%d\n", i)
+	}
+	return b.String()
+}
+
+func TestPublishTaskChunks(t *testing.T) {
+	cases := []struct {
+		name       string
+		recipients int
+		want       []int // размеры частей
+	}{
+		{"empty", 0, []int{0}},
+		{"one chunk", 3, []int{3}},
+		{"exact chunk", recipientsChunkSize, []int{recipientsChunkSize}},
+		{"exact multiple", 2 * recipientsChunkSize, []int{recipientsChunkSize, recipientsChunkSize}},
+		{"remainder", recipientsChunkSize + 1, []int{recipientsChunkSize, 1}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got []TaskNATSMessage
+			h := &TaskHandler{publish: func(msg TaskNATSMessage) error {
+				got = append(got, msg)
+				return nil
+			}}
+
+			recipients := make([]int64, c.recipients)
+			if err := h.publishTask(TaskNATSMessage{TaskID: "t1", Recipients: recipients}); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(c.want) {
+				t.Fatalf("published %d chunks, want %d", len(got), len(c.want))
+			}
+			for i, msg := range got {
+				last := i == len(got)-1
+				if msg.TaskID != "t1" || msg.ChunkIndex != i || msg.MoreChunks == last || len(msg.Recipients) != c.want[i] {
+					t.Fatalf("chunk %d = {index %d, more %v, %d recipients}, want %d recipients",
+						i, msg.ChunkIndex, msg.MoreChunks, len(msg.Recipients), c.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPublishTaskStopsOnError(t *testing.T) {
+	calls := 0
+	h := &TaskHandler{publish: func(TaskNATSMessage) error {
+		calls++
+		return errors.New("nats down")
+	}}
+	err := h.publishTask(TaskNATSMessage{Recipients: make([]int64, 3*recipientsChunkSize)})
+	if err == nil || calls != 1 {
+		t.Fatalf("publishTask = %v after %d calls, want error after 1", err, calls)
+	}
+}
+
+func TestStreamRecipients(t *testing.T) {
+	readErr := errors.New("connection reset")
+
+	type chunk struct {
+		size int
+		more bool
+	}
+	cases := []struct {
+		name    string
+		body    io.Reader
+		total   int
+		invalid int
+		readErr bool
+		want    []chunk
+	}{
+		{
+			name: "empty body",
+			body: strings.NewReader(""),
+		},
+		{
+			name:    "only invalid lines",
+			body:    strings.NewReader("\nabc\n  \n"),
+			invalid: 1,
+		},
+		{
+			name:  "one partial chunk",
+			body:  strings.NewReader("1\n2\n3"),
+			total: 3,
+			want:  []chunk{{3, false}},
+		},
+		{
+			name:  "exact chunk",
+			body:  strings.NewReader(recipientLines(recipientsChunkSize)),
+			total: recipientsChunkSize,
+			want:  []chunk{{recipientsChunkSize, false}},
+		},
+		{
+			name:  "exact multiple",
+			body:  strings.NewReader(recipientLines(2 * recipientsChunkSize)),
+			total: 2 * recipientsChunkSize,
+			want:  []chunk{{recipientsChunkSize, true}, {recipientsChunkSize, false}},
+		},
+		{
+			name:  "remainder",
+			body:  strings.NewReader(recipientLines(recipientsChunkSize + 2)),
+			total: recipientsChunkSize + 2,
+			want:  []chunk{{recipientsChunkSize, true}, {2, false}},
+		},
+		{
+			// Оборванная строка "12" может быть началом другого Chat ID и не отправляется
+			name:    "interrupted mid-chunk",
+			body:    io.MultiReader(strings.NewReader(recipientLines(recipientsChunkSize+5)+"12"), iotest.ErrReader(readErr)),
+			total:   recipientsChunkSize + 5,
+			readErr: true,
+			want:    []chunk{{recipientsChunkSize, true}, {5, false}},
+		},
+		{
+			name:    "interrupted before any recipient",
+			body:    iotest.ErrReader(readErr),
+			readErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got []chunk
+			upload, err := streamRecipients(c.body, func(recipients []int64, more bool) error {
+				got = append(got, chunk{len(recipients), more})
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if upload.total != c.total || upload.invalid != c.invalid || (upload.readErr != nil) != c.readErr {
+				t.Fatalf("upload = %+v, want total %d, invalid %d, read error %v", upload, c.total, c.invalid, c.readErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(c.want) {
+				t.Fatalf("chunks = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestStreamRecipientsStopsOnPublishError(t *testing.T) {
+	calls := 0
+	_, err := streamRecipients(strings.NewReader(recipientLines(3*recipientsChunkSize)), func([]int64, bool) error {
+		calls++
+		return errors.New("nats down")
+	})
+	if err == nil || calls != 1 {
+		t.Fatalf("streamRecipients = %v after %d calls, want error after 1", err, calls)
+	}
+}
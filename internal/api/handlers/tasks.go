@@ -1,76 +1,429 @@
 package handlers
 
 import (
-	"GoBlast/internal/api/middleware"
+	"GoBlast/internal/audiences"
+	"GoBlast/internal/bots"
+	"GoBlast/internal/links"
+	"GoBlast/internal/subscribers"
 	"GoBlast/internal/tasks"
+	"GoBlast/internal/users"
+	"GoBlast/pkg/abtest"
+	"GoBlast/pkg/linktrack"
 	"GoBlast/pkg/logger"
 	"GoBlast/pkg/metrics"
 	"GoBlast/pkg/queue"
 	"GoBlast/pkg/response"
 	"GoBlast/pkg/storage/models"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"go.uber.org/zap"
 
 	"encoding/json"
 	"net/http"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v4"
+	"gorm.io/gorm"
 )
 
 type Content struct {
-	Type     string `json:"type"`      // "text", "photo", "video", ...
+	Type     string `json:"type"`      // "text", "photo", "video", "poll", "location", ...
 	Text     string `json:"text"`      // если type="text"
 	MediaURL string `json:"media_url"` // если передается URL
-	MediaID  string `json:"media_id"`  // если передается media_id
+	MediaID  string `json:"media_id"`  // если передается media_id (в т. ч. для type="sticker")
 	Caption  string `json:"caption"`   // подпись
+
+	Poll     *Poll     `json:"poll,omitempty"`     // если type="poll"
+	Location *Location `json:"location,omitempty"` // если type="location"
+	Venue    *Venue    `json:"venue,omitempty"`    // если type="venue"
+	Contact  *Contact  `json:"contact,omitempty"`  // если type="contact"
+	Emoji    string    `json:"emoji,omitempty"`    // если type="dice": 🎲, 🎯, 🏀, ⚽, 🎰, 🎳
+
+	FromChatID int64 `json:"from_chat_id,omitempty"` // если type="copy"/"forward": чат с исходным сообщением
+	MessageID  int   `json:"message_id,omitempty"`   // если type="copy"/"forward": ID исходного сообщения
+
+	Buttons [][]Button `json:"buttons,omitempty"` // inline-кнопки со ссылками (кроме type="forward")
+
+	// Заполняется сервером при track_clicks: url -> ID отслеживаемой ссылки
+	TrackedLinks map[string]uint `json:"tracked_links,omitempty" swaggerignore:"true"`
+}
+
+// Button — inline-кнопка со ссылкой
+type Button struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Poll — опрос (обычный или викторина)
+type Poll struct {
+	Question        string   `json:"question"`
+	Options         []string `json:"options"`
+	Type            string   `json:"type,omitempty"`           // regular (по умолчанию) или quiz
+	CorrectOption   *int     `json:"correct_option,omitempty"` // индекс правильного ответа, обязателен для quiz
+	MultipleAnswers bool     `json:"multiple_answers,omitempty"`
+	Anonymous       *bool    `json:"anonymous,omitempty"` // по умолчанию true
+	Explanation     string   `json:"explanation,omitempty"`
+}
+
+// Location — точка на карте
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Venue — место (точка на карте с названием и адресом)
+type Venue struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Title     string  `json:"title"`
+	Address   string  `json:"address"`
+}
+
+// Contact — карточка контакта
+type Contact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name,omitempty"`
 }
 
 type TaskRequest struct {
-	Recipients []int64 `json:"recipients" binding:"required"` // Telegram Chat IDs
-	Content    Content `json:"content" binding:"required"`
-	Priority   string  `json:"priority,omitempty"` // high, medium, low
-	Schedule   string  `json:"schedule,omitempty"` // RFC3339
+	Recipients []int64 `json:"recipients,omitempty"` // Telegram Chat IDs
+	Content    Content `json:"content"`              // обязателен, если не заданы variants
+
+	// Бот из /api/bots, от имени которого идёт рассылка;
+	// если не задан — бот, указанный при регистрации
+	BotID uint `json:"bot_id,omitempty"`
+
+	// Аудитория вместо списка recipients; tag_expr фильтрует участников,
+	// например "vip AND (ru OR en) AND NOT churned"
+	AudienceID uint   `json:"audience_id,omitempty"`
+	TagExpr    string `json:"tag_expr,omitempty"`
+
+	// Подписчики бота (status active) вместо recipients и audience_id;
+	// language_code оставляет только подписчиков с этим языком
+	ToSubscribers bool   `json:"to_subscribers,omitempty"`
+	LanguageCode  string `json:"language_code,omitempty"`
+
+	// Получатели загружаются отдельно потоком через POST /tasks/{id}/recipients
+	// (для списков, которые неудобно передавать в JSON)
+	UploadRecipients bool `json:"upload_recipients,omitempty"`
+
+	// A/B-тест: варианты контента вместо Content. Получатель попадает в вариант
+	// детерминированно (по хешу получателя и задачи) пропорционально весам.
+	Variants []Variant `json:"variants,omitempty"`
+	// Процент получателей для тестовой выборки (1-99, 0 — без выборки). Остальным позже
+	// отправляется вариант-победитель через POST /tasks/{id}/winner.
+	TestPercent int `json:"test_percent,omitempty"`
+	// Отслеживать клики: ссылки в тексте, подписи и кнопках заменяются
+	// на персональные редиректы /r/{code}
+	TrackClicks bool   `json:"track_clicks,omitempty"`
+	Priority    string `json:"priority,omitempty"` // high, medium, low
+	Schedule    string `json:"schedule,omitempty"` // RFC3339
+}
+
+// Variant — вариант контента в A/B-тесте
+type Variant struct {
+	Name    string  `json:"name"`
+	Weight  int     `json:"weight"`
+	Content Content `json:"content"`
 }
 
 type TaskNATSMessage struct {
-	TaskID     string  `json:"task_id"`
-	UserID     uint    `json:"user_id"`
-	Recipients []int64 `json:"recipients"`
-	Content    Content `json:"content"`
-	Priority   string  `json:"priority,omitempty"`
+	TaskID        string    `json:"task_id"`
+	UserID        uint      `json:"user_id"`
+	BotID         uint      `json:"bot_id,omitempty"`    // 0 — бот из AuthUser.Token
+	Action        string    `json:"action,omitempty"`    // send, edit, recall
+	ParentID      string    `json:"parent_id,omitempty"` // для edit/recall
+	Recipients    []int64   `json:"recipients"`
+	AudienceID    uint      `json:"audience_id,omitempty"`
+	TagExpr       string    `json:"tag_expr,omitempty"`
+	ToSubscribers bool      `json:"to_subscribers,omitempty"`
+	LanguageCode  string    `json:"language_code,omitempty"`
+	Content       Content   `json:"content"`
+	Variants      []Variant `json:"variants,omitempty"`
+	Priority      string    `json:"priority,omitempty"`
+	// Большие списки получателей публикуются несколькими сообщениями с общим TaskID
+	ChunkIndex int  `json:"chunk_index,omitempty"`
+	MoreChunks bool `json:"more_chunks,omitempty"` // false у последней (или единственной) части
 	// Schedule  string   `json:"schedule,omitempty"` // если нужно
 }
 
 // TaskHandler обрабатывает задачи
 type TaskHandler struct {
-	repo       *tasks.TasksRepository
-	natsClient *queue.NATSClient
+	repo           *tasks.TasksRepository
+	userRepo       *users.AuthUserRepository
+	linkRepo       *links.LinksRepository
+	audienceRepo   *audiences.AudienceRepository
+	subscriberRepo *subscribers.SubscriberRepository
+	botRepo        *bots.BotRepository
+	natsClient     *queue.NATSClient
+
+	// publish отправляет одну часть задачи в NATS; в тестах подменяется
+	publish func(msg TaskNATSMessage) error
 }
 
 // NewTaskHandler создаёт новый TaskHandler
-func NewTaskHandler(repo *tasks.TasksRepository, natsClient *queue.NATSClient) *TaskHandler {
-	return &TaskHandler{repo: repo, natsClient: natsClient}
+func NewTaskHandler(repo *tasks.TasksRepository, userRepo *users.AuthUserRepository, linkRepo *links.LinksRepository,
+	audienceRepo *audiences.AudienceRepository, subscriberRepo *subscribers.SubscriberRepository, botRepo *bots.BotRepository,
+	natsClient *queue.NATSClient) *TaskHandler {
+	h := &TaskHandler{repo: repo, userRepo: userRepo, linkRepo: linkRepo, audienceRepo: audienceRepo,
+		subscriberRepo: subscriberRepo, botRepo: botRepo, natsClient: natsClient}
+	h.publish = h.publishChunk
+	return h
 }
 
 func validateContent(content Content) error {
+	if err := validateButtons(content.Buttons); err != nil {
+		return err
+	}
+
 	switch content.Type {
 	case "text":
 		if content.Text == "" {
 			return fmt.Errorf("text is required for type 'text'")
 		}
-	case "photo", "video", "animation", "audio", "circle", "document":
+	case "photo", "video", "animation", "audio", "circle", "document", "sticker":
 		if content.MediaURL == "" && content.MediaID == "" {
 			return fmt.Errorf("either media_url or media_id is required for type '%s'", content.Type)
 		}
+	case "poll":
+		return validatePoll(content.Poll)
+	case "location":
+		if content.Location == nil {
+			return fmt.Errorf("location is required for type 'location'")
+		}
+		return validateCoordinates(content.Location.Latitude, content.Location.Longitude)
+	case "venue":
+		return validateVenue(content.Venue)
+	case "contact":
+		return validateContact(content.Contact)
+	case "copy", "forward":
+		if content.FromChatID == 0 || content.MessageID <= 0 {
+			return fmt.Errorf("from_chat_id and message_id are required for type '%s'", content.Type)
+		}
+		// forwardMessage не принимает reply_markup: кнопки молча потерялись бы
+		if content.Type == "forward" && len(content.Buttons) > 0 {
+			return fmt.Errorf("buttons are not supported for type 'forward'")
+		}
+	case "dice":
+		if content.Emoji != "" && !validDiceEmoji[content.Emoji] {
+			return fmt.Errorf("invalid dice emoji: %s", content.Emoji)
+		}
 	default:
 		return fmt.Errorf("invalid content type: %s", content.Type)
 	}
 	return nil
 }
 
+func validateButtons(rows [][]Button) error {
+	for i, row := range rows {
+		for j, button := range row {
+			if strings.TrimSpace(button.Text) == "" {
+				return fmt.Errorf("button [%d][%d]: text is required", i, j)
+			}
+			u, err := url.Parse(button.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "tg") {
+				return fmt.Errorf("button [%d][%d]: invalid url", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+func validateVariants(variants []Variant, testPercent int) error {
+	if len(variants) == 0 {
+		if testPercent != 0 {
+			return fmt.Errorf("test_percent requires variants")
+		}
+		return nil
+	}
+	if len(variants) < 2 || len(variants) > 5 {
+		return fmt.Errorf("from 2 to 5 variants are allowed")
+	}
+	if testPercent < 0 || testPercent > 99 {
+		return fmt.Errorf("test_percent must be between 0 and 99 (0 disables the holdout)")
+	}
+
+	names := make(map[string]bool, len(variants))
+	for i, v := range variants {
+		if strings.TrimSpace(v.Name) == "" {
+			return fmt.Errorf("variant %d: name is required", i)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("variant %d: duplicate name %q", i, v.Name)
+		}
+		names[v.Name] = true
+		if v.Weight <= 0 {
+			return fmt.Errorf("variant %q: weight must be positive", v.Name)
+		}
+		if v.Content.Type != variants[0].Content.Type {
+			return fmt.Errorf("all variants must have the same content type")
+		}
+		if err := validateContent(v.Content); err != nil {
+			return fmt.Errorf("variant %q: %w", v.Name, err)
+		}
+	}
+	return nil
+}
+
+var validDiceEmoji = map[string]bool{"🎲": true, "🎯": true, "🏀": true, "⚽": true, "🎰": true, "🎳": true}
+
+func validatePoll(poll *Poll) error {
+	if poll == nil {
+		return fmt.Errorf("poll is required for type 'poll'")
+	}
+	if strings.TrimSpace(poll.Question) == "" {
+		return fmt.Errorf("poll question is required")
+	}
+	if utf8.RuneCountInString(poll.Question) > 300 {
+		return fmt.Errorf("poll question must be at most 300 characters")
+	}
+	if len(poll.Options) < 2 || len(poll.Options) > 10 {
+		return fmt.Errorf("poll must have from 2 to 10 options")
+	}
+	for i, option := range poll.Options {
+		if strings.TrimSpace(option) == "" {
+			return fmt.Errorf("poll option %d is empty", i)
+		}
+		if utf8.RuneCountInString(option) > 100 {
+			return fmt.Errorf("poll option %d must be at most 100 characters", i)
+		}
+	}
+
+	switch poll.Type {
+	case "", "regular":
+		if poll.CorrectOption != nil {
+			return fmt.Errorf("correct_option is allowed only for quiz polls")
+		}
+		if poll.Explanation != "" {
+			return fmt.Errorf("explanation is allowed only for quiz polls")
+		}
+	case "quiz":
+		if poll.CorrectOption == nil {
+			return fmt.Errorf("correct_option is required for quiz polls")
+		}
+		if *poll.CorrectOption < 0 || *poll.CorrectOption >= len(poll.Options) {
+			return fmt.Errorf("correct_option is out of range")
+		}
+		if poll.MultipleAnswers {
+			return fmt.Errorf("quiz polls cannot allow multiple answers")
+		}
+		if utf8.RuneCountInString(poll.Explanation) > 200 {
+			return fmt.Errorf("explanation must be at most 200 characters")
+		}
+	default:
+		return fmt.Errorf("invalid poll type: %s", poll.Type)
+	}
+	return nil
+}
+
+func validateCoordinates(latitude, longitude float64) error {
+	if latitude < -90 || latitude > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90")
+	}
+	if longitude < -180 || longitude > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+func validateVenue(venue *Venue) error {
+	if venue == nil {
+		return fmt.Errorf("venue is required for type 'venue'")
+	}
+	if strings.TrimSpace(venue.Title) == "" || strings.TrimSpace(venue.Address) == "" {
+		return fmt.Errorf("venue title and address are required")
+	}
+	return validateCoordinates(venue.Latitude, venue.Longitude)
+}
+
+func validateContact(contact *Contact) error {
+	if contact == nil {
+		return fmt.Errorf("contact is required for type 'contact'")
+	}
+	if strings.TrimSpace(contact.PhoneNumber) == "" || strings.TrimSpace(contact.FirstName) == "" {
+		return fmt.Errorf("contact phone_number and first_name are required")
+	}
+	return nil
+}
+
+// botToken возвращает расшифрованный токен бота задачи: бота организации из /api/bots
+// или, если botID не задан, бота, указанного пользователем при регистрации
+func (h *TaskHandler) botToken(userID, orgID, botID uint) (string, error) {
+	if botID == 0 {
+		user, err := h.userRepo.FindByID(userID)
+		if err != nil {
+			return "", fmt.Errorf("user not found")
+		}
+		if user.Token == "" {
+			return "", fmt.Errorf("bot_id is required: account has no default bot")
+		}
+		return bots.DecryptToken(user.Token)
+	}
+
+	bot, err := h.botRepo.Get(orgID, botID)
+	if err != nil {
+		return "", err
+	}
+	return bots.DecryptToken(bot.Token)
+}
+
+// validateBot проверяет, что бот задачи принадлежит организации пользователя и включён,
+// а без bot_id — что у пользователя есть бот по умолчанию
+func (h *TaskHandler) validateBot(userID, orgID uint, req TaskRequest) error {
+	if req.BotID == 0 {
+		user, err := h.userRepo.FindByID(userID)
+		if err != nil {
+			return fmt.Errorf("user not found")
+		}
+		if user.Token == "" {
+			return fmt.Errorf("bot_id is required: account has no default bot")
+		}
+		if user.BotStatus == models.BotUnauthorized {
+			return fmt.Errorf("default bot token was rejected by Telegram; add the bot again via /bots and pass bot_id")
+		}
+		return nil
+	}
+	if orgID == 0 {
+		return fmt.Errorf("token has no organization, log in again")
+	}
+	bot, err := h.botRepo.Get(orgID, req.BotID)
+	if err != nil {
+		return err
+	}
+	if bot.Status != models.BotActive {
+		return fmt.Errorf("bot %d is %s", bot.ID, bot.Status)
+	}
+	return nil
+}
+
+// checkSourceChatAccess проверяет, что бот задачи видит чат с исходным
+// сообщением для type="copy"/"forward". Сам бот при этом не опрашивает обновления.
+func (h *TaskHandler) checkSourceChatAccess(userID, orgID, botID uint, chatID int64) error {
+	botToken, err := h.botToken(userID, orgID, botID)
+	if err != nil {
+		return err
+	}
+
+	bot, err := tele.NewBot(tele.Settings{
+		Token:   botToken,
+		Offline: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create bot: %w", err)
+	}
+
+	if _, err := bot.ChatByID(chatID); err != nil {
+		return fmt.Errorf("bot has no access to source chat %d: %w", chatID, err)
+	}
+	return nil
+}
+
 func validatePriority(priority string) error {
 	validPriorities := map[string]bool{"high": true, "medium": true, "low": true}
 	if priority != "" && !validPriorities[priority] {
@@ -79,6 +432,141 @@ func validatePriority(priority string) error {
 	return nil
 }
 
+// validateTarget проверяет получателей задачи: ровно один источник из списка recipients,
+// аудитории (с необязательным tag_expr) или подписчиков бота (с необязательным language_code)
+func (h *TaskHandler) validateTarget(userID, orgID uint, req TaskRequest) error {
+	sources := 0
+	if len(req.Recipients) > 0 {
+		sources++
+	}
+	if req.AudienceID != 0 {
+		sources++
+	}
+	if req.ToSubscribers {
+		sources++
+	}
+	if req.UploadRecipients {
+		sources++
+	}
+	switch {
+	case sources == 0:
+		return fmt.Errorf("recipients, audience_id, to_subscribers or upload_recipients is required")
+	case sources > 1:
+		return fmt.Errorf("recipients, audience_id, to_subscribers and upload_recipients are mutually exclusive")
+	case req.TagExpr != "" && req.AudienceID == 0:
+		return fmt.Errorf("tag_expr requires audience_id")
+	case req.LanguageCode != "" && !req.ToSubscribers:
+		return fmt.Errorf("language_code requires to_subscribers")
+	}
+	if len(req.Recipients) > 0 {
+		return nil
+	}
+
+	if req.TestPercent > 0 {
+		return fmt.Errorf("test_percent is supported only for recipients")
+	}
+	if req.UploadRecipients {
+		return nil
+	}
+
+	if req.ToSubscribers {
+		count, err := h.subscriberRepo.CountActive(userID, req.BotID, req.LanguageCode)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("bot has no active subscribers")
+		}
+		return nil
+	}
+
+	if _, err := h.audienceRepo.Get(userID, orgID, req.AudienceID); err != nil {
+		return err
+	}
+	count, err := h.audienceRepo.CountMembers(req.AudienceID, req.TagExpr)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("audience has no matching members")
+	}
+	return nil
+}
+
+// contentURLs собирает ссылки из текста, подписи и кнопок
+func contentURLs(content Content) []string {
+	var buttonURLs []string
+	for _, row := range content.Buttons {
+		for _, b := range row {
+			buttonURLs = append(buttonURLs, b.URL)
+		}
+	}
+	return linktrack.ExtractURLs(strings.Join(append([]string{content.Text, content.Caption}, buttonURLs...), " "))
+}
+
+// trackLinks заводит отслеживаемые ссылки для контента задачи (и каждого варианта A/B-теста)
+func trackLinks(linkRepo *links.LinksRepository, taskID string, req *TaskRequest) error {
+	if len(req.Variants) == 0 {
+		tracked, err := linkRepo.CreateLinks(taskID, "", contentURLs(req.Content))
+		if err != nil {
+			return err
+		}
+		req.Content.TrackedLinks = tracked
+		return nil
+	}
+
+	for i := range req.Variants {
+		v := &req.Variants[i]
+		tracked, err := linkRepo.CreateLinks(taskID, v.Name, contentURLs(v.Content))
+		if err != nil {
+			return err
+		}
+		v.Content.TrackedLinks = tracked
+	}
+	req.Content = req.Variants[0].Content
+	return nil
+}
+
+// encodeTaskContent сериализует контент и варианты A/B-теста запроса в колонки задачи
+func encodeTaskContent(task *models.Task, req TaskRequest) error {
+	contentJSON, err := json.Marshal(req.Content)
+	if err != nil {
+		return fmt.Errorf("serialize content: %w", err)
+	}
+	variantsJSON, err := marshalOptionalJSON(req.Variants, len(req.Variants) > 0)
+	if err != nil {
+		return fmt.Errorf("serialize variants: %w", err)
+	}
+	task.Content = string(contentJSON)
+	task.Variants = variantsJSON
+	return nil
+}
+
+// splitTestSlice делит получателей на тестовую выборку и отложенных (holdout)
+func splitTestSlice(taskID string, recipients []int64, percent int) (test, holdout []int64) {
+	for _, r := range recipients {
+		if abtest.InTestSlice(taskID, r, percent) {
+			test = append(test, r)
+		} else {
+			holdout = append(holdout, r)
+		}
+	}
+	return test, holdout
+}
+
+// marshalOptionalJSON сериализует v для jsonb-колонки, либо возвращает nil, если present=false
+func marshalOptionalJSON(v interface{}, present bool) (*string, error) {
+	if !present {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	str := string(b)
+	return &str, nil
+}
+
 func parseSchedule(schedule string) (*time.Time, error) {
 	if schedule == "" {
 		return nil, nil
@@ -125,7 +613,7 @@ func parseSchedule(schedule string) (*time.Time, error) {
 //	  "success": true,
 //	  "data": {
 //	    "task_id": "a804bd98-8e4d-4e8d-9678-7e28b7a8408f",
-//	    "status": "scheduled"
+//	    "status": "queued"
 //	  }
 //	}
 //
@@ -140,29 +628,65 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		return
 	}
 
-	// Извлечение user_id из контекста
-	claims, exists := c.Get("claims")
-	if !exists {
-		logger.Log.Error("Ошибка авторизации: claims отсутствуют в контексте")
+	// Извлечение user_id и организации из контекста
+	claims, ok := currentClaims(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
 		return
 	}
+	userID, orgID := claims.UserID, claims.OrgID
 
-	userClaims, ok := claims.(*middleware.Claims)
-	if !ok {
-		logger.Log.Error("Ошибка авторизации: claims неверного формата")
-		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+	// Проверяем тип контента (или варианты A/B-теста)
+	contents := []Content{req.Content}
+	if len(req.Variants) > 0 {
+		if req.Content.Type != "" {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse("content and variants are mutually exclusive"))
+			return
+		}
+		contents = contents[:0]
+		for _, v := range req.Variants {
+			contents = append(contents, v.Content)
+		}
+		// Тип задачи и основной контент — по первому варианту
+		req.Content = req.Variants[0].Content
+	}
+	if err := validateVariants(req.Variants, req.TestPercent); err != nil {
+		logger.Log.Error("Ошибка валидации вариантов", zap.Error(err))
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
 		return
 	}
-	userID := userClaims.UserID
-
-	// Проверяем тип контента
 	if err := validateContent(req.Content); err != nil {
 		logger.Log.Error("Ошибка валидации контента", zap.Error(err))
 		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
 		return
 	}
 
+	// Проверяем бота рассылки
+	if err := h.validateBot(userID, orgID, req); err != nil {
+		logger.Log.Error("Ошибка валидации бота", zap.Error(err))
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+		return
+	}
+
+	// Для copy/forward убеждаемся, что бот видит исходный чат
+	for _, content := range contents {
+		if content.Type != "copy" && content.Type != "forward" {
+			continue
+		}
+		if err := h.checkSourceChatAccess(userID, orgID, req.BotID, content.FromChatID); err != nil {
+			logger.Log.Error("Ошибка проверки доступа к исходному чату", zap.Error(err))
+			c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+			return
+		}
+	}
+
+	// Проверяем получателей
+	if err := h.validateTarget(userID, orgID, req); err != nil {
+		logger.Log.Error("Ошибка валидации получателей", zap.Error(err))
+		c.JSON(http.StatusBadRequest, response.ErrorResponse(err.Error()))
+		return
+	}
+
 	// Проверяем Priority
 	if err := validatePriority(req.Priority); err != nil {
 		logger.Log.Error("Ошибка валидации приоритета", zap.Error(err))
@@ -181,51 +705,126 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		return
 	}
 
-	// Сериализуем Content в JSON (для БД)
-	contentJSON, err := json.Marshal(req.Content)
+	// Тестовая выборка: сейчас отправляем только её, остальные ждут победителя
+	recipients := req.Recipients
+	var holdout []int64
+	if req.TestPercent > 0 {
+		recipients, holdout = splitTestSlice(taskID, req.Recipients, req.TestPercent)
+		if len(recipients) == 0 {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse("Test slice is empty, increase test_percent"))
+			return
+		}
+	}
+
+	// Отслеживание кликов возможно, только если оно настроено в конфигурации
+	if req.TrackClicks && !linktrack.Enabled() {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("click tracking is not configured"))
+		return
+	}
+	// Пересланное сообщение нельзя изменить, поэтому ссылки в нём не заменить
+	if req.TrackClicks && req.Content.Type == "forward" {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse("track_clicks is not supported for type 'forward'"))
+		return
+	}
+
+	holdoutJSON, err := marshalOptionalJSON(holdout, len(holdout) > 0)
 	if err != nil {
-		logger.Log.Error("Ошибка сериализации контента", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to serialize content"))
+		logger.Log.Error("Ошибка сериализации отложенных получателей", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to serialize holdout"))
 		return
 	}
 
+	var audienceID *uint
+	if req.AudienceID != 0 {
+		audienceID = &req.AudienceID
+	}
+
+	var botID, taskOrgID *uint
+	if req.BotID != 0 {
+		botID = &req.BotID
+	}
+	if orgID != 0 {
+		taskOrgID = &orgID
+	}
+
+	// Задача с загрузкой получателей ждёт их и в NATS пока не публикуется
+	status := models.TaskScheduled
+	if req.UploadRecipients {
+		status = models.TaskDraft
+	}
+
 	// Создаём модель задачи
 	task := &models.Task{
-		ID:          taskID,
-		UserID:      userID,
-		MessageType: req.Content.Type,
-		Content:     string(contentJSON),
-		Priority:    req.Priority,
-		Schedule:    schedule,
-		Status:      "scheduled",
+		ID:            taskID,
+		UserID:        userID,
+		OrgID:         taskOrgID,
+		BotID:         botID,
+		Action:        "send",
+		MessageType:   req.Content.Type,
+		Holdout:       holdoutJSON,
+		AudienceID:    audienceID,
+		TagExpr:       req.TagExpr,
+		ToSubscribers: req.ToSubscribers,
+		LanguageCode:  req.LanguageCode,
+		Priority:      req.Priority,
+		Schedule:      schedule,
+		Status:        status,
+	}
+
+	// Отслеживаемые ссылки заводятся в транзакции сохранения задачи: их ID попадают
+	// в контент, а при ошибке сохранения ссылки не остаются без задачи
+	var prepare func(tx *gorm.DB) error
+	if req.TrackClicks {
+		prepare = func(tx *gorm.DB) error {
+			if err := trackLinks(h.linkRepo.WithTx(tx), taskID, &req); err != nil {
+				return fmt.Errorf("create tracked links: %w", err)
+			}
+			return encodeTaskContent(task, req)
+		}
+	} else if err := encodeTaskContent(task, req); err != nil {
+		logger.Log.Error("Ошибка сериализации контента", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to serialize content"))
+		return
 	}
 
 	// Сохраняем в БД
-	if err := h.repo.SaveTask(task); err != nil {
+	actor := tasks.UserActor(userID)
+	if err := h.repo.SaveTaskWith(task, actor, prepare); err != nil {
 		logger.Log.Error("Ошибка сохранения задачи в БД", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to save task"))
 		return
 	}
 
+	if req.UploadRecipients {
+		logger.Log.Info("Задача ожидает загрузки получателей", zap.String("task_id", taskID))
+		c.JSON(http.StatusCreated, response.SuccessResponse(map[string]interface{}{
+			"task_id":    taskID,
+			"status":     status,
+			"upload_url": fmt.Sprintf("/api/tasks/%s/recipients", taskID),
+		}))
+		return
+	}
+
 	// Формируем сообщение для NATS
 	msg := TaskNATSMessage{
-		TaskID:     taskID,
-		UserID:     userID,
-		Recipients: req.Recipients,
-		Content:    req.Content,
-		Priority:   req.Priority,
-	}
-	payload, err := json.Marshal(msg)
-	if err != nil {
-		logger.Log.Error("Ошибка сериализации сообщения для NATS", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to marshal NATS message"))
-		return
+		TaskID:        taskID,
+		UserID:        userID,
+		BotID:         req.BotID,
+		Action:        "send",
+		Recipients:    recipients,
+		AudienceID:    req.AudienceID,
+		TagExpr:       req.TagExpr,
+		ToSubscribers: req.ToSubscribers,
+		LanguageCode:  req.LanguageCode,
+		Content:       req.Content,
+		Variants:      req.Variants,
+		Priority:      req.Priority,
 	}
 
-	// Публикуем в NATS
-	if err := h.natsClient.Conn.Publish("tasks.create", payload); err != nil {
-		logger.Log.Error("Ошибка публикации в NATS", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to publish to NATS"))
+	// Публикуем в NATS (большие списки получателей — несколькими частями)
+	if err := h.dispatchTask(msg, actor); err != nil {
+		logger.Log.Error("Ошибка публикации в NATS", zap.String("task_id", taskID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to publish to NATS: "+err.Error()))
 		return
 	}
 
@@ -241,13 +840,13 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	// Возвращаем результат
 	c.JSON(http.StatusCreated, response.SuccessResponse(map[string]interface{}{
 		"task_id": taskID,
-		"status":  "scheduled",
+		"status":  models.TaskQueued,
 	}))
 }
 
 // GetTask Возвращает задачу по ID
 // @Summary Получить задачу
-// @Description Возвращает детали задачи по её ID
+// @Description Возвращает детали задачи по её ID вместе со статистикой доставки (объект stats)
 // @securityDefinitions.apikey BearerAuth
 // @Tags Tasks
 // @Security BearerAuth
@@ -272,17 +871,79 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 //	    "content": "{\"type\":\"text\",\"text\":\"Привет! Это тестовое сообщение.\"}",
 //	    "priority": "high",
 //	    "schedule": "2025-01-05T10:00:00Z",
-//	    "status": "scheduled",
+//	    "status": "partially_failed",
 //	    "created_at": "2025-01-04T21:37:39Z",
-//	    "updated_at": "2025-01-04T21:37:39Z"
+//	    "updated_at": "2025-01-05T10:00:42Z",
+//	    "stats": {
+//	      "expected_count": 1000,
+//	      "processed_count": 1000,
+//	      "total_sent": 990,
+//	      "total_failed": 10,
+//	      "total_skipped": 3,
+//	      "total_retried": 25,
+//	      "by_content_type": {"text": 990},
+//	      "error_counts": {"NOT_FOUND": 7, "FLOOD_WAIT": 3},
+//	      "start_time": "2025-01-05T10:00:00Z",
+//	      "end_time": "2025-01-05T10:00:40Z",
+//	      "time_spent": 40,
+//	      "throughput": 24.75
+//	    }
 //	  }
 //	}
 func (h *TaskHandler) GetTask(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
 	taskID := c.Param("id")
 	task, err := h.repo.GetTaskByID(taskID)
-	if err != nil {
+	if err != nil || !canAccessTask(claims, task) {
 		c.JSON(http.StatusNotFound, response.ErrorResponse("Task not found"))
 		return
 	}
+
+	// Клики по отслеживаемым ссылкам (у победителя A/B-теста они учитываются в родителе)
+	clicks, err := h.linkRepo.ClickStats(taskID)
+	if err != nil {
+		logger.Log.Error("Ошибка получения статистики кликов", zap.String("task_id", taskID), zap.Error(err))
+	}
+	task.Clicks = clicks
+
 	c.JSON(http.StatusOK, response.SuccessResponse(task))
 }
+
+// GetTaskHistory Возвращает историю статусов задачи
+// @Summary История статусов задачи
+// @Description Смены статуса задачи по времени: из какого в какой, кто изменил (user:<id>, worker или system) и почему
+// @Tags Tasks
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Success 200 {object} response.APIResponse{data=[]models.TaskStatusHistory} "История статусов"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 404 {object} response.APIResponse "Задача не найдена"
+// @Failure 500 {object} response.APIResponse "Внутренняя ошибка сервера"
+// @Router /tasks/{id}/history [get]
+func (h *TaskHandler) GetTaskHistory(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	task, err := h.repo.GetTaskByID(c.Param("id"))
+	if err != nil || !canAccessTask(claims, task) {
+		c.JSON(http.StatusNotFound, response.ErrorResponse("Task not found"))
+		return
+	}
+
+	history, err := h.repo.StatusHistory(task.ID)
+	if err != nil {
+		logger.Log.Error("Ошибка получения истории статусов", zap.String("task_id", task.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to load task history"))
+		return
+	}
+	c.JSON(http.StatusOK, response.SuccessResponse(history))
+}
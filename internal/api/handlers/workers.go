@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"GoBlast/internal/bots"
+	"GoBlast/internal/users"
+	"GoBlast/internal/worker"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/queue"
+	"GoBlast/pkg/response"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// statusGatherTimeout — сколько собирать ответы экземпляров воркера
+const statusGatherTimeout = time.Second
+
+// WorkersStatusResponse — ответ GET /admin/workers
+type WorkersStatusResponse struct {
+	Instances     []worker.ManagerStatus `json:"instances"`      // в Workers только боты организации
+	ActiveWorkers int                    `json:"active_workers"` // всего по экземплярам, включая чужие организации
+	ActiveBots    int                    `json:"active_bots"`    // ботов организации с запущенным воркером
+	QueueDepth    int64                  `json:"queue_depth"`
+	InFlightTasks int                    `json:"in_flight_tasks"`
+}
+
+// WorkersHandler показывает состояние воркеров администратору организации
+type WorkersHandler struct {
+	natsClient *queue.NATSClient
+	botRepo    *bots.BotRepository
+	userRepo   *users.AuthUserRepository
+}
+
+func NewWorkersHandler(natsClient *queue.NATSClient, botRepo *bots.BotRepository, userRepo *users.AuthUserRepository) *WorkersHandler {
+	return &WorkersHandler{natsClient: natsClient, botRepo: botRepo, userRepo: userRepo}
+}
+
+// GetWorkersStatus Возвращает состояние воркеров ботов
+// @Summary Состояние воркеров
+// @Description Опрашивает все экземпляры воркера через NATS. Показываются только боты организации; общее число воркеров — по всем экземплярам.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.APIResponse{data=WorkersStatusResponse} "Состояние воркеров"
+// @Failure 401 {object} response.APIResponse "Неавторизованный доступ"
+// @Failure 403 {object} response.APIResponse "Нужна роль admin"
+// @Failure 502 {object} response.APIResponse "NATS недоступен"
+// @Router /admin/workers [get]
+func (h *WorkersHandler) GetWorkersStatus(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+		return
+	}
+
+	ownBots, ownUsers, err := h.orgBots(orgID)
+	if err != nil {
+		logger.Log.Error("Ошибка получения ботов организации", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to load organization bots"))
+		return
+	}
+
+	instances, err := h.gather()
+	if err != nil {
+		logger.Log.Error("Ошибка запроса состояния воркеров", zap.Error(err))
+		c.JSON(http.StatusBadGateway, response.ErrorResponse("Failed to query workers"))
+		return
+	}
+
+	result := WorkersStatusResponse{Instances: make([]worker.ManagerStatus, 0, len(instances))}
+	for _, inst := range instances {
+		result.ActiveWorkers += inst.ActiveWorkers
+
+		own := make([]worker.WorkerStatus, 0, len(inst.Workers))
+		for _, ws := range inst.Workers {
+			if (ws.BotID != 0 && ownBots[ws.BotID]) || (ws.BotID == 0 && ownUsers[ws.UserID]) {
+				own = append(own, ws)
+				result.ActiveBots++
+				result.QueueDepth += ws.QueueDepth
+				result.InFlightTasks += ws.InFlightTasks
+			}
+		}
+		inst.Workers = own
+		result.Instances = append(result.Instances, inst)
+	}
+
+	c.JSON(http.StatusOK, response.SuccessResponse(result))
+}
+
+// orgBots возвращает ID ботов организации и ID её участников (их боты по умолчанию)
+func (h *WorkersHandler) orgBots(orgID uint) (map[uint]bool, map[uint]bool, error) {
+	botList, err := h.botRepo.List(orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := h.userRepo.ListByOrganization(orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ownBots := make(map[uint]bool, len(botList))
+	for _, bot := range botList {
+		ownBots[bot.ID] = true
+	}
+	ownUsers := make(map[uint]bool, len(members))
+	for _, user := range members {
+		ownUsers[user.ID] = true
+	}
+	return ownBots, ownUsers, nil
+}
+
+// gather рассылает запрос состояния и собирает ответы всех экземпляров за statusGatherTimeout
+func (h *WorkersHandler) gather() ([]worker.ManagerStatus, error) {
+	inbox := nats.NewInbox()
+	sub, err := h.natsClient.Conn.SubscribeSync(inbox)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	if err := h.natsClient.Conn.PublishRequest(worker.SubjectStatus, inbox, nil); err != nil {
+		return nil, err
+	}
+
+	var instances []worker.ManagerStatus
+	deadline := time.Now().Add(statusGatherTimeout)
+	for {
+		msg, err := sub.NextMsg(time.Until(deadline))
+		if errors.Is(err, nats.ErrTimeout) {
+			return instances, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var inst worker.ManagerStatus
+		if err := json.Unmarshal(msg.Data, &inst); err != nil {
+			logger.Log.Warn("Некорректный ответ о состоянии воркера", zap.Error(err))
+			continue
+		}
+		instances = append(instances, inst)
+	}
+}
@@ -4,7 +4,11 @@ package middleware
 
 import (
 	"GoBlast/configs"
+	"GoBlast/internal/apikeys"
+	"GoBlast/pkg/logger"
 	"GoBlast/pkg/response"
+	"GoBlast/pkg/storage/models"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -12,13 +16,31 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 var (
 	JWTSecret     string
 	EncryptionKey string
+
+	// Revocations проверяет отозванные access-токены (если задан)
+	Revocations RevocationStore
 )
 
+// AccessTokenTTL — срок жизни access-токена; дальше сессия продлевается refresh-токеном
+const AccessTokenTTL = 15 * time.Minute
+
+// RevocationStore хранит отозванные токены (реализуется sessions.SessionRepository)
+type RevocationStore interface {
+	IsRevoked(jti string, userID uint, issuedAt int64) (bool, error)
+}
+
+// SetRevocationStore подключает проверку отзыва токенов в JWTMiddleware
+func SetRevocationStore(store RevocationStore) {
+	Revocations = store
+}
+
 // Initialize инициализирует ключи из конфигурации
 func Initialize(cfg *configs.Config) {
 	JWTSecret = cfg.App.JWTSecret
@@ -32,57 +54,173 @@ func Initialize(cfg *configs.Config) {
 
 // Claims представляет структуру JWT-токена
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID uint   `json:"user_id"`
+	OrgID  uint   `json:"org_id"`
+	Role   string `json:"role"` // admin, editor, viewer
 	jwt.StandardClaims
 }
 
 func JWTMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Получаем заголовок Authorization
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, response.ErrorResponse("Authorization header required"))
-			c.Abort()
+		claims, ok := bearerClaims(c)
+		if !ok {
 			return
 		}
 
-		// Извлекаем токен из заголовка
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		// Устанавливаем claims в контекст
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// bearerClaims разбирает JWT из заголовка Authorization.
+// При ошибке сам отвечает 401 и прерывает обработку.
+func bearerClaims(c *gin.Context) (*Claims, bool) {
+	// Получаем заголовок Authorization
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Authorization header required"))
+		c.Abort()
+		return nil, false
+	}
+
+	// Извлекаем токен из заголовка
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+	claims, err := ValidateToken(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse("Invalid token"))
+		c.Abort()
+		return nil, false
+	}
+
+	// Отозванные токены (logout, "выйти везде") не принимаются до истечения срока
+	if Revocations != nil {
+		revoked, err := Revocations.IsRevoked(claims.Id, claims.UserID, claims.IssuedAt)
+		if err != nil {
+			logger.Log.Error("Ошибка проверки отзыва токена", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse("Failed to validate token"))
+			c.Abort()
+			return nil, false
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, response.ErrorResponse("Token revoked"))
+			c.Abort()
+			return nil, false
+		}
+	}
+	return claims, true
+}
+
+// APIKeyStore проверяет API-ключи (реализуется apikeys.APIKeyRepository)
+type APIKeyStore interface {
+	Authenticate(raw string) (*models.APIKey, string, error)
+}
+
+// APIKeyOrJWTMiddleware принимает API-ключ (заголовок X-API-Key или "Bearer gb_...")
+// или, если ключа нет, обычный JWT. Для ключа в контекст кладутся claims владельца
+// и сам ключ ("api_key"), по которому RequireScope проверяет scopes.
+func APIKeyOrJWTMiddleware(store APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.GetHeader("X-API-Key")
+		if raw == "" {
+			if bearer := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "); apikeys.IsKey(bearer) {
+				raw = bearer
+			}
+		}
 
-		// Парсим токен
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// Проверяем метод подписи
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
+		if raw == "" {
+			claims, ok := bearerClaims(c)
+			if !ok {
+				return
 			}
-			return []byte(JWTSecret), nil
-		})
+			c.Set("claims", claims)
+			c.Next()
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, response.ErrorResponse("Invalid token"))
+		key, role, err := store.Authenticate(raw)
+		if err != nil {
+			if !errors.Is(err, apikeys.ErrInvalidKey) {
+				logger.Log.Error("Ошибка проверки API-ключа", zap.Error(err))
+			}
+			c.JSON(http.StatusUnauthorized, response.ErrorResponse("Invalid API key"))
 			c.Abort()
 			return
 		}
 
-		// Устанавливаем claims в контекст
-		c.Set("claims", claims)
+		c.Set("claims", &Claims{UserID: key.UserID, OrgID: key.OrgID, Role: role})
+		c.Set("api_key", key)
 		c.Next()
 	}
 }
 
-// GenerateToken генерирует JWT-токен для заданного userID
-func GenerateToken(userID uint) (string, error) {
+// RequireScope требует scope у API-ключа; запросы с JWT проходят без проверки
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value, ok := c.Get("api_key"); ok {
+			key := value.(*models.APIKey)
+			if !apikeys.HasScope(key, scope) {
+				c.JSON(http.StatusForbidden, response.ErrorResponse("API key lacks scope "+scope))
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// GenerateToken генерирует access-токен для пользователя организации orgID с ролью role.
+// Возвращает токен и его jti, по которому токен можно отозвать.
+func GenerateToken(userID, orgID uint, role string) (string, string, error) {
+	jti := uuid.New().String()
 	claims := &Claims{
 		UserID: userID,
+		OrgID:  orgID,
+		Role:   role,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(), // Токен истекает через 24 часа
+			Id:        jti,
+			ExpiresAt: time.Now().Add(AccessTokenTTL).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(JWTSecret))
+	signed, err := token.SignedString([]byte(JWTSecret))
+	return signed, jti, err
+}
+
+// RequireRole пропускает запрос, только если роль из JWT не ниже role.
+// Должен стоять после JWTMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := c.Get("claims")
+		userClaims, _ := claims.(*Claims)
+		if !ok || userClaims == nil {
+			c.JSON(http.StatusUnauthorized, response.ErrorResponse("Unauthorized"))
+			c.Abort()
+			return
+		}
+		if !models.RoleAtLeast(userClaims.Role, role) {
+			c.JSON(http.StatusForbidden, response.ErrorResponse("Insufficient role: "+role+" required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RoleByMethod требует роль viewer для чтения и editor для изменяющих запросов
+func RoleByMethod() gin.HandlerFunc {
+	viewer, editor := RequireRole(models.RoleViewer), RequireRole(models.RoleEditor)
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead:
+			viewer(c)
+		default:
+			editor(c)
+		}
+	}
 }
 
 // ValidateToken валидирует JWT-токен и возвращает claims
@@ -1,9 +1,17 @@
 package api
 
 import (
+	"GoBlast/configs"
 	handlers2 "GoBlast/internal/api/handlers"
 	middleware2 "GoBlast/internal/api/middleware"
+	"GoBlast/internal/apikeys"
+	"GoBlast/internal/audiences"
+	"GoBlast/internal/bots"
+	"GoBlast/internal/links"
+	"GoBlast/internal/reports"
 	"GoBlast/internal/routes"
+	"GoBlast/internal/sessions"
+	"GoBlast/internal/subscribers"
 	"GoBlast/internal/tasks"
 	"GoBlast/internal/users"
 	"GoBlast/pkg/metrics"
@@ -16,7 +24,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func SetupRouter(database *gorm.DB, natsClient *queue.NATSClient) *gin.Engine {
+func SetupRouter(database *gorm.DB, natsClient *queue.NATSClient, appCfg configs.AppConfig) *gin.Engine {
 	metrics.InitMetrics()
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
@@ -40,20 +48,63 @@ func SetupRouter(database *gorm.DB, natsClient *queue.NATSClient) *gin.Engine {
 	// Repositories
 	authRepo := users.NewAuthUserRepository(database)
 	taskRepo := tasks.NewTasksRepository(database)
+	linkRepo := links.NewLinksRepository(database)
+	audienceRepo := audiences.NewAudienceRepository(database)
+	subscriberRepo := subscribers.NewSubscriberRepository(database)
+	botRepo := bots.NewBotRepository(database)
+	apiKeyRepo := apikeys.NewAPIKeyRepository(database)
+	sessionRepo := sessions.NewSessionRepository(database)
+	reportRepo := reports.NewReportsRepository(database)
+
+	// Отозванные access-токены отклоняются в JWTMiddleware
+	middleware2.SetRevocationStore(sessionRepo)
 
 	// Handlers
-	authHandler := handlers2.NewAuthHandler(authRepo)
-	taskHandler := handlers2.NewTaskHandler(taskRepo, natsClient)
+	authHandler := handlers2.NewAuthHandler(authRepo, sessionRepo, botRepo, appCfg.LegacyTokenLogin)
+	taskHandler := handlers2.NewTaskHandler(taskRepo, authRepo, linkRepo, audienceRepo, subscriberRepo, botRepo, natsClient)
+	audienceHandler := handlers2.NewAudienceHandler(audienceRepo)
+	linkHandler := handlers2.NewLinkHandler(linkRepo)
+	botHandler := handlers2.NewBotHandler(botRepo, authRepo, natsClient)
+	orgHandler := handlers2.NewOrgHandler(authRepo, sessionRepo)
+	apiKeyHandler := handlers2.NewAPIKeyHandler(apiKeyRepo)
+	subscriberHandler := handlers2.NewSubscriberHandler(subscriberRepo, authRepo, botRepo, natsClient, appCfg.PublicURL)
+	workersHandler := handlers2.NewWorkersHandler(natsClient, botRepo, authRepo)
+	reportHandler := handlers2.NewReportHandler(reportRepo)
+
+	// Редиректы отслеживаемых ссылок и вебхуки Telegram (публичные)
+	routes.SetupLinkRoutes(router.Group(""), linkHandler)
+	routes.SetupWebhookRoutes(router.Group(""), subscriberHandler)
 
 	api := router.Group("/api")
 	{
 		routes.SetupAuthRoutes(api, authHandler)
 	}
 
+	// Собственный аккаунт: выход, пароль и TOTP доступны любой роли
+	account := router.Group("/api")
+	account.Use(middleware2.JWTMiddleware())
+	{
+		routes.SetupAccountRoutes(account, authHandler)
+	}
+
+	// Задачи доступны и сервисам по API-ключам (со scopes tasks:read/tasks:write)
+	taskAPI := router.Group("/api")
+	taskAPI.Use(middleware2.APIKeyOrJWTMiddleware(apiKeyRepo), middleware2.RoleByMethod())
+	{
+		routes.SetupTaskRoutes(taskAPI, taskHandler)
+		routes.SetupReportRoutes(taskAPI, reportHandler)
+	}
+
+	// Чтение — роль viewer, изменения — editor (отдельные маршруты требуют admin)
 	protected := router.Group("/api")
-	protected.Use(middleware2.JWTMiddleware())
+	protected.Use(middleware2.JWTMiddleware(), middleware2.RoleByMethod())
 	{
-		routes.SetupTaskRoutes(protected, taskHandler)
+		routes.SetupAudienceRoutes(protected, audienceHandler)
+		routes.SetupSubscriberRoutes(protected, subscriberHandler)
+		routes.SetupBotRoutes(protected, botHandler)
+		routes.SetupOrgRoutes(protected, orgHandler)
+		routes.SetupAPIKeyRoutes(protected, apiKeyHandler)
+		routes.SetupAdminRoutes(protected, workersHandler)
 	}
 
 	return router
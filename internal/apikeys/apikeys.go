@@ -0,0 +1,146 @@
+package apikeys
+
+import (
+	"GoBlast/pkg/storage/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// keyPrefix отличает ключи GoBlast от JWT и чужих секретов
+const keyPrefix = "gb_"
+
+// touchInterval — как часто обновляется last_used_at, чтобы не писать в БД на каждый запрос
+const touchInterval = time.Minute
+
+var (
+	// ErrNotFound — ключ не найден или принадлежит другой организации
+	ErrNotFound = errors.New("api key not found")
+	// ErrInvalidKey — ключ неверен, отозван или истёк
+	ErrInvalidKey = errors.New("invalid api key")
+)
+
+// IsKey сообщает, похожа ли строка на API-ключ GoBlast
+func IsKey(raw string) bool {
+	return strings.HasPrefix(raw, keyPrefix)
+}
+
+// Generate создаёт новый ключ вида gb_<prefix>_<secret> и возвращает его вместе с префиксом и хешем
+func Generate() (raw, prefix, hash string, err error) {
+	buf := make([]byte, 6+32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(buf[:6])
+	raw = keyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(buf[6:])
+	return raw, prefix, Hash(raw), nil
+}
+
+// Hash возвращает SHA-256 ключа: у ключа 256 бит случайности, медленный хеш не нужен
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParsePrefix извлекает префикс из ключа
+func ParsePrefix(raw string) (string, bool) {
+	rest, ok := strings.CutPrefix(raw, keyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 12 || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// HasScope сообщает, выдан ли ключу scope
+func HasScope(key *models.APIKey, scope string) bool {
+	for _, s := range strings.Fields(key.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// List возвращает ключи организации
+func (r *APIKeyRepository) List(orgID uint) ([]models.APIKey, error) {
+	var list []models.APIKey
+	err := r.db.Where("org_id = ?", orgID).Order("id").Find(&list).Error
+	return list, err
+}
+
+// Revoke отзывает ключ организации
+func (r *APIKeyRepository) Revoke(orgID, id uint) error {
+	res := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND org_id = ? AND revoked_at IS NULL", id, orgID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Authenticate проверяет ключ и возвращает его вместе с текущей ролью владельца.
+// Попутно обновляет last_used_at (не чаще раза в touchInterval).
+func (r *APIKeyRepository) Authenticate(raw string) (*models.APIKey, string, error) {
+	prefix, ok := ParsePrefix(raw)
+	if !ok {
+		return nil, "", ErrInvalidKey
+	}
+
+	var key models.APIKey
+	if err := r.db.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrInvalidKey
+		}
+		return nil, "", err
+	}
+	if subtle.ConstantTimeCompare([]byte(Hash(raw)), []byte(key.Hash)) != 1 {
+		return nil, "", ErrInvalidKey
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, "", ErrInvalidKey
+	}
+
+	// Роль берётся у владельца на момент запроса: понижение роли сразу ограничивает ключ
+	var owner models.AuthUser
+	if err := r.db.Select("id", "org_id", "role").First(&owner, key.UserID).Error; err != nil {
+		return nil, "", ErrInvalidKey
+	}
+	if owner.OrgID == nil || *owner.OrgID != key.OrgID {
+		return nil, "", ErrInvalidKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > touchInterval {
+		if err := r.db.Model(&key).Update("last_used_at", now).Error; err != nil {
+			return nil, "", err
+		}
+	}
+	return &key, owner.Role, nil
+}
@@ -0,0 +1,45 @@
+package apikeys
+
+import (
+	"GoBlast/pkg/storage/models"
+	"testing"
+)
+
+func TestGenerateAndParse(t *testing.T) {
+	raw, prefix, hash, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if !IsKey(raw) {
+		t.Fatalf("generated key %q is not recognised", raw)
+	}
+
+	parsed, ok := ParsePrefix(raw)
+	if !ok || parsed != prefix {
+		t.Fatalf("ParsePrefix(%q) = %q, %v; want %q", raw, parsed, ok, prefix)
+	}
+	if Hash(raw) != hash {
+		t.Fatal("hash of the same key must match")
+	}
+
+	other, _, _, _ := Generate()
+	if other == raw || Hash(other) == hash {
+		t.Fatal("keys must be unique")
+	}
+
+	for _, bad := range []string{"", "gb_", "gb_short_x", "eyJhbGciOi.jwt", "gb_0123456789ab_"} {
+		if _, ok := ParsePrefix(bad); ok {
+			t.Fatalf("ParsePrefix(%q) must fail", bad)
+		}
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	key := &models.APIKey{Scopes: "tasks:read tasks:write"}
+	if !HasScope(key, models.ScopeTasksWrite) {
+		t.Fatal("tasks:write must be granted")
+	}
+	if HasScope(&models.APIKey{Scopes: "tasks:read"}, models.ScopeTasksWrite) {
+		t.Fatal("tasks:write must not be granted")
+	}
+}
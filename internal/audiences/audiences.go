@@ -0,0 +1,202 @@
+package audiences
+
+import (
+	"GoBlast/pkg/storage/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotFound — аудитория не найдена или недоступна пользователю
+var ErrNotFound = errors.New("audience not found")
+
+type AudienceRepository struct {
+	db *gorm.DB
+}
+
+func NewAudienceRepository(db *gorm.DB) *AudienceRepository {
+	return &AudienceRepository{db: db}
+}
+
+func (r *AudienceRepository) Create(audience *models.Audience) error {
+	return r.db.Create(audience).Error
+}
+
+// owned ограничивает запрос аудиториями пользователя и его организации (orgID 0 — только своими)
+func owned(query *gorm.DB, userID, orgID uint) *gorm.DB {
+	if orgID != 0 {
+		return query.Where("(audiences.user_id = ? OR audiences.org_id = ?)", userID, orgID)
+	}
+	return query.Where("audiences.user_id = ?", userID)
+}
+
+// Get возвращает аудиторию пользователя или его организации вместе с числом участников
+func (r *AudienceRepository) Get(userID, orgID, id uint) (*models.Audience, error) {
+	var audience models.Audience
+	if err := owned(r.db.Where("id = ?", id), userID, orgID).First(&audience).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err := r.db.Model(&models.AudienceMember{}).
+		Where("audience_id = ?", id).
+		Count(&audience.MemberCount).Error; err != nil {
+		return nil, err
+	}
+	return &audience, nil
+}
+
+// List возвращает аудитории пользователя и его организации вместе с числом участников
+func (r *AudienceRepository) List(userID, orgID uint) ([]models.Audience, error) {
+	var list []models.Audience
+	if err := owned(r.db, userID, orgID).Order("id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		AudienceID uint
+		Count      int64
+	}
+	if err := owned(r.db.Model(&models.AudienceMember{}).
+		Select("audience_id, COUNT(*) AS count").
+		Joins("JOIN audiences ON audiences.id = audience_members.audience_id"), userID, orgID).
+		Group("audience_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		byID[c.AudienceID] = c.Count
+	}
+	for i := range list {
+		list[i].MemberCount = byID[list[i].ID]
+	}
+	return list, nil
+}
+
+// Delete удаляет аудиторию пользователя или его организации вместе с участниками
+func (r *AudienceRepository) Delete(userID, orgID, id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := owned(tx.Where("id = ?", id), userID, orgID).Delete(&models.Audience{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Where("audience_id = ?", id).Delete(&models.AudienceMember{}).Error
+	})
+}
+
+// AddMembers добавляет участников пачкой. Для уже существующих объединяет теги.
+func (r *AudienceRepository) AddMembers(audienceID uint, chatIDs []int64, tags []string) error {
+	if len(chatIDs) == 0 {
+		return nil
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return fmt.Errorf("marshal tags: %w", err)
+	}
+
+	members := make([]models.AudienceMember, 0, len(chatIDs))
+	seen := make(map[int64]bool, len(chatIDs))
+	for _, chatID := range chatIDs {
+		if seen[chatID] {
+			continue
+		}
+		seen[chatID] = true
+		members = append(members, models.AudienceMember{
+			AudienceID: audienceID,
+			ChatID:     chatID,
+			Tags:       string(tagsJSON),
+		})
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "audience_id"}, {Name: "chat_id"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "tags"}, Value: gorm.Expr(
+				"(SELECT COALESCE(jsonb_agg(DISTINCT t), '[]'::jsonb) FROM jsonb_array_elements_text(audience_members.tags || excluded.tags) AS t)")},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+		},
+	}).CreateInBatches(members, 1000).Error
+}
+
+// RemoveMembers удаляет участников и возвращает количество удалённых
+func (r *AudienceRepository) RemoveMembers(audienceID uint, chatIDs []int64) (int64, error) {
+	if len(chatIDs) == 0 {
+		return 0, nil
+	}
+	res := r.db.Where("audience_id = ? AND chat_id IN ?", audienceID, chatIDs).Delete(&models.AudienceMember{})
+	return res.RowsAffected, res.Error
+}
+
+// TagMembers добавляет и/или снимает теги у участников
+func (r *AudienceRepository) TagMembers(audienceID uint, chatIDs []int64, add, remove []string) (int64, error) {
+	if len(chatIDs) == 0 || (len(add) == 0 && len(remove) == 0) {
+		return 0, nil
+	}
+	if add == nil {
+		add = []string{}
+	}
+	addJSON, err := json.Marshal(add)
+	if err != nil {
+		return 0, fmt.Errorf("marshal tags: %w", err)
+	}
+
+	tagsExpr := gorm.Expr(
+		"(SELECT COALESCE(jsonb_agg(DISTINCT t), '[]'::jsonb) FROM jsonb_array_elements_text(tags || ?::jsonb) AS t)",
+		string(addJSON))
+	if len(remove) > 0 {
+		tagsExpr = gorm.Expr(
+			"(SELECT COALESCE(jsonb_agg(DISTINCT t), '[]'::jsonb) FROM jsonb_array_elements_text(tags || ?::jsonb) AS t WHERE t NOT IN ?)",
+			string(addJSON), remove)
+	}
+
+	res := r.db.Model(&models.AudienceMember{}).
+		Where("audience_id = ? AND chat_id IN ?", audienceID, chatIDs).
+		Update("tags", tagsExpr)
+	return res.RowsAffected, res.Error
+}
+
+// membersQuery — участники аудитории, отфильтрованные выражением над тегами
+func (r *AudienceRepository) membersQuery(audienceID uint, tagExpr string) (*gorm.DB, error) {
+	query := r.db.Model(&models.AudienceMember{}).Where("audience_id = ?", audienceID)
+	if tagExpr != "" {
+		cond, args, err := TagExprSQL(tagExpr)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(cond, args...)
+	}
+	return query, nil
+}
+
+// CountMembers считает участников, подходящих под выражение над тегами
+func (r *AudienceRepository) CountMembers(audienceID uint, tagExpr string) (int64, error) {
+	query, err := r.membersQuery(audienceID, tagExpr)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = query.Count(&count).Error
+	return count, err
+}
+
+// MembersPage возвращает следующую страницу участников после afterID (keyset-пагинация по ID)
+func (r *AudienceRepository) MembersPage(audienceID uint, tagExpr string, afterID uint, limit int) ([]models.AudienceMember, error) {
+	query, err := r.membersQuery(audienceID, tagExpr)
+	if err != nil {
+		return nil, err
+	}
+	var page []models.AudienceMember
+	err = query.Where("id > ?", afterID).Order("id").Limit(limit).Find(&page).Error
+	return page, err
+}
@@ -0,0 +1,49 @@
+package audiences
+
+import (
+	"GoBlast/pkg/storage/db/dbtest"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"testing"
+)
+
+func TestAudienceRepositoryOrgScope(t *testing.T) {
+	conn := dbtest.Open(t)
+	repo := NewAudienceRepository(conn)
+
+	orgID := uint(7)
+	shared := &models.Audience{UserID: 1, OrgID: &orgID, Name: "shared"}
+	private := &models.Audience{UserID: 3, Name: "private"}
+	for _, a := range []*models.Audience{shared, private} {
+		if err := repo.Create(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.AddMembers(shared.ID, []int64{10, 11}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Участник той же организации видит аудиторию коллеги
+	got, err := repo.Get(2, orgID, shared.ID)
+	if err != nil || got.MemberCount != 2 {
+		t.Fatalf("Get by org member = %+v, %v", got, err)
+	}
+	if _, err := repo.Get(2, orgID, private.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get foreign audience = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Get(2, 0, shared.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get without organization = %v, want ErrNotFound", err)
+	}
+
+	list, err := repo.List(2, orgID)
+	if err != nil || len(list) != 1 || list[0].ID != shared.ID || list[0].MemberCount != 2 {
+		t.Fatalf("List = %+v, %v", list, err)
+	}
+
+	if err := repo.Delete(2, orgID, private.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Delete foreign audience = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(2, orgID, shared.ID); err != nil {
+		t.Fatalf("Delete by org member: %v", err)
+	}
+}
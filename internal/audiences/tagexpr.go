@@ -0,0 +1,149 @@
+package audiences
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}_\-:.]{1,64}$`)
+
+// ValidateTag проверяет имя тега: буквы, цифры и _-:. длиной до 64 символов
+func ValidateTag(tag string) error {
+	if !tagPattern.MatchString(tag) {
+		return fmt.Errorf("invalid tag: %q", tag)
+	}
+	return nil
+}
+
+// TagExprSQL переводит выражение над тегами вида `vip AND (ru OR en) AND NOT churned`
+// в условие WHERE по jsonb-колонке tags. Ключевые слова AND/OR/NOT нечувствительны к регистру.
+func TagExprSQL(expr string) (string, []interface{}, error) {
+	p := &tagExprParser{tokens: tokenizeTagExpr(expr)}
+	if len(p.tokens) == 0 {
+		return "", nil, fmt.Errorf("empty tag expression")
+	}
+
+	sql, err := p.parseOr()
+	if err != nil {
+		return "", nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return "", nil, fmt.Errorf("unexpected %q in tag expression", p.tokens[p.pos])
+	}
+	return sql, p.args, nil
+}
+
+func tokenizeTagExpr(expr string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+	)
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range expr {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type tagExprParser struct {
+	tokens []string
+	pos    int
+	args   []interface{}
+}
+
+func (p *tagExprParser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], keyword)
+}
+
+// parseOr: term { OR term }
+func (p *tagExprParser) parseOr() (string, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return "", err
+	}
+	for p.peekKeyword("OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " OR " + right + ")"
+	}
+	return left, nil
+}
+
+// parseAnd: factor { AND factor }
+func (p *tagExprParser) parseAnd() (string, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return "", err
+	}
+	for p.peekKeyword("AND") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " AND " + right + ")"
+	}
+	return left, nil
+}
+
+// parseNot: NOT factor | '(' expr ')' | tag
+func (p *tagExprParser) parseNot() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", fmt.Errorf("unexpected end of tag expression")
+	}
+
+	token := p.tokens[p.pos]
+	switch {
+	case strings.EqualFold(token, "NOT"):
+		p.pos++
+		inner, err := p.parseNot()
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + inner, nil
+
+	case token == "(":
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return "", err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return "", fmt.Errorf("missing ')' in tag expression")
+		}
+		p.pos++
+		return inner, nil
+
+	case token == ")" || strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR"):
+		return "", fmt.Errorf("unexpected %q in tag expression", token)
+
+	default:
+		if err := ValidateTag(token); err != nil {
+			return "", err
+		}
+		p.pos++
+		tagJSON, _ := json.Marshal([]string{token})
+		p.args = append(p.args, string(tagJSON))
+		return "tags @> ?::jsonb", nil
+	}
+}
@@ -0,0 +1,51 @@
+package audiences
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagExprSQL(t *testing.T) {
+	tests := []struct {
+		expr string
+		sql  string
+		args []interface{}
+	}{
+		{
+			expr: "vip",
+			sql:  "tags @> ?::jsonb",
+			args: []interface{}{`["vip"]`},
+		},
+		{
+			expr: "vip and (ru OR en) AND not churned",
+			sql:  "((tags @> ?::jsonb AND (tags @> ?::jsonb OR tags @> ?::jsonb)) AND NOT tags @> ?::jsonb)",
+			args: []interface{}{`["vip"]`, `["ru"]`, `["en"]`, `["churned"]`},
+		},
+		{
+			expr: "a OR b AND c",
+			sql:  "(tags @> ?::jsonb OR (tags @> ?::jsonb AND tags @> ?::jsonb))",
+			args: []interface{}{`["a"]`, `["b"]`, `["c"]`},
+		},
+	}
+
+	for _, tt := range tests {
+		sql, args, err := TagExprSQL(tt.expr)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.expr, err)
+		}
+		if sql != tt.sql {
+			t.Errorf("%q: sql = %s, want %s", tt.expr, sql, tt.sql)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%q: args = %v, want %v", tt.expr, args, tt.args)
+		}
+	}
+}
+
+func TestTagExprSQLErrors(t *testing.T) {
+	for _, expr := range []string{"", "vip AND", "(vip", "vip)", "OR vip", "bad'tag", "vip ru"} {
+		if _, _, err := TagExprSQL(expr); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+}
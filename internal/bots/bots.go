@@ -0,0 +1,150 @@
+package bots
+
+import (
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound — бот не найден или принадлежит другой организации
+	ErrNotFound = errors.New("bot not found")
+	// ErrDuplicate — бот с тем же Telegram ID уже подключён (уникальный индекс idx_bots_telegram_id_active)
+	ErrDuplicate = errors.New("bot is already registered")
+)
+
+// isUniqueViolation — ошибка Postgres о нарушении уникального индекса (SQLSTATE 23505)
+func isUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23505"
+}
+
+type BotRepository struct {
+	db *gorm.DB
+}
+
+func NewBotRepository(db *gorm.DB) *BotRepository {
+	return &BotRepository{db: db}
+}
+
+// Create сохраняет бота; ErrDuplicate — тот же бот уже подключён параллельным запросом
+func (r *BotRepository) Create(bot *models.Bot) error {
+	err := r.db.Create(bot).Error
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// Get возвращает бота организации
+func (r *BotRepository) Get(orgID, id uint) (*models.Bot, error) {
+	var bot models.Bot
+	if err := r.db.Where("id = ? AND org_id = ?", id, orgID).First(&bot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &bot, nil
+}
+
+// GetIncludingDeleted возвращает бота организации, даже удалённого: правка, отзыв и рассылка
+// победителя продолжают задачи, уже отправленные этим ботом
+func (r *BotRepository) GetIncludingDeleted(orgID, id uint) (*models.Bot, error) {
+	var bot models.Bot
+	if err := r.db.Unscoped().Where("id = ? AND org_id = ?", id, orgID).First(&bot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &bot, nil
+}
+
+// FindByID возвращает бота любой организации (для приёма его обновлений)
+func (r *BotRepository) FindByID(id uint) (*models.Bot, error) {
+	var bot models.Bot
+	if err := r.db.First(&bot, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &bot, nil
+}
+
+// FindByUpdatesMode возвращает ботов всех организаций, получающих обновления в режиме mode
+func (r *BotRepository) FindByUpdatesMode(mode string) ([]models.Bot, error) {
+	var list []models.Bot
+	err := r.db.Where("updates_mode = ?", mode).Order("id").Find(&list).Error
+	return list, err
+}
+
+// UpdateUpdatesMode меняет режим получения обновлений бота
+func (r *BotRepository) UpdateUpdatesMode(id uint, mode, webhookSecret string) error {
+	return r.db.Model(&models.Bot{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"updates_mode":   mode,
+		"webhook_secret": webhookSecret,
+	}).Error
+}
+
+// FindByTelegramID возвращает ботов всех организаций с данным Telegram ID
+func (r *BotRepository) FindByTelegramID(telegramID int64) ([]models.Bot, error) {
+	var list []models.Bot
+	err := r.db.Where("telegram_id = ?", telegramID).Find(&list).Error
+	return list, err
+}
+
+// List возвращает ботов организации
+func (r *BotRepository) List(orgID uint) ([]models.Bot, error) {
+	var list []models.Bot
+	err := r.db.Where("org_id = ?", orgID).Order("id").Find(&list).Error
+	return list, err
+}
+
+// ListByStatus возвращает ботов всех организаций в статусе status (для проверки здоровья)
+func (r *BotRepository) ListByStatus(status string) ([]models.Bot, error) {
+	var list []models.Bot
+	err := r.db.Where("status = ?", status).Order("id").Find(&list).Error
+	return list, err
+}
+
+// MarkUnauthorized отключает бота, чей токен отклонён Telegram
+func (r *BotRepository) MarkUnauthorized(id uint, reason string) error {
+	return r.db.Model(&models.Bot{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        models.BotUnauthorized,
+		"status_reason": reason,
+	}).Error
+}
+
+// RecordCheck сохраняет время проверки getMe и ошибку связи (пусто, если ответ получен)
+func (r *BotRepository) RecordCheck(id uint, checkedAt time.Time, checkErr string) error {
+	return r.db.Model(&models.Bot{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_checked_at":  checkedAt,
+		"last_check_error": checkErr,
+	}).Error
+}
+
+// Update сохраняет изменённые поля бота; ErrDuplicate — новый Telegram ID уже занят
+func (r *BotRepository) Update(bot *models.Bot, fields map[string]interface{}) error {
+	err := r.db.Model(bot).Updates(fields).Error
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// Delete удаляет бота организации (мягко: задачи продолжают на него ссылаться,
+// а их правка и отзыв находят бота через GetIncludingDeleted)
+func (r *BotRepository) Delete(orgID, id uint) error {
+	res := r.db.Where("id = ? AND org_id = ?", id, orgID).Delete(&models.Bot{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package bots
+
+import (
+	"GoBlast/pkg/storage/db/dbtest"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"testing"
+)
+
+func TestBotRepositoryCreateAndDelete(t *testing.T) {
+	conn := dbtest.Open(t)
+	repo := NewBotRepository(conn)
+
+	org := &models.Organization{Name: "acme"}
+	if err := conn.Create(org).Error; err != nil {
+		t.Fatal(err)
+	}
+	bot := &models.Bot{UserID: 1, OrgID: org.ID, Name: "news", Token: "enc", TelegramID: 42, Status: models.BotActive}
+	if err := repo.Create(bot); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := repo.Get(org.ID, bot.ID)
+	if err != nil || got.TelegramID != 42 {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	if _, err := repo.Get(org.ID+1, bot.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get from another organization = %v, want ErrNotFound", err)
+	}
+	if found, err := repo.FindByTelegramID(42); err != nil || len(found) != 1 {
+		t.Fatalf("FindByTelegramID = %v, %v", found, err)
+	}
+
+	// Параллельный запрос прошёл проверку дубликатов, но индекс не даёт подключить бота дважды
+	twin := &models.Bot{UserID: 2, OrgID: org.ID, Name: "twin", Token: "enc", TelegramID: 42, Status: models.BotActive}
+	if err := repo.Create(twin); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("Create duplicate = %v, want ErrDuplicate", err)
+	}
+
+	if err := repo.Delete(org.ID+1, bot.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Delete from another organization = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(org.ID, bot.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// Удалённый бот не мешает подключить его снова, но остаётся доступным задачам, которые он отправил
+	if _, err := repo.Get(org.ID, bot.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after delete = %v, want ErrNotFound", err)
+	}
+	if found, err := repo.FindByTelegramID(42); err != nil || len(found) != 0 {
+		t.Fatalf("FindByTelegramID after delete = %v, %v", found, err)
+	}
+	if got, err := repo.GetIncludingDeleted(org.ID, bot.ID); err != nil || got.ID != bot.ID {
+		t.Fatalf("GetIncludingDeleted = %+v, %v", got, err)
+	}
+	if err := repo.Delete(org.ID, bot.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Delete = %v, want ErrNotFound", err)
+	}
+	twin.ID = 0
+	if err := repo.Create(twin); err != nil {
+		t.Fatalf("Create after delete: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package bots
+
+import (
+	"GoBlast/configs"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Значения по умолчанию, если в конфигурации секция worker не заполнена
+const (
+	defaultConcurrency = 10
+	defaultQueueSize   = 100
+	defaultRateHigh    = 30
+	defaultRateMedium  = 10
+	defaultRateLow     = 2
+	defaultMaxAttempts = 3
+	defaultBackoff     = 5 * time.Second
+	defaultMaxBackoff  = time.Minute
+)
+
+// Допустимые значения переопределений
+const (
+	MaxConcurrency = 100
+	MaxQueueSize   = 10000
+	MaxRate        = 1000 // msg/sec: предел платных рассылок Telegram
+	MaxAttempts    = 10
+)
+
+// Limits — действующие ограничения воркера бота
+type Limits struct {
+	Concurrency int           `json:"concurrency"`
+	QueueSize   int           `json:"queue_size"`
+	RateHigh    float64       `json:"rate_high"`
+	RateMedium  float64       `json:"rate_medium"`
+	RateLow     float64       `json:"rate_low"`
+	MaxAttempts int           `json:"retry_max_attempts"`
+	Backoff     time.Duration `json:"-"`
+	MaxBackoff  time.Duration `json:"-"`
+}
+
+// ResolveLimits накладывает переопределения бота (может быть nil) на конфигурацию
+func ResolveLimits(cfg configs.WorkerConfig, override *models.BotLimit) Limits {
+	l := Limits{
+		Concurrency: orDefault(cfg.Concurrency, defaultConcurrency),
+		QueueSize:   cfg.QueueSize,
+		RateHigh:    orDefaultRate(cfg.Rates.High, defaultRateHigh),
+		RateMedium:  orDefaultRate(cfg.Rates.Medium, defaultRateMedium),
+		RateLow:     orDefaultRate(cfg.Rates.Low, defaultRateLow),
+		MaxAttempts: orDefault(cfg.Retry.MaxAttempts, defaultMaxAttempts),
+		Backoff:     cfg.Retry.Backoff,
+		MaxBackoff:  cfg.Retry.MaxBackoff,
+	}
+	if l.QueueSize <= 0 {
+		l.QueueSize = defaultQueueSize
+	}
+	if l.Backoff <= 0 {
+		l.Backoff = defaultBackoff
+	}
+	if l.MaxBackoff < l.Backoff {
+		l.MaxBackoff = defaultMaxBackoff
+	}
+
+	if override == nil {
+		return l
+	}
+	if override.Concurrency != nil {
+		l.Concurrency = *override.Concurrency
+	}
+	if override.QueueSize != nil {
+		l.QueueSize = *override.QueueSize
+	}
+	if override.RateHigh != nil {
+		l.RateHigh = *override.RateHigh
+	}
+	if override.RateMedium != nil {
+		l.RateMedium = *override.RateMedium
+	}
+	if override.RateLow != nil {
+		l.RateLow = *override.RateLow
+	}
+	if override.RetryMaxAttempts != nil {
+		l.MaxAttempts = *override.RetryMaxAttempts
+	}
+	return l
+}
+
+// RetryDelay — пауза перед попыткой attempt (вторая попытка — Backoff, дальше вдвое больше)
+func (l Limits) RetryDelay(attempt int) time.Duration {
+	delay := l.Backoff
+	for i := 2; i < attempt && delay < l.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > l.MaxBackoff {
+		delay = l.MaxBackoff
+	}
+	return delay
+}
+
+// ValidateLimit проверяет переопределения бота
+func ValidateLimit(limit *models.BotLimit) error {
+	if v := limit.Concurrency; v != nil && (*v < 1 || *v > MaxConcurrency) {
+		return fmt.Errorf("concurrency must be between 1 and %d", MaxConcurrency)
+	}
+	if v := limit.QueueSize; v != nil && (*v < 1 || *v > MaxQueueSize) {
+		return fmt.Errorf("queue_size must be between 1 and %d", MaxQueueSize)
+	}
+	for name, v := range map[string]*float64{"rate_high": limit.RateHigh, "rate_medium": limit.RateMedium, "rate_low": limit.RateLow} {
+		if v != nil && (*v <= 0 || *v > MaxRate) {
+			return fmt.Errorf("%s must be greater than 0 and at most %d", name, MaxRate)
+		}
+	}
+	if v := limit.RetryMaxAttempts; v != nil && (*v < 1 || *v > MaxAttempts) {
+		return fmt.Errorf("retry_max_attempts must be between 1 and %d", MaxAttempts)
+	}
+	return nil
+}
+
+func orDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func orDefaultRate(v, def float64) float64 {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// GetLimit возвращает переопределения бота; nil — их нет
+func (r *BotRepository) GetLimit(botID uint) (*models.BotLimit, error) {
+	var limit models.BotLimit
+	if err := r.db.Where("bot_id = ?", botID).First(&limit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// SaveLimit создаёт или заменяет переопределения бота
+func (r *BotRepository) SaveLimit(limit *models.BotLimit) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "bot_id"}},
+		UpdateAll: true,
+	}).Create(limit).Error
+}
+
+// DeleteLimit сбрасывает переопределения бота к конфигурации
+func (r *BotRepository) DeleteLimit(botID uint) error {
+	return r.db.Where("bot_id = ?", botID).Delete(&models.BotLimit{}).Error
+}
@@ -0,0 +1,46 @@
+package bots
+
+import (
+	"GoBlast/configs"
+	"GoBlast/pkg/storage/models"
+	"testing"
+	"time"
+)
+
+func TestResolveLimits(t *testing.T) {
+	cfg := configs.WorkerConfig{
+		Concurrency: 5,
+		Rates:       configs.PriorityRates{High: 20},
+		Retry:       configs.RetryConfig{MaxAttempts: 4, Backoff: time.Second, MaxBackoff: 5 * time.Second},
+	}
+
+	l := ResolveLimits(cfg, nil)
+	if l.Concurrency != 5 || l.QueueSize != defaultQueueSize || l.RateHigh != 20 || l.RateMedium != defaultRateMedium {
+		t.Fatalf("unexpected defaults: %+v", l)
+	}
+
+	concurrency, rate := 50, 500.0
+	l = ResolveLimits(cfg, &models.BotLimit{Concurrency: &concurrency, RateHigh: &rate})
+	if l.Concurrency != 50 || l.RateHigh != 500 || l.RateLow != defaultRateLow || l.MaxAttempts != 4 {
+		t.Fatalf("override not applied: %+v", l)
+	}
+
+	for attempt, want := range map[int]time.Duration{2: time.Second, 3: 2 * time.Second, 4: 4 * time.Second, 5: 5 * time.Second} {
+		if got := l.RetryDelay(attempt); got != want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestValidateLimit(t *testing.T) {
+	zero, tooFast, ok := 0, 2000.0, 15.0
+	if err := ValidateLimit(&models.BotLimit{Concurrency: &zero}); err == nil {
+		t.Error("concurrency 0 must be rejected")
+	}
+	if err := ValidateLimit(&models.BotLimit{RateLow: &tooFast}); err == nil {
+		t.Error("rate above MaxRate must be rejected")
+	}
+	if err := ValidateLimit(&models.BotLimit{RateMedium: &ok}); err != nil {
+		t.Errorf("valid limit rejected: %v", err)
+	}
+}
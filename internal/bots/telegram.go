@@ -0,0 +1,59 @@
+package bots
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// ErrInvalidToken — токен имеет неверный формат или отклонён Telegram
+var ErrInvalidToken = errors.New("invalid bot token")
+
+// tokenPattern — формат токена BotFather: <id бота>:<секрет>
+var tokenPattern = regexp.MustCompile(`^[0-9]{1,20}:[A-Za-z0-9_-]{30,}$`)
+
+// getMeTimeout ограничивает ожидание ответа Telegram при проверке токена
+const getMeTimeout = 10 * time.Second
+
+// Identity — данные бота из getMe
+type Identity struct {
+	TelegramID int64
+	Username   string
+	Name       string
+}
+
+// ValidTokenFormat проверяет формат токена без обращения к Telegram
+func ValidTokenFormat(token string) bool {
+	return tokenPattern.MatchString(token)
+}
+
+// GetMe проверяет токен через getMe и возвращает данные бота.
+// Токен неверного формата или отклонённый Telegram даёт ErrInvalidToken;
+// прочие ошибки (сеть, лимиты) возвращаются как есть.
+func GetMe(token string) (*Identity, error) {
+	if !ValidTokenFormat(token) {
+		return nil, ErrInvalidToken
+	}
+
+	bot, err := tele.NewBot(tele.Settings{
+		Token:  token,
+		Client: &http.Client{Timeout: getMeTimeout},
+	})
+	if err != nil {
+		// На неизвестный токен Telegram отвечает 401 Unauthorized или 404 Not Found
+		if errors.Is(err, tele.ErrUnauthorized) || errors.Is(err, tele.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		return nil, err
+	}
+
+	return &Identity{
+		TelegramID: bot.Me.ID,
+		Username:   bot.Me.Username,
+		Name:       bot.Me.FirstName,
+	}, nil
+}
@@ -0,0 +1,27 @@
+package bots
+
+import "testing"
+
+func TestValidTokenFormat(t *testing.T) {
+	cases := map[string]bool{
+		"123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw": true,
+		"123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDs-_": true,
+		"":          false,
+		"123456789": false,
+		"bot123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw": false,
+		"123456789:short": false,
+		"123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsa w": false,
+		" 123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw": false,
+	}
+	for token, want := range cases {
+		if got := ValidTokenFormat(token); got != want {
+			t.Errorf("ValidTokenFormat(%q) = %v, want %v", token, got, want)
+		}
+	}
+}
+
+func TestGetMeRejectsMalformedToken(t *testing.T) {
+	if _, err := GetMe("not-a-token"); err != ErrInvalidToken {
+		t.Fatalf("GetMe: got %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,30 @@
+package bots
+
+import (
+	"GoBlast/internal/api/middleware"
+	"GoBlast/pkg/encryption"
+	"encoding/base64"
+	"fmt"
+)
+
+// EncryptToken шифрует токен бота для хранения в Bot.Token и AuthUser.Token
+func EncryptToken(token string) (string, error) {
+	encrypted, err := encryption.Encrypt([]byte(token), []byte(middleware.EncryptionKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt bot token: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// DecryptToken расшифровывает токен бота, сохранённый EncryptToken
+func DecryptToken(encoded string) (string, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode bot token: %w", err)
+	}
+	token, err := encryption.Decrypt(encrypted, []byte(middleware.EncryptionKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt bot token: %w", err)
+	}
+	return string(token), nil
+}
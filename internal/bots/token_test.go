@@ -0,0 +1,22 @@
+package bots
+
+import (
+	"GoBlast/internal/api/middleware"
+	"testing"
+)
+
+func TestTokenEncryptionRoundTrip(t *testing.T) {
+	middleware.EncryptionKey = "0123456789abcdef0123456789abcdef"
+
+	encoded, err := EncryptToken("123456:ABC-DEF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := DecryptToken(encoded)
+	if err != nil || token != "123456:ABC-DEF" {
+		t.Fatalf("DecryptToken = %q, %v", token, err)
+	}
+	if _, err := DecryptToken("not base64!"); err == nil {
+		t.Fatal("DecryptToken accepted a malformed value")
+	}
+}
@@ -0,0 +1,65 @@
+package leases
+
+import (
+	"GoBlast/pkg/storage/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LeaseRepository хранит аренды ботов экземплярами воркера.
+// Аренда истекает, если владелец перестал её продлевать (например, процесс упал).
+type LeaseRepository struct {
+	db *gorm.DB
+}
+
+func NewLeaseRepository(db *gorm.DB) *LeaseRepository {
+	return &LeaseRepository{db: db}
+}
+
+// Acquire берёт аренду key на ttl, если она свободна, истекла или уже принадлежит owner.
+// Захват атомарен: из нескольких экземпляров аренду получает только один.
+func (r *LeaseRepository) Acquire(key, owner string, ttl time.Duration) (bool, error) {
+	res := r.db.Exec(`
+		INSERT INTO bot_leases (bot_key, owner, expires_at)
+		VALUES (?, ?, NOW() + make_interval(secs => ?))
+		ON CONFLICT (bot_key) DO UPDATE
+		SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+		WHERE bot_leases.owner = EXCLUDED.owner OR bot_leases.expires_at < NOW()`,
+		key, owner, ttl.Seconds())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Renew продлевает аренды owner из keys и возвращает те, что ещё за ним
+func (r *LeaseRepository) Renew(owner string, keys []string, ttl time.Duration) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	var renewed []string
+	err := r.db.Raw(`
+		UPDATE bot_leases SET expires_at = NOW() + make_interval(secs => ?)
+		WHERE owner = ? AND bot_key IN ?
+		RETURNING bot_key`,
+		ttl.Seconds(), owner, keys).Scan(&renewed).Error
+	return renewed, err
+}
+
+// Release освобождает аренду key, если она принадлежит owner
+func (r *LeaseRepository) Release(key, owner string) error {
+	return r.db.Where("bot_key = ? AND owner = ?", key, owner).Delete(&models.BotLease{}).Error
+}
+
+// ReleaseAll освобождает все аренды owner (при остановке воркера)
+func (r *LeaseRepository) ReleaseAll(owner string) error {
+	return r.db.Where("owner = ?", owner).Delete(&models.BotLease{}).Error
+}
+
+// List возвращает действующие аренды
+func (r *LeaseRepository) List() ([]models.BotLease, error) {
+	var list []models.BotLease
+	err := r.db.Where("expires_at >= NOW()").Order("bot_key").Find(&list).Error
+	return list, err
+}
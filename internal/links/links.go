@@ -0,0 +1,103 @@
+package links
+
+import (
+	"GoBlast/pkg/storage/models"
+
+	"gorm.io/gorm"
+)
+
+type LinksRepository struct {
+	db *gorm.DB
+}
+
+func NewLinksRepository(db *gorm.DB) *LinksRepository {
+	return &LinksRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *LinksRepository) WithTx(tx *gorm.DB) *LinksRepository {
+	return &LinksRepository{db: tx}
+}
+
+// CreateLinks заводит отслеживаемые ссылки задачи и возвращает карту url -> ID ссылки.
+func (r *LinksRepository) CreateLinks(taskID, variant string, urls []string) (map[string]uint, error) {
+	result := make(map[string]uint, len(urls))
+	if len(urls) == 0 {
+		return result, nil
+	}
+
+	rows := make([]models.TaskLink, 0, len(urls))
+	for _, u := range urls {
+		rows = append(rows, models.TaskLink{TaskID: taskID, Variant: variant, URL: u})
+	}
+	if err := r.db.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.URL] = row.ID
+	}
+	return result, nil
+}
+
+func (r *LinksRepository) FindByID(id uint) (*models.TaskLink, error) {
+	var link models.TaskLink
+	if err := r.db.First(&link, id).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// RecordClick сохраняет переход получателя по ссылке
+func (r *LinksRepository) RecordClick(link *models.TaskLink, recipient int64) error {
+	return r.db.Create(&models.LinkClick{
+		LinkID:    link.ID,
+		TaskID:    link.TaskID,
+		Recipient: recipient,
+	}).Error
+}
+
+// ClickStats агрегирует клики по задаче. Возвращает nil, если у задачи нет отслеживаемых ссылок.
+func (r *LinksRepository) ClickStats(taskID string) (*models.ClickStats, error) {
+	var linkCount int64
+	if err := r.db.Model(&models.TaskLink{}).Where("task_id = ?", taskID).Count(&linkCount).Error; err != nil {
+		return nil, err
+	}
+	if linkCount == 0 {
+		return nil, nil
+	}
+
+	stats := &models.ClickStats{
+		ByURL:     make(map[string]int64),
+		ByVariant: make(map[string]int64),
+	}
+
+	if err := r.db.Model(&models.LinkClick{}).
+		Where("task_id = ?", taskID).
+		Select("COUNT(*) AS total_clicks, COUNT(DISTINCT recipient) AS unique_recipients").
+		Row().Scan(&stats.TotalClicks, &stats.UniqueRecipients); err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		URL     string
+		Variant string
+		Clicks  int64
+	}
+	if err := r.db.Table("task_links").
+		Select("task_links.url, task_links.variant, COUNT(link_clicks.id) AS clicks").
+		Joins("LEFT JOIN link_clicks ON link_clicks.link_id = task_links.id").
+		Where("task_links.task_id = ?", taskID).
+		Group("task_links.url, task_links.variant").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		stats.ByURL[row.URL] += row.Clicks
+		if row.Variant != "" {
+			stats.ByVariant[row.Variant] += row.Clicks
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,86 @@
+package reports
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// WriteCSV пишет строки отчёта и итог: колонки измерений, метрики и по колонке на каждый код ошибки
+func WriteCSV(w io.Writer, report *Report) error {
+	codes := report.errorCodes()
+
+	header := append([]string{}, report.GroupBy...)
+	header = append(header, "tasks", "expected", "sent", "failed", "skipped", "retried", "blocked",
+		"failure_rate", "block_rate", "avg_throughput", "avg_latency")
+	for _, code := range codes {
+		header = append(header, "error_"+code)
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, row := range report.Rows {
+		if err := out.Write(row.record(report.GroupBy, codes, false)); err != nil {
+			return err
+		}
+	}
+	if err := out.Write(report.Totals.record(report.GroupBy, codes, true)); err != nil {
+		return err
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// record — строка CSV; у итога в первой колонке измерений стоит "total"
+func (row Row) record(groupBy, codes []string, total bool) []string {
+	record := make([]string, 0, len(groupBy)+11+len(codes))
+	for i, key := range groupBy {
+		if total {
+			value := ""
+			if i == 0 {
+				value = "total"
+			}
+			record = append(record, value)
+			continue
+		}
+		record = append(record, row.dimension(key))
+	}
+
+	for _, v := range []int64{row.Tasks, row.Expected, row.Sent, row.Failed, row.Skipped, row.Retried, row.Blocked} {
+		record = append(record, strconv.FormatInt(v, 10))
+	}
+	for _, v := range []float64{row.FailureRate, row.BlockRate, row.AvgThroughput, row.AvgLatency} {
+		record = append(record, strconv.FormatFloat(v, 'f', 4, 64))
+	}
+	for _, code := range codes {
+		record = append(record, strconv.FormatInt(row.ErrorCounts[code], 10))
+	}
+	return record
+}
+
+// dimension — значение измерения key строки для CSV
+func (row Row) dimension(key string) string {
+	switch key {
+	case GroupDay:
+		return formatDate(row.Day)
+	case GroupWeek:
+		return formatDate(row.Week)
+	case GroupBot:
+		if row.BotID != nil {
+			return strconv.FormatUint(uint64(*row.BotID), 10)
+		}
+	case GroupContentType:
+		return row.ContentType
+	}
+	return ""
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02")
+}
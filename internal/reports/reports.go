@@ -0,0 +1,249 @@
+package reports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Измерения группировки отчёта
+const (
+	GroupDay         = "day"
+	GroupWeek        = "week"
+	GroupBot         = "bot"
+	GroupContentType = "content_type"
+)
+
+// groupColumns — выражения SQL для измерений (дни и недели — по UTC)
+var groupColumns = map[string]string{
+	GroupDay:         "date_trunc('day', tasks.created_at AT TIME ZONE 'UTC') AS day",
+	GroupWeek:        "date_trunc('week', tasks.created_at AT TIME ZONE 'UTC') AS week",
+	GroupBot:         "COALESCE(tasks.bot_id, 0) AS bot_id",
+	GroupContentType: "tasks.message_type AS content_type",
+}
+
+// groupOrder — порядок колонок в GROUP BY и в CSV
+var groupOrder = []string{GroupDay, GroupWeek, GroupBot, GroupContentType}
+
+// blockedCode — код ошибки «получатель заблокировал бота» в статистике задачи
+const blockedCode = "BLOCKED"
+
+// aggregates — метрики группы; статистика задачи хранится в tasks.stats (jsonb)
+const aggregates = `COUNT(*) AS tasks,
+	COALESCE(SUM((tasks.stats->>'expected_count')::bigint), 0) AS expected,
+	COALESCE(SUM((tasks.stats->>'total_sent')::bigint), 0) AS sent,
+	COALESCE(SUM((tasks.stats->>'total_failed')::bigint), 0) AS failed,
+	COALESCE(SUM((tasks.stats->>'total_skipped')::bigint), 0) AS skipped,
+	COALESCE(SUM((tasks.stats->>'total_retried')::bigint), 0) AS retried,
+	COALESCE(AVG(NULLIF((tasks.stats->>'throughput')::float8, 0)), 0) AS avg_throughput,
+	COALESCE(AVG(EXTRACT(EPOCH FROM (tasks.stats->>'end_time')::timestamptz - tasks.created_at)), 0) AS avg_latency`
+
+// Filter — чьи задачи и за какой период попадают в отчёт
+type Filter struct {
+	UserID  uint
+	OrgID   uint      // 0 — только задачи пользователя
+	From    time.Time // включительно
+	To      time.Time // не включительно
+	GroupBy []string
+}
+
+// Row — метрики одной группы задач. Поля измерений, не входящих в группировку, пусты.
+type Row struct {
+	Day         *time.Time `json:"day,omitempty"`
+	Week        *time.Time `json:"week,omitempty"`
+	BotID       *uint      `json:"bot_id,omitempty"` // 0 — бот по умолчанию пользователя
+	ContentType string     `json:"content_type,omitempty"`
+
+	Tasks    int64 `json:"tasks"`
+	Expected int64 `json:"expected"`
+	Sent     int64 `json:"sent"`
+	Failed   int64 `json:"failed"`
+	Skipped  int64 `json:"skipped"`
+	Retried  int64 `json:"retried"`
+	Blocked  int64 `gorm:"-" json:"blocked"` // ошибки «бот заблокирован получателем»
+
+	FailureRate   float64 `gorm:"-" json:"failure_rate"` // failed / (sent + failed)
+	BlockRate     float64 `gorm:"-" json:"block_rate"`   // blocked / (sent + failed)
+	AvgThroughput float64 `json:"avg_throughput"`        // сообщений в секунду
+	AvgLatency    float64 `json:"avg_latency"`           // секунд от создания задачи до завершения рассылки
+
+	ErrorCounts map[string]int64 `gorm:"-" json:"error_counts"`
+}
+
+// Report — отчёт по рассылкам за период
+type Report struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	GroupBy []string  `json:"group_by"`
+	Rows    []Row     `json:"rows"`
+	Totals  Row       `json:"totals"`
+}
+
+// ReportsRepository считает отчёты по задачам в Postgres
+type ReportsRepository struct {
+	db *gorm.DB
+}
+
+func NewReportsRepository(db *gorm.DB) *ReportsRepository {
+	return &ReportsRepository{db: db}
+}
+
+// ParseGroupBy разбирает список измерений через запятую; day и week взаимоисключающие
+func ParseGroupBy(value string) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		key := strings.TrimSpace(part)
+		if key == "" {
+			continue
+		}
+		if _, ok := groupColumns[key]; !ok {
+			return nil, fmt.Errorf("unknown group_by %q: use day, week, bot or content_type", key)
+		}
+		seen[key] = true
+	}
+	if seen[GroupDay] && seen[GroupWeek] {
+		return nil, fmt.Errorf("group_by cannot contain both day and week")
+	}
+
+	groupBy := make([]string, 0, len(seen))
+	for _, key := range groupOrder {
+		if seen[key] {
+			groupBy = append(groupBy, key)
+		}
+	}
+	return groupBy, nil
+}
+
+// Build считает отчёт: строки по группам и итог за весь период
+func (r *ReportsRepository) Build(filter Filter) (*Report, error) {
+	rows, err := r.aggregate(filter, filter.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := r.aggregate(filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{From: filter.From, To: filter.To, GroupBy: filter.GroupBy, Rows: rows}
+	if len(totals) == 1 {
+		report.Totals = totals[0]
+	}
+	return report, nil
+}
+
+// aggregate возвращает метрики по группам groupBy (без группировки — одну строку)
+func (r *ReportsRepository) aggregate(filter Filter, groupBy []string) ([]Row, error) {
+	columns := make([]string, 0, len(groupBy))
+	names := make([]string, 0, len(groupBy))
+	for _, key := range groupBy {
+		columns = append(columns, groupColumns[key])
+		names = append(names, groupName(key))
+	}
+
+	var rows []Row
+	query := r.scoped(filter).Select(strings.Join(append(columns, aggregates), ", "))
+	if len(names) > 0 {
+		group := strings.Join(names, ", ")
+		query = query.Group(group).Order(group)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var errorRows []struct {
+		Row
+		Code  string
+		Count int64
+	}
+	errQuery := r.scoped(filter).
+		Joins("CROSS JOIN LATERAL jsonb_each_text(COALESCE(tasks.stats->'error_counts', '{}'::jsonb)) AS e").
+		Select(strings.Join(append(columns, "e.key AS code", "SUM(e.value::bigint) AS count"), ", ")).
+		Group(strings.Join(append(names, "e.key"), ", "))
+	if err := errQuery.Scan(&errorRows).Error; err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*Row, len(rows))
+	for i := range rows {
+		rows[i].ErrorCounts = make(map[string]int64)
+		index[rows[i].key()] = &rows[i]
+	}
+	for _, er := range errorRows {
+		if row := index[er.Row.key()]; row != nil {
+			row.ErrorCounts[er.Code] += er.Count
+		}
+	}
+	for i := range rows {
+		rows[i].fillRates()
+	}
+	return rows, nil
+}
+
+// scoped — отправленные рассылки пользователя (или его организации) за период
+func (r *ReportsRepository) scoped(filter Filter) *gorm.DB {
+	query := r.db.Table("tasks").
+		Where("tasks.deleted_at IS NULL AND tasks.action = ? AND tasks.stats IS NOT NULL", "send").
+		Where("tasks.created_at >= ? AND tasks.created_at < ?", filter.From, filter.To)
+	if filter.OrgID != 0 {
+		return query.Where("(tasks.user_id = ? OR tasks.org_id = ?)", filter.UserID, filter.OrgID)
+	}
+	return query.Where("tasks.user_id = ?", filter.UserID)
+}
+
+// groupName — имя колонки измерения в результате запроса
+func groupName(key string) string {
+	if key == GroupBot {
+		return "bot_id"
+	}
+	return key
+}
+
+// key — значения измерений строки для сопоставления с разбивкой по ошибкам
+func (row *Row) key() string {
+	var b strings.Builder
+	if row.Day != nil {
+		b.WriteString(row.Day.UTC().Format(time.RFC3339))
+	}
+	b.WriteByte('|')
+	if row.Week != nil {
+		b.WriteString(row.Week.UTC().Format(time.RFC3339))
+	}
+	b.WriteByte('|')
+	if row.BotID != nil {
+		fmt.Fprint(&b, *row.BotID)
+	}
+	b.WriteByte('|')
+	b.WriteString(row.ContentType)
+	return b.String()
+}
+
+// fillRates считает доли ошибок и блокировок от попыток отправки
+func (row *Row) fillRates() {
+	row.Blocked = row.ErrorCounts[blockedCode]
+	attempted := row.Sent + row.Failed
+	if attempted == 0 {
+		return
+	}
+	row.FailureRate = float64(row.Failed) / float64(attempted)
+	row.BlockRate = float64(row.Blocked) / float64(attempted)
+}
+
+// errorCodes — все коды ошибок отчёта по алфавиту (колонки CSV)
+func (report *Report) errorCodes() []string {
+	seen := make(map[string]bool)
+	for _, row := range append(report.Rows, report.Totals) {
+		for code := range row.ErrorCounts {
+			seen[code] = true
+		}
+	}
+	codes := make([]string, 0, len(seen))
+	for code := range seen {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
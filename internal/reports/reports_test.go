@@ -0,0 +1,72 @@
+package reports
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	got, err := ParseGroupBy(" content_type, bot ,day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "day,bot,content_type" {
+		t.Errorf("got %v, want dimensions in canonical order", got)
+	}
+
+	if got, err := ParseGroupBy(""); err != nil || len(got) != 0 {
+		t.Errorf("empty group_by: got %v, %v", got, err)
+	}
+	for _, bad := range []string{"month", "day,week"} {
+		if _, err := ParseGroupBy(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestFillRates(t *testing.T) {
+	row := Row{Sent: 90, Failed: 10, ErrorCounts: map[string]int64{"BLOCKED": 5, "NOT_FOUND": 5}}
+	row.fillRates()
+	if row.Blocked != 5 || row.FailureRate != 0.1 || row.BlockRate != 0.05 {
+		t.Errorf("blocked %d, failure rate %v, block rate %v", row.Blocked, row.FailureRate, row.BlockRate)
+	}
+
+	empty := Row{ErrorCounts: map[string]int64{}}
+	empty.fillRates()
+	if empty.FailureRate != 0 || empty.BlockRate != 0 {
+		t.Errorf("rates of a group without attempts must be zero: %+v", empty)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	day := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	bot := uint(3)
+	report := &Report{
+		GroupBy: []string{GroupDay, GroupBot},
+		Rows: []Row{{
+			Day: &day, BotID: &bot, Tasks: 2, Sent: 9, Failed: 1, Blocked: 1,
+			FailureRate: 0.1, BlockRate: 0.1, ErrorCounts: map[string]int64{"BLOCKED": 1},
+		}},
+		Totals: Row{Tasks: 2, Sent: 9, Failed: 1, Blocked: 1, ErrorCounts: map[string]int64{"BLOCKED": 1}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want header, row and total:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "day,bot,tasks,") || !strings.HasSuffix(lines[0], ",error_BLOCKED") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "2025-01-06,3,2,0,9,1,") {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "total,,2,") {
+		t.Errorf("unexpected total %q", lines[2])
+	}
+}
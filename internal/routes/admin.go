@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"GoBlast/internal/api/handlers"
+	"GoBlast/internal/api/middleware"
+	"GoBlast/pkg/storage/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAdminRoutes(router *gin.RouterGroup, workersHandler *handlers.WorkersHandler) {
+	adminRoutes := router.Group("/admin", middleware.RequireRole(models.RoleAdmin))
+	{
+		adminRoutes.GET("/workers", workersHandler.GetWorkersStatus)
+	}
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"GoBlast/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAPIKeyRoutes(router *gin.RouterGroup, apiKeyHandler *handlers.APIKeyHandler) {
+	keyRoutes := router.Group("/keys")
+	{
+		keyRoutes.POST("", apiKeyHandler.CreateAPIKey)
+		keyRoutes.GET("", apiKeyHandler.ListAPIKeys)
+		keyRoutes.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"GoBlast/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAudienceRoutes(router *gin.RouterGroup, audienceHandler *handlers.AudienceHandler) {
+	audienceRoutes := router.Group("/audiences")
+	{
+		audienceRoutes.POST("", audienceHandler.CreateAudience)
+		audienceRoutes.GET("", audienceHandler.ListAudiences)
+		audienceRoutes.GET("/:id", audienceHandler.GetAudience)
+		audienceRoutes.DELETE("/:id", audienceHandler.DeleteAudience)
+		audienceRoutes.POST("/:id/members", audienceHandler.AddMembers)
+		audienceRoutes.DELETE("/:id/members", audienceHandler.RemoveMembers)
+		audienceRoutes.POST("/:id/tags", audienceHandler.TagMembers)
+		audienceRoutes.POST("/:id/import", audienceHandler.ImportMembers)
+	}
+}
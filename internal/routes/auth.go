@@ -11,5 +11,19 @@ func SetupAuthRoutes(router *gin.RouterGroup, authHandler *handlers.AuthHandler)
 	{
 		authRoutes.POST("/login", authHandler.LoginHandler)
 		authRoutes.POST("/register", authHandler.RegisterHandler)
+		authRoutes.POST("/refresh", authHandler.RefreshHandler)
+	}
+}
+
+// SetupAccountRoutes — сессии и настройки собственного аккаунта, доступные любой роли
+func SetupAccountRoutes(router *gin.RouterGroup, authHandler *handlers.AuthHandler) {
+	authRoutes := router.Group("/auth")
+	{
+		authRoutes.POST("/logout", authHandler.LogoutHandler)
+		authRoutes.POST("/logout-all", authHandler.LogoutAllHandler)
+		authRoutes.POST("/password", authHandler.ChangePassword)
+		authRoutes.POST("/totp/setup", authHandler.SetupTOTP)
+		authRoutes.POST("/totp/enable", authHandler.EnableTOTP)
+		authRoutes.POST("/totp/disable", authHandler.DisableTOTP)
 	}
 }
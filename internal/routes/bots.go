@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"GoBlast/internal/api/handlers"
+	"GoBlast/internal/api/middleware"
+	"GoBlast/pkg/storage/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupBotRoutes(router *gin.RouterGroup, botHandler *handlers.BotHandler) {
+	botRoutes := router.Group("/bots")
+	{
+		botRoutes.GET("", botHandler.ListBots)
+		botRoutes.GET("/:id", botHandler.GetBot)
+		botRoutes.GET("/:id/limits", botHandler.GetBotLimits)
+
+		// Токены ботов меняет только администратор организации
+		adminOnly := middleware.RequireRole(models.RoleAdmin)
+		botRoutes.POST("", adminOnly, botHandler.CreateBot)
+		botRoutes.PATCH("/:id", adminOnly, botHandler.UpdateBot)
+		botRoutes.DELETE("/:id", adminOnly, botHandler.DeleteBot)
+
+		// Ограничения воркера бота (например, для платных рассылок)
+		botRoutes.PUT("/:id/limits", adminOnly, botHandler.UpdateBotLimits)
+		botRoutes.DELETE("/:id/limits", adminOnly, botHandler.DeleteBotLimits)
+	}
+}
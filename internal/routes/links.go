@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"GoBlast/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupLinkRoutes(router *gin.RouterGroup, linkHandler *handlers.LinkHandler) {
+	router.GET("/r/:code", linkHandler.Redirect)
+}
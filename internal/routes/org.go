@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"GoBlast/internal/api/handlers"
+	"GoBlast/internal/api/middleware"
+	"GoBlast/pkg/storage/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupOrgRoutes(router *gin.RouterGroup, orgHandler *handlers.OrgHandler) {
+	orgRoutes := router.Group("/org")
+	{
+		orgRoutes.GET("", orgHandler.GetOrganization)
+
+		admin := orgRoutes.Group("/users", middleware.RequireRole(models.RoleAdmin))
+		admin.POST("", orgHandler.CreateMember)
+		admin.PATCH("/:id", orgHandler.UpdateMemberRole)
+		admin.DELETE("/:id", orgHandler.DeleteMember)
+	}
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"GoBlast/internal/api/handlers"
+	"GoBlast/internal/api/middleware"
+	"GoBlast/pkg/storage/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupReportRoutes(router *gin.RouterGroup, reportHandler *handlers.ReportHandler) {
+	// Отчёты строятся по задачам: API-ключу достаточно scope tasks:read
+	router.GET("/reports", middleware.RequireScope(models.ScopeTasksRead), reportHandler.GetReport)
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"GoBlast/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupSubscriberRoutes(router *gin.RouterGroup, subscriberHandler *handlers.SubscriberHandler) {
+	router.GET("/subscribers", subscriberHandler.ListSubscribers)
+	router.PUT("/subscribers/mode", subscriberHandler.SetUpdatesMode)
+}
+
+func SetupWebhookRoutes(router *gin.RouterGroup, subscriberHandler *handlers.SubscriberHandler) {
+	router.POST("/webhook/:user_id", subscriberHandler.Webhook)
+	router.POST("/webhook/bots/:bot_id", subscriberHandler.BotWebhook)
+}
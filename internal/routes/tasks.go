@@ -2,10 +2,23 @@ package routes
 
 import (
 	"GoBlast/internal/api/handlers"
+	"GoBlast/internal/api/middleware"
+	"GoBlast/pkg/storage/models"
+
 	"github.com/gin-gonic/gin"
 )
 
 func SetupTaskRoutes(router *gin.RouterGroup, taskHandler *handlers.TaskHandler) {
-	router.POST("/tasks", taskHandler.CreateTask)
-	router.GET("/tasks/:id", taskHandler.GetTask)
+	// Scopes проверяются только у API-ключей
+	read := middleware.RequireScope(models.ScopeTasksRead)
+	write := middleware.RequireScope(models.ScopeTasksWrite)
+
+	router.POST("/tasks", write, taskHandler.CreateTask)
+	router.GET("/tasks/:id", read, taskHandler.GetTask)
+	router.GET("/tasks/:id/history", read, taskHandler.GetTaskHistory)
+	router.POST("/tasks/:id/edit", write, taskHandler.EditTask)
+	router.POST("/tasks/:id/recall", write, taskHandler.RecallTask)
+	router.POST("/tasks/:id/cancel", write, taskHandler.CancelTask)
+	router.POST("/tasks/:id/winner", write, taskHandler.SendWinner)
+	router.POST("/tasks/:id/recipients", write, taskHandler.UploadRecipients)
 }
@@ -0,0 +1,168 @@
+package sessions
+
+import (
+	"GoBlast/pkg/storage/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefreshTokenTTL — срок жизни refresh-токена
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidRefresh — refresh-токен неизвестен или истёк
+	ErrInvalidRefresh = errors.New("invalid refresh token")
+	// ErrRefreshReused — предъявлен уже заменённый refresh-токен: вероятна утечка,
+	// поэтому все сессии пользователя отзываются
+	ErrRefreshReused = errors.New("refresh token reuse detected")
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Issue выпускает refresh-токен для новой сессии
+func (r *SessionRepository) Issue(userID uint, accessJTI string) (string, error) {
+	raw, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	err = r.db.Create(&models.RefreshToken{
+		UserID:    userID,
+		Hash:      hashToken(raw),
+		AccessJTI: accessJTI,
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}).Error
+	return raw, err
+}
+
+// Lookup находит действующий refresh-токен. Повторное предъявление
+// отозванного токена отзывает все сессии пользователя.
+func (r *SessionRepository) Lookup(raw string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.Where("hash = ?", hashToken(raw)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefresh
+		}
+		return nil, err
+	}
+	if token.RevokedAt != nil {
+		if token.ReplacedBy != nil {
+			if err := r.RevokeAll(token.UserID); err != nil {
+				return nil, err
+			}
+			return nil, ErrRefreshReused
+		}
+		return nil, ErrInvalidRefresh
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidRefresh
+	}
+	return &token, nil
+}
+
+// Rotate отзывает refresh-токен old и выпускает ему замену.
+// Если old уже заменён параллельным запросом, возвращает ErrRefreshReused.
+func (r *SessionRepository) Rotate(old *models.RefreshToken, accessJTI string) (string, error) {
+	raw, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, old.ID).Error; err != nil {
+			return err
+		}
+		if current.RevokedAt != nil {
+			return ErrRefreshReused
+		}
+
+		next := &models.RefreshToken{
+			UserID:    old.UserID,
+			Hash:      hashToken(raw),
+			AccessJTI: accessJTI,
+			ExpiresAt: time.Now().Add(RefreshTokenTTL),
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		return tx.Model(&current).Updates(map[string]interface{}{
+			"revoked_at":  time.Now(),
+			"replaced_by": next.ID,
+		}).Error
+	})
+	return raw, err
+}
+
+// Logout завершает сессию: отзывает access-токен jti и выданный с ним refresh-токен
+func (r *SessionRepository) Logout(userID uint, jti string, accessExpiresAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RevokedToken{JTI: jti, ExpiresAt: accessExpiresAt}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND access_jti = ? AND revoked_at IS NULL", userID, jti).
+			Update("revoked_at", time.Now()).Error
+	})
+}
+
+// RevokeAll завершает все сессии пользователя: access-токены, выпущенные до этого момента,
+// перестают приниматься, а refresh-токены отзываются
+func (r *SessionRepository) RevokeAll(userID uint) error {
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AuthUser{}).
+			Where("id = ?", userID).
+			Update("sessions_revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// IsRevoked сообщает, отозван ли access-токен: по jti или через "выйти везде".
+// iat токена хранится с точностью до секунды, поэтому и момент "выйти везде" сравнивается
+// в секундах: токен нового входа, выпущенный в ту же секунду, остаётся действительным.
+func (r *SessionRepository) IsRevoked(jti string, userID uint, issuedAt int64) (bool, error) {
+	var revoked bool
+	err := r.db.Raw(`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?)
+		OR EXISTS (SELECT 1 FROM auth_users WHERE id = ? AND date_trunc('second', sessions_revoked_at) > to_timestamp(?))`,
+		jti, userID, issuedAt).Scan(&revoked).Error
+	return revoked, err
+}
+
+// PurgeExpired удаляет записи, которые уже не влияют на проверку токенов
+func (r *SessionRepository) PurgeExpired() error {
+	now := time.Now()
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error
+}
@@ -0,0 +1,32 @@
+package sessions
+
+import (
+	"GoBlast/pkg/storage/db/dbtest"
+	"GoBlast/pkg/storage/models"
+	"testing"
+	"time"
+)
+
+func TestIsRevokedAfterRevokeAll(t *testing.T) {
+	conn := dbtest.Open(t)
+	repo := NewSessionRepository(conn)
+
+	user := &models.AuthUser{Username: "alice"}
+	if err := conn.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now().Add(-2 * time.Second).Unix()
+	if err := repo.RevokeAll(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	// Вход сразу после "выйти везде" попадает в ту же секунду
+	loginAfter := time.Now().Unix()
+
+	if revoked, err := repo.IsRevoked("old", user.ID, before); err != nil || !revoked {
+		t.Fatalf("token issued before RevokeAll: revoked = %v, %v", revoked, err)
+	}
+	if revoked, err := repo.IsRevoked("new", user.ID, loginAfter); err != nil || revoked {
+		t.Fatalf("token issued right after RevokeAll: revoked = %v, %v", revoked, err)
+	}
+}
@@ -0,0 +1,83 @@
+package subscribers
+
+import (
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/storage/models"
+	"strings"
+
+	"go.uber.org/zap"
+	tele "gopkg.in/telebot.v4"
+)
+
+// AllowedUpdates — типы обновлений, которые нужны для учёта подписчиков
+var AllowedUpdates = []string{"message", "my_chat_member"}
+
+// Ingest разбирает обновление Telegram и учитывает подписчика бота из /api/bots botID
+// или, если botID = 0, бота по умолчанию аккаунта userID:
+// /start — активен, /stop — остановил, блокировка/разблокировка бота — по my_chat_member.
+// Обновления, не относящиеся к личным чатам, игнорируются.
+func (r *SubscriberRepository) Ingest(userID, botID uint, upd tele.Update) error {
+	sub := subscriberFromUpdate(upd)
+	if sub == nil {
+		return nil
+	}
+	sub.UserID = userID
+	sub.BotID = botID
+
+	logger.Log.Info("[Subscribers] Обновление статуса подписчика",
+		zap.Uint("user_id", userID),
+		zap.Uint("bot_id", botID),
+		zap.Int64("chat_id", sub.ChatID),
+		zap.String("status", sub.Status))
+
+	return r.Upsert(sub)
+}
+
+func subscriberFromUpdate(upd tele.Update) *models.Subscriber {
+	if m := upd.Message; m != nil && m.Private() && m.Sender != nil {
+		var status string
+		switch command(m.Text) {
+		case "/start":
+			status = models.SubscriberActive
+		case "/stop":
+			status = models.SubscriberStopped
+		default:
+			return nil
+		}
+		return &models.Subscriber{
+			ChatID:       m.Chat.ID,
+			Username:     m.Sender.Username,
+			FirstName:    m.Sender.FirstName,
+			LanguageCode: m.Sender.LanguageCode,
+			Status:       status,
+		}
+	}
+
+	if cm := upd.MyChatMember; cm != nil && cm.Chat != nil && cm.Chat.Type == tele.ChatPrivate && cm.NewChatMember != nil {
+		status := models.SubscriberActive
+		if cm.NewChatMember.Role == tele.Kicked {
+			status = models.SubscriberBlocked
+		}
+		sub := &models.Subscriber{ChatID: cm.Chat.ID, Status: status}
+		if cm.Sender != nil {
+			sub.Username = cm.Sender.Username
+			sub.FirstName = cm.Sender.FirstName
+			sub.LanguageCode = cm.Sender.LanguageCode
+		}
+		return sub
+	}
+
+	return nil
+}
+
+// command возвращает команду из текста сообщения: "/start payload" и "/start@bot" -> "/start"
+func command(text string) string {
+	if !strings.HasPrefix(text, "/") {
+		return ""
+	}
+	cmd := strings.Fields(text)[0]
+	if i := strings.Index(cmd, "@"); i >= 0 {
+		cmd = cmd[:i]
+	}
+	return cmd
+}
@@ -0,0 +1,70 @@
+package subscribers
+
+import (
+	"GoBlast/pkg/storage/models"
+	"testing"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestSubscriberFromUpdate(t *testing.T) {
+	private := &tele.Chat{ID: 42, Type: tele.ChatPrivate}
+	sender := &tele.User{ID: 42, Username: "sokrat", FirstName: "Sokrat", LanguageCode: "ru"}
+
+	tests := []struct {
+		name   string
+		update tele.Update
+		status string
+	}{
+		{
+			name:   "start with payload",
+			update: tele.Update{Message: &tele.Message{Chat: private, Sender: sender, Text: "/start promo"}},
+			status: models.SubscriberActive,
+		},
+		{
+			name:   "stop with bot mention",
+			update: tele.Update{Message: &tele.Message{Chat: private, Sender: sender, Text: "/stop@goblast_bot"}},
+			status: models.SubscriberStopped,
+		},
+		{
+			name: "blocked",
+			update: tele.Update{MyChatMember: &tele.ChatMemberUpdate{
+				Chat: private, Sender: sender, NewChatMember: &tele.ChatMember{Role: tele.Kicked},
+			}},
+			status: models.SubscriberBlocked,
+		},
+		{
+			name: "unblocked",
+			update: tele.Update{MyChatMember: &tele.ChatMemberUpdate{
+				Chat: private, Sender: sender, NewChatMember: &tele.ChatMember{Role: tele.Member},
+			}},
+			status: models.SubscriberActive,
+		},
+	}
+
+	for _, tt := range tests {
+		sub := subscriberFromUpdate(tt.update)
+		if sub == nil {
+			t.Fatalf("%s: subscriber not recognised", tt.name)
+		}
+		if sub.Status != tt.status || sub.ChatID != 42 || sub.LanguageCode != "ru" {
+			t.Errorf("%s: got %+v", tt.name, sub)
+		}
+	}
+}
+
+func TestSubscriberFromUpdateIgnored(t *testing.T) {
+	group := &tele.Chat{ID: -100, Type: tele.ChatGroup}
+	sender := &tele.User{ID: 42}
+
+	ignored := []tele.Update{
+		{Message: &tele.Message{Chat: &tele.Chat{ID: 42, Type: tele.ChatPrivate}, Sender: sender, Text: "hello"}},
+		{Message: &tele.Message{Chat: group, Sender: sender, Text: "/start"}},
+		{MyChatMember: &tele.ChatMemberUpdate{Chat: group, NewChatMember: &tele.ChatMember{Role: tele.Kicked}}},
+	}
+	for i, upd := range ignored {
+		if sub := subscriberFromUpdate(upd); sub != nil {
+			t.Errorf("update %d: expected to be ignored, got %+v", i, sub)
+		}
+	}
+}
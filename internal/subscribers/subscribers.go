@@ -0,0 +1,151 @@
+package subscribers
+
+import (
+	"GoBlast/pkg/storage/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SubscriberRepository struct {
+	db *gorm.DB
+}
+
+func NewSubscriberRepository(db *gorm.DB) *SubscriberRepository {
+	return &SubscriberRepository{db: db}
+}
+
+// Upsert сохраняет подписчика бота с новым статусом.
+// Профиль (username, имя, язык) обновляется, только если он передан.
+func (r *SubscriberRepository) Upsert(sub *models.Subscriber) error {
+	if sub.BotID != 0 {
+		// Подписчики бота из /api/bots общие для всей организации
+		sub.UserID = 0
+	}
+	now := time.Now()
+	if sub.StartedAt.IsZero() {
+		sub.StartedAt = now
+	}
+	if sub.Status != models.SubscriberActive && sub.LeftAt == nil {
+		sub.LeftAt = &now
+	}
+
+	updates := clause.Set{
+		{Column: clause.Column{Name: "status"}, Value: gorm.Expr("excluded.status")},
+		{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+	}
+	if sub.Status == models.SubscriberActive {
+		updates = append(updates,
+			clause.Assignment{Column: clause.Column{Name: "left_at"}, Value: nil},
+			clause.Assignment{Column: clause.Column{Name: "started_at"}, Value: gorm.Expr(
+				"CASE WHEN subscribers.status = ? THEN subscribers.started_at ELSE excluded.started_at END", models.SubscriberActive)},
+		)
+	} else {
+		updates = append(updates, clause.Assignment{Column: clause.Column{Name: "left_at"}, Value: gorm.Expr("excluded.left_at")})
+	}
+	for _, column := range []string{"username", "first_name", "language_code"} {
+		updates = append(updates, clause.Assignment{Column: clause.Column{Name: column}, Value: gorm.Expr(
+			"COALESCE(NULLIF(excluded." + column + ", ''), subscribers." + column + ")")})
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "bot_id"}, {Name: "chat_id"}},
+		DoUpdates: updates,
+	}).Create(sub).Error
+}
+
+// MarkBlocked отмечает, что получатель заблокировал бота (по ошибке 403 при отправке).
+// botID — бот из /api/bots, 0 — бот по умолчанию аккаунта userID.
+func (r *SubscriberRepository) MarkBlocked(userID, botID uint, chatID int64) error {
+	return r.Upsert(&models.Subscriber{UserID: userID, BotID: botID, ChatID: chatID, Status: models.SubscriberBlocked})
+}
+
+// ofBot ограничивает запрос подписчиками одного бота: бота из /api/bots botID
+// или, если botID = 0, бота по умолчанию аккаунта userID
+func ofBot(query *gorm.DB, userID, botID uint) *gorm.DB {
+	if botID != 0 {
+		return query.Where("bot_id = ?", botID)
+	}
+	return query.Where("bot_id = 0 AND user_id = ?", userID)
+}
+
+// owned ограничивает запрос подписчиками ботов пользователя и его организации: ботов
+// по умолчанию участников и ботов организации из /api/bots (orgID 0 — только своими)
+func owned(query *gorm.DB, userID, orgID uint) *gorm.DB {
+	if orgID != 0 {
+		return query.Where("((bot_id = 0 AND (user_id = ? OR user_id IN (SELECT id FROM auth_users WHERE org_id = ?)))"+
+			" OR bot_id IN (SELECT id FROM bots WHERE org_id = ?))", userID, orgID, orgID)
+	}
+	return query.Where("bot_id = 0 AND user_id = ?", userID)
+}
+
+// List возвращает подписчиков ботов пользователя и его организации, при необходимости
+// с фильтром по статусу
+func (r *SubscriberRepository) List(userID, orgID uint, status string, limit, offset int) ([]models.Subscriber, error) {
+	query := owned(r.db, userID, orgID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var list []models.Subscriber
+	err := query.Order("id").Limit(limit).Offset(offset).Find(&list).Error
+	return list, err
+}
+
+// CountByStatus возвращает количество подписчиков ботов пользователя и его организации по статусам
+func (r *SubscriberRepository) CountByStatus(userID, orgID uint) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := owned(r.db.Model(&models.Subscriber{}).Select("status, COUNT(*) AS count"), userID, orgID).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *SubscriberRepository) activeQuery(userID, botID uint, languageCode string) *gorm.DB {
+	query := ofBot(r.db.Model(&models.Subscriber{}), userID, botID).Where("status = ?", models.SubscriberActive)
+	if languageCode != "" {
+		query = query.Where("language_code = ?", languageCode)
+	}
+	return query
+}
+
+// CountActive считает активных подписчиков бота (с фильтром по языку)
+func (r *SubscriberRepository) CountActive(userID, botID uint, languageCode string) (int64, error) {
+	var count int64
+	err := r.activeQuery(userID, botID, languageCode).Count(&count).Error
+	return count, err
+}
+
+// SubscribersPage возвращает следующую страницу активных подписчиков бота после afterID
+func (r *SubscriberRepository) SubscribersPage(userID, botID uint, languageCode string, afterID uint, limit int) ([]models.Subscriber, error) {
+	var page []models.Subscriber
+	err := r.activeQuery(userID, botID, languageCode).Where("id > ?", afterID).Order("id").Limit(limit).Find(&page).Error
+	return page, err
+}
+
+// SuppressedAmong возвращает тех из chatIDs, кто остановил или заблокировал этого бота
+func (r *SubscriberRepository) SuppressedAmong(userID, botID uint, chatIDs []int64) (map[int64]bool, error) {
+	suppressed := make(map[int64]bool)
+	if len(chatIDs) == 0 {
+		return suppressed, nil
+	}
+	var ids []int64
+	if err := ofBot(r.db.Model(&models.Subscriber{}), userID, botID).
+		Where("status <> ? AND chat_id IN ?", models.SubscriberActive, chatIDs).
+		Pluck("chat_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		suppressed[id] = true
+	}
+	return suppressed, nil
+}
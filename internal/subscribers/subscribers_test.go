@@ -0,0 +1,46 @@
+package subscribers
+
+import (
+	"GoBlast/pkg/storage/db/dbtest"
+	"GoBlast/pkg/storage/models"
+	"testing"
+)
+
+func TestSubscribersArePerBot(t *testing.T) {
+	conn := dbtest.Open(t)
+	repo := NewSubscriberRepository(conn)
+
+	// Получатель 42 подписан и на бота по умолчанию аккаунта 1, и на бота 5
+	for _, botID := range []uint{0, 5} {
+		if err := repo.Upsert(&models.Subscriber{UserID: 1, BotID: botID, ChatID: 42, Status: models.SubscriberActive}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 403 от бота 5 не касается бота по умолчанию
+	if err := repo.MarkBlocked(1, 5, 42); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := repo.SuppressedAmong(1, 5, []int64{42}); err != nil || !got[42] {
+		t.Fatalf("SuppressedAmong(bot 5) = %v, %v, want 42 suppressed", got, err)
+	}
+	if got, err := repo.SuppressedAmong(1, 0, []int64{42}); err != nil || got[42] {
+		t.Fatalf("SuppressedAmong(default bot) = %v, %v, want none", got, err)
+	}
+
+	if n, err := repo.CountActive(1, 0, ""); err != nil || n != 1 {
+		t.Fatalf("CountActive(default bot) = %d, %v, want 1", n, err)
+	}
+	if n, err := repo.CountActive(1, 5, ""); err != nil || n != 0 {
+		t.Fatalf("CountActive(bot 5) = %d, %v, want 0", n, err)
+	}
+
+	// Подписчики бота 5 общие: автор задачи не важен
+	if err := repo.Upsert(&models.Subscriber{UserID: 2, BotID: 5, ChatID: 43, Status: models.SubscriberActive}); err != nil {
+		t.Fatal(err)
+	}
+	page, err := repo.SubscribersPage(3, 5, "", 0, 10)
+	if err != nil || len(page) != 1 || page[0].ChatID != 43 || page[0].UserID != 0 {
+		t.Fatalf("SubscribersPage(bot 5) = %+v, %v", page, err)
+	}
+}
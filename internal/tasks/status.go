@@ -0,0 +1,216 @@
+package tasks
+
+import (
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Кто меняет статус задачи (помимо пользователей — см. UserActor)
+const (
+	ActorWorker = "worker" // воркер рассылки
+	ActorSystem = "system" // отключение бота и другие автоматические действия
+)
+
+// UserActor — пользователь, изменивший статус задачи через API
+func UserActor(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+var (
+	// ErrInvalidTransition — переход между статусами не предусмотрен автоматом
+	ErrInvalidTransition = errors.New("invalid task status transition")
+	// ErrStatusConflict — статус задачи уже изменён параллельным запросом
+	ErrStatusConflict = errors.New("task status was changed concurrently")
+)
+
+// TransitionError — недопустимый переход; errors.Is(err, ErrInvalidTransition) == true
+type TransitionError struct {
+	From, To models.TaskStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: %s -> %s", ErrInvalidTransition, e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool { return target == ErrInvalidTransition }
+
+// StatusChange — кто меняет статус, почему и, если есть, итоговая статистика
+type StatusChange struct {
+	Actor  string
+	Reason string        // пишется в status_reason задачи и в историю
+	Stats  *models.Stats // nil — статистика не меняется
+}
+
+// abortableStatuses — статусы задач, которые прерываются без участия воркера.
+// Задачи в running прерывает сам воркер при остановке.
+var abortableStatuses = []models.TaskStatus{
+	models.TaskDraft, models.TaskScheduled, models.TaskQueued,
+}
+
+// SaveTask сохраняет новую задачу (в статусе draft или scheduled) и первую запись истории.
+func (r *TasksRepository) SaveTask(task *models.Task, actor string) error {
+	return r.SaveTaskWith(task, actor, nil)
+}
+
+// SaveTaskWith сохраняет задачу, как SaveTask, но сначала вызывает prepare в той же
+// транзакции: то, что prepare записал в БД, откатывается, если задачу сохранить не удалось.
+func (r *TasksRepository) SaveTaskWith(task *models.Task, actor string, prepare func(tx *gorm.DB) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if prepare != nil {
+			if err := prepare(tx); err != nil {
+				return err
+			}
+		}
+		return createTask(tx, task, actor)
+	})
+}
+
+// createTask сохраняет новую задачу и первую запись истории в транзакции tx
+func createTask(tx *gorm.DB, task *models.Task, actor string) error {
+	if task.Status != models.TaskDraft && task.Status != models.TaskScheduled {
+		return &TransitionError{To: task.Status}
+	}
+	if err := tx.Create(task).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.TaskStatusHistory{
+		TaskID:   task.ID,
+		ToStatus: task.Status,
+		Actor:    actor,
+	}).Error
+}
+
+// ChangeStatus переводит задачу в статус to, если автомат это допускает.
+// Статус меняется только при неизменной версии задачи: проигравший параллельный
+// запрос получает ErrStatusConflict.
+func (r *TasksRepository) ChangeStatus(taskID string, to models.TaskStatus, change StatusChange) error {
+	return r.changeStatus(taskID, nil, to, change)
+}
+
+// TransitionStatus меняет статус задачи с from на to, только если он ещё from.
+// Возвращает false, если статус уже изменён другим запросом.
+func (r *TasksRepository) TransitionStatus(taskID string, from, to models.TaskStatus, actor string) (bool, error) {
+	err := r.changeStatus(taskID, &from, to, StatusChange{Actor: actor})
+	if errors.Is(err, ErrStatusConflict) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *TasksRepository) changeStatus(taskID string, from *models.TaskStatus, to models.TaskStatus, change StatusChange) error {
+	fields := map[string]interface{}{
+		"status":  to,
+		"version": gorm.Expr("version + 1"),
+	}
+	if change.Reason != "" {
+		fields["status_reason"] = change.Reason
+	}
+	if change.Stats != nil {
+		fields["stats"] = change.Stats
+	}
+
+	var current models.Task
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id", "status", "version").First(&current, "id = ?", taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("задача %s не найдена", taskID)
+			}
+			return err
+		}
+		if from != nil && current.Status != *from {
+			return ErrStatusConflict
+		}
+		if !current.Status.CanTransitionTo(to) {
+			return &TransitionError{From: current.Status, To: to}
+		}
+
+		res := tx.Model(&models.Task{}).
+			Where("id = ? AND version = ?", taskID, current.Version).
+			Updates(fields)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return ErrStatusConflict
+		}
+		return tx.Create(&models.TaskStatusHistory{
+			TaskID:     taskID,
+			FromStatus: current.Status,
+			ToStatus:   to,
+			Actor:      change.Actor,
+			Reason:     change.Reason,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Info("Статус задачи изменён",
+		zap.String("task_id", taskID),
+		zap.String("from", string(current.Status)),
+		zap.String("to", string(to)),
+		zap.String("actor", change.Actor))
+	return nil
+}
+
+// AbortBotTasks прерывает ещё не начатые задачи бота botID
+// (или, при botID = 0, бота по умолчанию пользователя userID)
+func (r *TasksRepository) AbortBotTasks(userID, botID uint, reason string) (int64, error) {
+	var aborted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status").
+			Where("status IN ?", abortableStatuses)
+		if botID != 0 {
+			query = query.Where("bot_id = ?", botID)
+		} else {
+			query = query.Where("bot_id IS NULL AND user_id = ?", userID)
+		}
+		var list []models.Task
+		if err := query.Find(&list).Error; err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return nil
+		}
+
+		ids := make([]string, 0, len(list))
+		history := make([]models.TaskStatusHistory, 0, len(list))
+		for _, t := range list {
+			ids = append(ids, t.ID)
+			history = append(history, models.TaskStatusHistory{
+				TaskID:     t.ID,
+				FromStatus: t.Status,
+				ToStatus:   models.TaskFailed,
+				Actor:      ActorSystem,
+				Reason:     reason,
+			})
+		}
+		res := tx.Model(&models.Task{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":        models.TaskFailed,
+			"status_reason": reason,
+			"version":       gorm.Expr("version + 1"),
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		aborted = res.RowsAffected
+		return tx.Create(&history).Error
+	})
+	return aborted, err
+}
+
+// StatusHistory возвращает смены статуса задачи в хронологическом порядке
+func (r *TasksRepository) StatusHistory(taskID string) ([]models.TaskStatusHistory, error) {
+	var history []models.TaskStatusHistory
+	if err := r.db.Where("task_id = ?", taskID).Order("created_at, id").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -1,15 +1,14 @@
 package tasks
 
 import (
-	"GoBlast/pkg/logger"
 	"GoBlast/pkg/queue"
 	"GoBlast/pkg/storage/models"
 	"encoding/json"
 	"errors"
 	"fmt"
 
-	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TasksRepository struct {
@@ -25,11 +24,6 @@ func (r *TasksRepository) SetNATSClient(nc *queue.NATSClient) {
 	r.natsClient = nc
 }
 
-// SaveTask сохраняет задачу (если нужно).
-func (r *TasksRepository) SaveTask(task *models.Task) error {
-	return r.db.Create(task).Error
-}
-
 // GetTaskByID возвращает задачу по ID (если нужно).
 func (r *TasksRepository) GetTaskByID(id string) (*models.Task, error) {
 	var t models.Task
@@ -42,54 +36,96 @@ func (r *TasksRepository) GetTaskByID(id string) (*models.Task, error) {
 	return &t, nil
 }
 
-// UpdateStatusAndStats удовлетворяет интерфейсу worker.WorkerRepo.
-// Принимает worker.Stats, сериализует в JSON, пишет в колонку `stats` таблицы tasks.
-func (r *TasksRepository) UpdateStatusAndStats(taskID, newStatus string, stats models.Stats) error {
-	statsBytes, err := json.Marshal(stats)
-	if err != nil {
-		return fmt.Errorf("marshal stats: %w", err)
-	}
-	statsStr := string(statsBytes)
+// SaveMessage сохраняет ID сообщения, отправленного получателю в рамках задачи.
+func (r *TasksRepository) SaveMessage(taskID string, recipient int64, messageID int) error {
+	return r.db.Create(&models.TaskMessage{
+		TaskID:    taskID,
+		Recipient: recipient,
+		MessageID: messageID,
+	}).Error
+}
 
-	// Обновляем поля в БД
-	if err := r.db.Model(&models.Task{}).
-		Where("id = ?", taskID).
-		Updates(map[string]interface{}{
-			"status": newStatus,
-			"stats":  statsStr,
-		}).Error; err != nil {
-		return err
+// DeliveredAmong возвращает получателей из recipients, которым сообщение задачи уже отправлено
+func (r *TasksRepository) DeliveredAmong(taskID string, recipients []int64) (map[int64]bool, error) {
+	delivered := make(map[int64]bool)
+	if len(recipients) == 0 {
+		return delivered, nil
 	}
+	var ids []int64
+	if err := r.db.Model(&models.TaskMessage{}).
+		Where("task_id = ? AND recipient IN ?", taskID, recipients).
+		Pluck("recipient", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		delivered[id] = true
+	}
+	return delivered, nil
+}
 
-	logger.Log.Info("Обновлены статус и статистика задачи в БД",
-		zap.String("task_id", taskID),
-		zap.String("status", newStatus))
+// ListMessages возвращает все сообщения, отправленные в рамках задачи, вместе с сообщениями
+// её рассылки победителя A/B-теста: edit/recall исходной задачи затрагивают и их.
+func (r *TasksRepository) ListMessages(taskID string) ([]models.TaskMessage, error) {
+	var messages []models.TaskMessage
+	winners := r.db.Model(&models.Task{}).Select("id").Where("parent_id = ? AND action = ?", taskID, "send")
+	if err := r.db.Where("task_id = ? OR task_id IN (?)", taskID, winners).Order("id").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
 
-	// Если требуется опубликовать в NATS
-	if newStatus == "complete" && r.natsClient != nil {
-		completeMsg, _ := json.Marshal(map[string]interface{}{
-			"task_id": taskID,
-			"status":  newStatus,
-			"stats":   stats,
-		})
-		if err := r.natsClient.Conn.Publish("tasks.complete", completeMsg); err != nil {
-			logger.Log.Error("Ошибка публикации в tasks.complete", zap.Error(err))
-		} else {
-			logger.Log.Info("Опубликовано событие tasks.complete в NATS",
-				zap.String("task_id", taskID))
+// SaveWinnerTask атомарно забирает отложенных получателей A/B-теста parentID и в той же
+// транзакции сохраняет задачу рассылки победителя, так что победитель уходит только
+// один раз. Если получатели уже забраны, задача не сохраняется и список пуст.
+func (r *TasksRepository) SaveWinnerTask(parentID string, task *models.Task, actor string) ([]int64, error) {
+	var holdout []int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var t models.Task
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "holdout").
+			First(&t, "id = ?", parentID).Error; err != nil {
+			return err
+		}
+		if t.Holdout == nil {
+			return nil
+		}
+		if err := json.Unmarshal([]byte(*t.Holdout), &holdout); err != nil {
+			return fmt.Errorf("unmarshal holdout: %w", err)
 		}
+		if len(holdout) == 0 {
+			return nil
+		}
+		if err := createTask(tx, task, actor); err != nil {
+			return err
+		}
+		return tx.Model(&models.Task{}).Where("id = ?", parentID).Update("holdout", nil).Error
+	})
+	if err != nil {
+		return nil, err
 	}
+	return holdout, nil
+}
 
-	return nil
+// RestoreHoldout возвращает отложенных получателей родителю, если рассылку
+// победителя не удалось передать воркеру
+func (r *TasksRepository) RestoreHoldout(parentID string, holdout []int64) error {
+	data, err := json.Marshal(holdout)
+	if err != nil {
+		return err
+	}
+	return r.db.Model(&models.Task{}).
+		Where("id = ? AND holdout IS NULL", parentID).
+		Update("holdout", string(data)).Error
 }
 
-func (r *TasksRepository) PublishCompleteStatus(taskID string, finalStats models.Stats) error {
+// PublishCompleteStatus сообщает в NATS о завершении задачи со статусом status
+func (r *TasksRepository) PublishCompleteStatus(taskID string, status models.TaskStatus, finalStats models.Stats) error {
 	if r.natsClient == nil {
 		return nil
 	}
 	completeMsg, _ := json.Marshal(map[string]interface{}{
 		"task_id": taskID,
-		"status":  "complete",
+		"status":  status,
 		"stats":   finalStats,
 	})
 	return r.natsClient.Conn.Publish("tasks.complete", completeMsg)
@@ -0,0 +1,42 @@
+package tasks
+
+import (
+	"GoBlast/pkg/storage/db/dbtest"
+	"GoBlast/pkg/storage/models"
+	"testing"
+)
+
+func TestListMessagesIncludesWinner(t *testing.T) {
+	conn := dbtest.Open(t)
+	repo := NewTasksRepository(conn)
+
+	parentID := "ab-test"
+	for _, task := range []*models.Task{
+		{ID: parentID, UserID: 1, Action: "send", MessageType: "text", Content: "{}", Status: models.TaskComplete},
+		{ID: "winner", UserID: 1, Action: "send", ParentID: &parentID, MessageType: "text", Content: "{}", Status: models.TaskComplete},
+		{ID: "edit", UserID: 1, Action: "edit", ParentID: &parentID, MessageType: "text", Content: "{}", Status: models.TaskComplete},
+	} {
+		if err := conn.Create(task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, m := range []models.TaskMessage{
+		{TaskID: parentID, Recipient: 1, MessageID: 10},
+		{TaskID: "winner", Recipient: 2, MessageID: 20},
+		{TaskID: "edit", Recipient: 1, MessageID: 10},
+	} {
+		if err := repo.SaveMessage(m.TaskID, m.Recipient, m.MessageID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Тестовая выборка и рассылка победителя, но не сообщения задач edit/recall
+	messages, err := repo.ListMessages(parentID)
+	if err != nil || len(messages) != 2 || messages[0].Recipient != 1 || messages[1].Recipient != 2 {
+		t.Fatalf("ListMessages(parent) = %+v, %v", messages, err)
+	}
+	messages, err = repo.ListMessages("winner")
+	if err != nil || len(messages) != 1 || messages[0].Recipient != 2 {
+		t.Fatalf("ListMessages(winner) = %+v, %v", messages, err)
+	}
+}
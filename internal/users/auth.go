@@ -31,3 +31,113 @@ func (r *AuthUserRepository) FindByID(id uint) (*models.AuthUser, error) {
 	}
 	return &user, nil
 }
+
+// FindByUpdatesMode возвращает пользователей, чьи боты получают обновления в режиме mode
+func (r *AuthUserRepository) FindByUpdatesMode(mode string) ([]models.AuthUser, error) {
+	var list []models.AuthUser
+	err := r.db.Where("updates_mode = ?", mode).Find(&list).Error
+	return list, err
+}
+
+// FindByBotTelegramID возвращает пользователей, у которых бот по умолчанию имеет данный Telegram ID
+func (r *AuthUserRepository) FindByBotTelegramID(telegramID int64) ([]models.AuthUser, error) {
+	var list []models.AuthUser
+	err := r.db.Where("bot_telegram_id = ?", telegramID).Find(&list).Error
+	return list, err
+}
+
+// FindWithActiveDefaultBot возвращает пользователей с действующим ботом по умолчанию
+func (r *AuthUserRepository) FindWithActiveDefaultBot() ([]models.AuthUser, error) {
+	var list []models.AuthUser
+	err := r.db.Where("token <> '' AND bot_status = ?", models.BotActive).Find(&list).Error
+	return list, err
+}
+
+// MarkDefaultBotUnauthorized отмечает, что токен бота по умолчанию отклонён Telegram
+func (r *AuthUserRepository) MarkDefaultBotUnauthorized(id uint) error {
+	return r.db.Model(&models.AuthUser{}).Where("id = ?", id).Update("bot_status", models.BotUnauthorized).Error
+}
+
+// UpdateUpdatesMode меняет режим получения обновлений бота
+func (r *AuthUserRepository) UpdateUpdatesMode(id uint, mode, webhookSecret string) error {
+	return r.db.Model(&models.AuthUser{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"updates_mode":   mode,
+		"webhook_secret": webhookSecret,
+	}).Error
+}
+
+// CreateWithOrganization создаёт организацию и её первого пользователя (администратора)
+func (r *AuthUserRepository) CreateWithOrganization(org *models.Organization, user *models.AuthUser) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		user.OrgID = &org.ID
+		user.Role = models.RoleAdmin
+		return tx.Create(user).Error
+	})
+}
+
+// EnsureOrganization заводит личную организацию аккаунту, созданному до появления организаций,
+// и переносит в неё его ботов
+func (r *AuthUserRepository) EnsureOrganization(user *models.AuthUser) error {
+	if user.OrgID != nil {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		org := &models.Organization{Name: user.Username}
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"org_id": org.ID,
+			"role":   models.RoleAdmin,
+		}).Error; err != nil {
+			return err
+		}
+		user.OrgID = &org.ID
+		user.Role = models.RoleAdmin
+		return tx.Model(&models.Bot{}).
+			Where("user_id = ? AND org_id = 0", user.ID).
+			Update("org_id", org.ID).Error
+	})
+}
+
+// FindOrganization возвращает организацию по ID
+func (r *AuthUserRepository) FindOrganization(id uint) (*models.Organization, error) {
+	var org models.Organization
+	if err := r.db.First(&org, id).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListByOrganization возвращает пользователей организации
+func (r *AuthUserRepository) ListByOrganization(orgID uint) ([]models.AuthUser, error) {
+	var list []models.AuthUser
+	err := r.db.Where("org_id = ?", orgID).Order("id").Find(&list).Error
+	return list, err
+}
+
+// UpdatePassword сохраняет новый хеш пароля
+func (r *AuthUserRepository) UpdatePassword(id uint, passwordHash string) error {
+	return r.db.Model(&models.AuthUser{}).Where("id = ?", id).Update("password_hash", passwordHash).Error
+}
+
+// UpdateRole меняет роль пользователя в организации
+func (r *AuthUserRepository) UpdateRole(id uint, role string) error {
+	return r.db.Model(&models.AuthUser{}).Where("id = ?", id).Update("role", role).Error
+}
+
+// UpdateTOTP сохраняет секрет TOTP и признак того, что второй фактор включён
+func (r *AuthUserRepository) UpdateTOTP(id uint, secret string, enabled bool) error {
+	return r.db.Model(&models.AuthUser{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"totp_secret":  secret,
+		"totp_enabled": enabled,
+	}).Error
+}
+
+// Delete удаляет пользователя организации
+func (r *AuthUserRepository) Delete(orgID, id uint) error {
+	return r.db.Where("id = ? AND org_id = ?", id, orgID).Delete(&models.AuthUser{}).Error
+}
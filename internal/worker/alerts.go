@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"GoBlast/configs"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/metrics"
+	"GoBlast/pkg/notify"
+	"GoBlast/pkg/queue"
+	"GoBlast/pkg/storage/models"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SubjectDLQ — subject, куда попадают сообщения задач, которые воркер не смог принять
+const SubjectDLQ = "tasks.dlq"
+
+var (
+	alerts     *notify.Dispatcher // nil — оповещения только пишутся в лог
+	alertRules configs.AlertRulesConfig
+	dlq        dlqTracker
+)
+
+// SetAlerts подключает доставку оповещений и правила, по которым они формируются
+func SetAlerts(dispatcher *notify.Dispatcher, rules configs.AlertRulesConfig) {
+	alerts = dispatcher
+	alertRules = rules
+}
+
+// alertBotUnauthorized — бот отключён из-за отозванного токена
+func alertBotUnauthorized(ref botRef, reason string, aborted int64) {
+	if !alertRules.BotUnauthorized {
+		return
+	}
+	alerts.Send(notify.Alert{
+		Key:      "bot_unauthorized:" + ref.label(),
+		Rule:     "bot_unauthorized",
+		Severity: notify.SeverityCritical,
+		Title:    fmt.Sprintf("Бот %s отключён", ref.label()),
+		Message:  fmt.Sprintf("%s\nПрервано задач: %d", reason, aborted),
+	})
+}
+
+// alertTaskFinished оповещает, если доля неудачных отправок задачи выше порога
+func alertTaskFinished(taskID string, st *models.Stats) {
+	if alertRules.TaskFailedPercent <= 0 {
+		return
+	}
+	attempted := st.TotalSent + st.TotalFailed
+	if attempted == 0 || attempted < alertRules.TaskFailedMinRecipients {
+		return
+	}
+	percent := float64(st.TotalFailed) * 100 / float64(attempted)
+	if percent <= alertRules.TaskFailedPercent {
+		return
+	}
+	alerts.Send(notify.Alert{
+		Key:      "task_failed:" + taskID,
+		Rule:     "task_failed_percent",
+		Severity: notify.SeverityWarning,
+		Title:    fmt.Sprintf("Задача %s: %.1f%% отправок с ошибкой", taskID, percent),
+		Message: fmt.Sprintf("Отправлено: %d, ошибок: %d (порог %.1f%%)\nОшибки: %v",
+			st.TotalSent, st.TotalFailed, alertRules.TaskFailedPercent, st.ErrorCounts),
+	})
+}
+
+// deadLetter перекладывает непринятое сообщение задачи в SubjectDLQ
+// и оповещает, если за окно их накопилось больше порога
+func deadLetter(natsClient *queue.NATSClient, data []byte, reason string) {
+	payload, err := json.Marshal(map[string]string{
+		"reason": reason,
+		"data":   string(data),
+	})
+	if err == nil {
+		err = natsClient.Conn.Publish(SubjectDLQ, payload)
+	}
+	if err != nil {
+		logger.Log.Error("Ошибка публикации в "+SubjectDLQ, zap.Error(err))
+	}
+	metrics.DeadLetterCounter.Inc()
+
+	if alertRules.DLQThreshold <= 0 || alertRules.DLQWindow <= 0 {
+		return
+	}
+	if count := dlq.add(time.Now(), alertRules.DLQWindow); count >= alertRules.DLQThreshold {
+		alerts.Send(notify.Alert{
+			Key:      "dlq_growth",
+			Rule:     "dlq_growth",
+			Severity: notify.SeverityWarning,
+			Title:    fmt.Sprintf("В %s %d сообщений за %s", SubjectDLQ, count, alertRules.DLQWindow),
+			Message:  "Последняя причина: " + reason,
+		})
+	}
+}
+
+// dlqTracker считает сообщения DLQ в скользящем окне
+type dlqTracker struct {
+	mu    sync.Mutex
+	times []time.Time
+}
+
+// add учитывает сообщение и возвращает их число за последние window
+func (t *dlqTracker) add(now time.Time, window time.Duration) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.times = append(t.times, now)
+	cut := 0
+	for cut < len(t.times) && now.Sub(t.times[cut]) > window {
+		cut++
+	}
+	t.times = t.times[cut:]
+	return len(t.times)
+}
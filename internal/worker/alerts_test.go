@@ -0,0 +1,23 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDLQTrackerSlidingWindow(t *testing.T) {
+	var tr dlqTracker
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	window := 10 * time.Minute
+
+	for i := 0; i < 5; i++ {
+		if got := tr.add(start.Add(time.Duration(i)*time.Minute), window); got != i+1 {
+			t.Fatalf("add #%d = %d, want %d", i, got, i+1)
+		}
+	}
+
+	// Через 12 минут от начала первые две записи (0 и 1 мин) выпадают из окна
+	if got := tr.add(start.Add(12*time.Minute), window); got != 4 {
+		t.Fatalf("count after window = %d, want 4", got)
+	}
+}
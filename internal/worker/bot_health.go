@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"GoBlast/internal/bots"
+	"GoBlast/internal/users"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/metrics"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// BotHealthInterval — период проверки токенов ботов через getMe
+const BotHealthInterval = 15 * time.Minute
+
+// BotHealthChecker периодически вызывает getMe для действующих ботов.
+// Отклонённый токен отключает бота так же, как ответ 401 при рассылке;
+// результат проверки виден в API (status, last_checked_at) и в метриках.
+type BotHealthChecker struct {
+	interval time.Duration
+	manager  *BotManager
+	botRepo  *bots.BotRepository
+	userRepo *users.AuthUserRepository
+	stop     chan struct{}
+}
+
+func NewBotHealthChecker(db *gorm.DB, manager *BotManager, interval time.Duration) *BotHealthChecker {
+	return &BotHealthChecker{
+		interval: interval,
+		manager:  manager,
+		botRepo:  bots.NewBotRepository(db),
+		userRepo: users.NewAuthUserRepository(db),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start запускает проверки: первую сразу, дальше раз в interval
+func (hc *BotHealthChecker) Start() {
+	go func() {
+		ticker := time.NewTicker(hc.interval)
+		defer ticker.Stop()
+
+		for {
+			hc.checkAll()
+			select {
+			case <-hc.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Log.Info("[Health] Проверка ботов запущена", zap.Duration("interval", hc.interval))
+}
+
+// Stop останавливает проверки
+func (hc *BotHealthChecker) Stop() {
+	close(hc.stop)
+}
+
+func (hc *BotHealthChecker) checkAll() {
+	list, err := hc.botRepo.ListByStatus(models.BotActive)
+	if err != nil {
+		logger.Log.Error("[Health] Ошибка получения ботов", zap.Error(err))
+	}
+	for _, bot := range list {
+		checkErr := hc.check(botRef{BotID: bot.ID}, bot.Token)
+		if err := hc.botRepo.RecordCheck(bot.ID, time.Now(), checkErr); err != nil {
+			logger.Log.Error("[Health] Ошибка сохранения результата проверки",
+				zap.Uint("bot_id", bot.ID), zap.Error(err))
+		}
+	}
+
+	owners, err := hc.userRepo.FindWithActiveDefaultBot()
+	if err != nil {
+		logger.Log.Error("[Health] Ошибка получения ботов по умолчанию", zap.Error(err))
+	}
+	for _, user := range owners {
+		hc.check(botRef{UserID: user.ID}, user.Token)
+	}
+}
+
+// check проверяет один токен и возвращает описание сбоя связи с Telegram (пусто, если ответ получен)
+func (hc *BotHealthChecker) check(ref botRef, encryptedToken string) string {
+	token, err := bots.DecryptToken(encryptedToken)
+	if err != nil {
+		logger.Log.Error("[Health] Ошибка дешифрования токена", zap.String("bot", ref.label()), zap.Error(err))
+		return "failed to decrypt token"
+	}
+
+	_, err = bots.GetMe(token)
+	switch {
+	case err == nil:
+		metrics.BotHealthChecks.WithLabelValues("ok").Inc()
+		metrics.BotHealthy.WithLabelValues(ref.label()).Set(1)
+		return ""
+
+	case errors.Is(err, bots.ErrInvalidToken):
+		metrics.BotHealthChecks.WithLabelValues("unauthorized").Inc()
+		logger.Log.Warn("[Health] Токен бота отклонён Telegram", zap.String("bot", ref.label()), zap.Error(err))
+		hc.manager.DisableBot(ref, reasonBotUnauthorized)
+		return ""
+
+	default:
+		// Сбой сети или лимиты Telegram не означают, что токен отозван
+		metrics.BotHealthChecks.WithLabelValues("error").Inc()
+		logger.Log.Warn("[Health] Не удалось проверить бота", zap.String("bot", ref.label()), zap.Error(err))
+		return err.Error()
+	}
+}
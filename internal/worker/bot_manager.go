@@ -1,50 +1,443 @@
 package worker
 
 import (
+	"GoBlast/configs"
+	"GoBlast/internal/audiences"
+	"GoBlast/internal/bots"
+	"GoBlast/internal/subscribers"
 	"GoBlast/internal/tasks"
+	"GoBlast/internal/users"
 	"GoBlast/pkg/logger"
+	"GoBlast/pkg/metrics"
+	"GoBlast/pkg/ratelimit"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// workerRepo собирает репозитории, нужные воркеру, в один WorkerRepo
+type workerRepo struct {
+	*tasks.TasksRepository
+	*audiences.AudienceRepository
+	*subscribers.SubscriberRepository
+}
+
+// botRef указывает запись бота в БД: бота организации (BotID)
+// или бота по умолчанию пользователя (UserID при BotID = 0)
+type botRef struct {
+	UserID uint
+	BotID  uint
+}
+
+// refOf возвращает бота, от имени которого идёт задача
+func refOf(task TaskNATSMessage) botRef {
+	if task.BotID != 0 {
+		return botRef{BotID: task.BotID}
+	}
+	return botRef{UserID: task.UserID}
+}
+
+// label — значение метки bot в метриках
+func (r botRef) label() string {
+	if r.BotID != 0 {
+		return fmt.Sprintf("bot:%d", r.BotID)
+	}
+	return fmt.Sprintf("user:%d", r.UserID)
+}
+
+// Значения по умолчанию для жизненного цикла воркеров
+const (
+	defaultIdleTimeout = 10 * time.Minute
+	defaultMaxWorkers  = 200
+)
+
+const (
+	reasonWorkerIdle   = "worker evicted after idle timeout"
+	reasonTokenRotated = "bot token was replaced; create the task again to send it with the new token"
+	reasonLeaseLost    = "bot lease moved to another worker instance; sending continues there"
+	reasonShutdown     = "worker instance shut down; create the task again to resend"
+)
+
+// ErrWorkerStopped — воркер бота остановлен раньше, чем принял задачу (например, аренду
+// бота перехватил другой экземпляр); задачу нужно передать заново
+var ErrWorkerStopped = errors.New("bot worker stopped before accepting the task")
+
+// ErrNoCapacity — запущено maxWorkers воркеров и ни один не простаивает; задачу нужно повторить позже
+var ErrNoCapacity = errors.New("no free bot worker slots")
+
+// managedWorker — воркер бота и служебные данные менеджера о нём
+type managedWorker struct {
+	*Worker
+	ref     botRef
+	token   string // токен, с которым создан воркер
+	pending int    // задач, переданных в StartTask, но ещё не выложенных воркером
+}
+
+// BotManager держит по воркеру на бота (ключ — botRef.key(), а не токен),
+// останавливает простаивающие воркеры и ограничивает их общее число
 type BotManager struct {
-	mu      sync.Mutex
-	workers map[string]*Worker
+	mu          sync.Mutex
+	db          *gorm.DB
+	limiter     ratelimit.Limiter
+	cfg         configs.WorkerConfig // ограничения по умолчанию
+	idleTimeout time.Duration
+	maxWorkers  int
+	workers     map[string]*managedWorker
+	stop        chan struct{}
 }
 
-// NewBotManager возвращает новый менеджер ботов
-func NewBotManager() *BotManager {
-	return &BotManager{
-		workers: make(map[string]*Worker),
+// NewBotManager возвращает новый менеджер ботов; limiter задаёт бюджеты отправок всех ботов
+func NewBotManager(db *gorm.DB, limiter ratelimit.Limiter, cfg configs.WorkerConfig) *BotManager {
+	bm := &BotManager{
+		db:          db,
+		limiter:     limiter,
+		cfg:         cfg,
+		idleTimeout: cfg.IdleTimeout,
+		maxWorkers:  cfg.MaxActive,
+		workers:     make(map[string]*managedWorker),
+		stop:        make(chan struct{}),
+	}
+	if bm.idleTimeout <= 0 {
+		bm.idleTimeout = defaultIdleTimeout
 	}
+	if bm.maxWorkers <= 0 {
+		bm.maxWorkers = defaultMaxWorkers
+	}
+	return bm
 }
 
-func (bm *BotManager) StartTask(botToken string, natsMsg TaskNATSMessage, db *gorm.DB) {
+// Start запускает периодическое удаление простаивающих воркеров
+func (bm *BotManager) Start() {
+	interval := bm.idleTimeout / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-bm.stop:
+				return
+			case <-ticker.C:
+				bm.evictIdle()
+			}
+		}
+	}()
+	logger.Log.Info("[BotManager] Запущен",
+		zap.Duration("idle_timeout", bm.idleTimeout),
+		zap.Int("max_workers", bm.maxWorkers))
+}
+
+// Stop останавливает удаление простаивающих воркеров и все воркеры менеджера
+func (bm *BotManager) Stop() {
+	close(bm.stop)
+
 	bm.mu.Lock()
-	defer bm.mu.Unlock()
+	list := make([]*managedWorker, 0, len(bm.workers))
+	for key, mw := range bm.workers {
+		list = append(list, mw)
+		delete(bm.workers, key)
+	}
+	metrics.ActiveBotWorkers.Set(0)
+	bm.mu.Unlock()
 
-	worker, exists := bm.workers[botToken]
-	if !exists {
-		// Создаём repo
-		repo := tasks.NewTasksRepository(db)
+	for _, mw := range list {
+		mw.Stop(reasonShutdown)
+	}
+}
 
-		// Создаём воркер
-		w, err := NewWorker(botToken, 10, repo)
-		if err != nil {
-			logger.Log.Error("Ошибка создания воркера для бота", zap.Error(err))
+// StartTask передаёт задачу воркеру бота, при необходимости создавая его.
+// ErrNoCapacity — запущено maxWorkers воркеров и ни один не простаивает.
+// ErrWorkerStopped — воркер остановили раньше, чем он принял задачу.
+func (bm *BotManager) StartTask(botToken string, natsMsg TaskNATSMessage) error {
+	ref := refOf(natsMsg)
+
+	mw, err := bm.acquire(ref, botToken)
+	if errors.Is(err, ErrNoCapacity) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("create worker for %s: %w", ref.label(), err)
+	}
+
+	// Задача выкладывается без блокировки менеджера: воркер не удалят, пока pending > 0
+	accepted := mw.AddTask(natsMsg)
+
+	bm.mu.Lock()
+	mw.pending--
+	bm.mu.Unlock()
+
+	if !accepted {
+		return ErrWorkerStopped
+	}
+	return nil
+}
+
+// Handoff останавливает воркер бота с ключом key, аренду которого перехватил другой экземпляр,
+// и возвращает части его незавершённых задач для передачи новому владельцу
+func (bm *BotManager) Handoff(key string) []TaskNATSMessage {
+	bm.mu.Lock()
+	mw := bm.workers[key]
+	delete(bm.workers, key)
+	metrics.ActiveBotWorkers.Set(float64(len(bm.workers)))
+	bm.mu.Unlock()
+
+	if mw == nil {
+		return nil
+	}
+	return mw.Handoff(reasonLeaseLost)
+}
+
+// CancelTask прекращает рассылку отменённой задачи воркером, который её рассылает
+func (bm *BotManager) CancelTask(taskID string) {
+	bm.mu.Lock()
+	list := make([]*managedWorker, 0, len(bm.workers))
+	for _, mw := range bm.workers {
+		list = append(list, mw)
+	}
+	bm.mu.Unlock()
+
+	for _, mw := range list {
+		if mw.CancelTask(taskID) {
 			return
 		}
-		worker = w
+	}
+}
+
+// acquire возвращает воркер бота с увеличенным pending. Не ждёт освобождения места:
+// вызывается из обработчика NATS, поэтому при нехватке воркеров возвращает ErrNoCapacity.
+func (bm *BotManager) acquire(ref botRef, botToken string) (*managedWorker, error) {
+	key := ref.key()
+	limits := bm.limitsFor(ref)
 
-		// Сохраняем
-		bm.workers[botToken] = worker
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
 
-		// Запускаем
-		worker.Start()
+	mw := bm.workers[key]
+	if mw != nil && mw.token != botToken {
+		// Токен бота заменили: старый отозван, его задачи уже не отправить
+		delete(bm.workers, key)
+		go mw.Stop(reasonTokenRotated)
+		mw = nil
 	}
+	if mw == nil {
+		if len(bm.workers) >= bm.maxWorkers && !bm.evictOldestIdleLocked() {
+			logger.Log.Warn("[BotManager] Достигнут предел воркеров, задача будет повторена",
+				zap.String("bot", ref.label()),
+				zap.Int("max_workers", bm.maxWorkers))
+			return nil, ErrNoCapacity
+		}
+		created, err := bm.newWorkerLocked(ref, botToken, limits)
+		if err != nil {
+			return nil, err
+		}
+		mw = created
+	}
+	mw.pending++
+	return mw, nil
+}
+
+// newWorkerLocked создаёт и запускает воркер бота; вызывается под bm.mu
+func (bm *BotManager) newWorkerLocked(ref botRef, botToken string, limits bots.Limits) (*managedWorker, error) {
+	// Создаём repo
+	repo := workerRepo{
+		TasksRepository:      tasks.NewTasksRepository(bm.db),
+		AudienceRepository:   audiences.NewAudienceRepository(bm.db),
+		SubscriberRepository: subscribers.NewSubscriberRepository(bm.db),
+	}
+
+	// Создаём воркер
+	w, err := NewWorker(botToken, limits, repo, bm.limiter)
+	if err != nil {
+		return nil, err
+	}
+	w.metricsBot = ref.label()
+	mw := &managedWorker{Worker: w, ref: ref, token: botToken}
+
+	// Первый ответ 401 отключает бота целиком
+	w.onUnauthorized = func(reason string) {
+		bm.workerUnauthorized(mw, reason)
+	}
+
+	bm.workers[ref.key()] = mw
+	metrics.ActiveBotWorkers.Set(float64(len(bm.workers)))
+
+	// Запускаем
+	w.Start()
+	return mw, nil
+}
+
+// limitsFor возвращает ограничения бота: конфигурация с переопределениями из БД
+// (переопределения есть только у ботов организаций)
+func (bm *BotManager) limitsFor(ref botRef) bots.Limits {
+	if ref.BotID == 0 {
+		return bots.ResolveLimits(bm.cfg, nil)
+	}
+	override, err := bots.NewBotRepository(bm.db).GetLimit(ref.BotID)
+	if err != nil {
+		logger.Log.Error("[BotManager] Ошибка загрузки ограничений бота, используются значения по умолчанию",
+			zap.String("bot", ref.label()), zap.Error(err))
+	}
+	return bots.ResolveLimits(bm.cfg, override)
+}
+
+// ApplyLimits перечитывает ограничения бота botID и применяет их к его работающему воркеру
+func (bm *BotManager) ApplyLimits(botID uint) {
+	ref := botRef{BotID: botID}
+	limits := bm.limitsFor(ref)
+
+	bm.mu.Lock()
+	mw := bm.workers[ref.key()]
+	bm.mu.Unlock()
+
+	if mw != nil {
+		mw.SetLimits(limits)
+	}
+}
+
+// workerUnauthorized отключает бота, если получивший 401 воркер — текущий воркер бота
+// (а не заменённый после смены токена)
+func (bm *BotManager) workerUnauthorized(mw *managedWorker, reason string) {
+	bm.mu.Lock()
+	current := bm.workers[mw.ref.key()] == mw
+	bm.mu.Unlock()
+
+	if !current {
+		logger.Log.Info("[BotManager] Ответ 401 у заменённого воркера, бот не отключается",
+			zap.String("bot", mw.ref.label()))
+		return
+	}
+	bm.DisableBot(mw.ref, reason)
+}
+
+// evictIdle останавливает воркеры без задач дольше idleTimeout
+func (bm *BotManager) evictIdle() {
+	before := time.Now().Add(-bm.idleTimeout)
+
+	bm.mu.Lock()
+	var evicted []*managedWorker
+	for key, mw := range bm.workers {
+		if mw.pending == 0 && mw.idleSince(before) {
+			delete(bm.workers, key)
+			evicted = append(evicted, mw)
+		}
+	}
+	metrics.ActiveBotWorkers.Set(float64(len(bm.workers)))
+	bm.mu.Unlock()
+
+	for _, mw := range evicted {
+		mw.Stop(reasonWorkerIdle)
+		logger.Log.Info("[BotManager] Простаивающий воркер остановлен", zap.String("bot", mw.ref.label()))
+	}
+}
+
+// evictOldestIdleLocked освобождает место: останавливает воркер без задач,
+// дольше всех не получавший работы. Вызывается под bm.mu.
+func (bm *BotManager) evictOldestIdleLocked() bool {
+	now := time.Now()
+	var (
+		oldestKey string
+		oldest    *managedWorker
+		oldestAt  time.Time
+	)
+	for key, mw := range bm.workers {
+		if mw.pending != 0 || !mw.idleSince(now) {
+			continue
+		}
+		if at := mw.status().LastActive; oldest == nil || at.Before(oldestAt) {
+			oldestKey, oldest, oldestAt = key, mw, at
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+
+	delete(bm.workers, oldestKey)
+	metrics.ActiveBotWorkers.Set(float64(len(bm.workers)))
+	go oldest.Stop(reasonWorkerIdle)
+	logger.Log.Info("[BotManager] Воркер остановлен, чтобы освободить место",
+		zap.String("bot", oldest.ref.label()))
+	return true
+}
+
+// ManagerStatus — состояние воркеров одного экземпляра
+type ManagerStatus struct {
+	Instance      string         `json:"instance"`
+	ActiveWorkers int            `json:"active_workers"`
+	MaxWorkers    int            `json:"max_workers"`
+	IdleTimeout   string         `json:"idle_timeout"`
+	Workers       []WorkerStatus `json:"workers"`
+}
+
+// Status возвращает состояние всех воркеров менеджера
+func (bm *BotManager) Status() ManagerStatus {
+	bm.mu.Lock()
+	list := make([]*managedWorker, 0, len(bm.workers))
+	for _, mw := range bm.workers {
+		list = append(list, mw)
+	}
+	bm.mu.Unlock()
+
+	st := ManagerStatus{
+		ActiveWorkers: len(list),
+		MaxWorkers:    bm.maxWorkers,
+		IdleTimeout:   bm.idleTimeout.String(),
+		Workers:       make([]WorkerStatus, 0, len(list)),
+	}
+	for _, mw := range list {
+		ws := mw.status()
+		ws.Bot, ws.BotID, ws.UserID = mw.ref.label(), mw.ref.BotID, mw.ref.UserID
+		st.Workers = append(st.Workers, ws)
+	}
+	sort.Slice(st.Workers, func(i, j int) bool { return st.Workers[i].Bot < st.Workers[j].Bot })
+	return st
+}
+
+// DisableBot отключает бота с отозванным токеном: останавливает и убирает его воркер,
+// помечает бота в БД и прерывает его незавершённые задачи с причиной reason.
+func (bm *BotManager) DisableBot(ref botRef, reason string) {
+	bm.mu.Lock()
+	mw := bm.workers[ref.key()]
+	delete(bm.workers, ref.key())
+	metrics.ActiveBotWorkers.Set(float64(len(bm.workers)))
+	bm.mu.Unlock()
+
+	if mw != nil {
+		mw.Stop(reason)
+	}
+
+	botRepo := bots.NewBotRepository(bm.db)
+	userRepo := users.NewAuthUserRepository(bm.db)
+	taskRepo := tasks.NewTasksRepository(bm.db)
+
+	var err error
+	if ref.BotID != 0 {
+		err = botRepo.MarkUnauthorized(ref.BotID, reason)
+	} else {
+		err = userRepo.MarkDefaultBotUnauthorized(ref.UserID)
+	}
+	if err != nil {
+		logger.Log.Error("[BotManager] Ошибка отключения бота",
+			zap.String("bot", ref.label()), zap.Error(err))
+	}
+
+	aborted, err := taskRepo.AbortBotTasks(ref.UserID, ref.BotID, reason)
+	if err != nil {
+		logger.Log.Error("[BotManager] Ошибка прерывания задач бота",
+			zap.String("bot", ref.label()), zap.Error(err))
+	}
+
+	metrics.BotHealthy.WithLabelValues(ref.label()).Set(0)
+	metrics.BotsDisabledCounter.Inc()
 
-	// Добавляем задачу в воркер
-	worker.AddTask(natsMsg)
+	logger.Log.Warn("[BotManager] Бот отключён: токен отклонён Telegram",
+		zap.String("bot", ref.label()),
+		zap.Int64("aborted_tasks", aborted))
+	alertBotUnauthorized(ref, reason, aborted)
 }
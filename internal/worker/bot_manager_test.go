@@ -0,0 +1,303 @@
+package worker
+
+import (
+	"GoBlast/configs"
+	"GoBlast/internal/bots"
+	"GoBlast/internal/tasks"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/metrics"
+	"GoBlast/pkg/storage/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func testManagedWorker(ref botRef, lastActive time.Time) *managedWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &managedWorker{
+		Worker: &Worker{
+			stats:      make(map[string]*models.Stats),
+			enqueuing:  make(map[string]int),
+			chunks:     make(map[string]*chunkProgress),
+			sources:    make(map[string][]TaskNATSMessage),
+			cancelled:  make(map[string]int64),
+			quit:       make(chan struct{}),
+			ctx:        ctx,
+			cancel:     cancel,
+			lastActive: lastActive,
+		},
+		ref: ref,
+	}
+}
+
+func TestBotManagerEviction(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	bm := NewBotManager(nil, nil, configs.WorkerConfig{IdleTimeout: time.Minute, MaxActive: 3})
+	now := time.Now()
+
+	idle := testManagedWorker(botRef{BotID: 1}, now.Add(-2*time.Minute))
+	busy := testManagedWorker(botRef{BotID: 2}, now.Add(-2*time.Minute))
+	busy.stats["task"] = &models.Stats{ExpectedCount: 5, ProcessedCount: 2}
+	pending := testManagedWorker(botRef{BotID: 3}, now.Add(-2*time.Minute))
+	pending.pending = 1
+	recent := testManagedWorker(botRef{UserID: 7}, now)
+	for _, mw := range []*managedWorker{idle, busy, pending, recent} {
+		bm.workers[mw.ref.key()] = mw
+	}
+
+	bm.evictIdle()
+
+	if _, ok := bm.workers[idle.ref.key()]; ok {
+		t.Fatal("idle worker was not evicted")
+	}
+	if !idle.stopped {
+		t.Fatal("evicted worker was not stopped")
+	}
+	for _, mw := range []*managedWorker{busy, pending, recent} {
+		if _, ok := bm.workers[mw.ref.key()]; !ok {
+			t.Fatalf("worker %s must stay", mw.ref.label())
+		}
+	}
+
+	// При нехватке места освобождается воркер без задач, даже не простоявший idleTimeout
+	bm.mu.Lock()
+	freed := bm.evictOldestIdleLocked()
+	bm.mu.Unlock()
+	if !freed {
+		t.Fatal("expected a worker to be evicted for capacity")
+	}
+	if _, ok := bm.workers[recent.ref.key()]; ok {
+		t.Fatal("recent idle worker should be evicted for capacity")
+	}
+
+	st := bm.Status()
+	if st.ActiveWorkers != 2 || st.MaxWorkers != 3 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+	if st.Workers[0].Bot != "bot:2" || st.Workers[0].QueueDepth != 3 || st.Workers[0].InFlightTasks != 1 {
+		t.Fatalf("unexpected worker status: %+v", st.Workers[0])
+	}
+
+	// Место не освободить: новый бот не ждёт, а получает ErrNoCapacity
+	bm.maxWorkers = 2
+	if _, err := bm.acquire(botRef{UserID: 9}, "token"); !errors.Is(err, ErrNoCapacity) {
+		t.Fatalf("acquire at capacity = %v, want ErrNoCapacity", err)
+	}
+}
+
+func TestWorkerSetLimits(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	mw := testManagedWorker(botRef{BotID: 1}, time.Now())
+	w := mw.Worker
+	w.TaskChan = make(chan TaskItem)
+	w.limits = bots.Limits{Concurrency: 3, RateMedium: 10}
+	w.priority = "medium"
+
+	w.Start()
+	if w.NumWorkers != 3 {
+		t.Fatalf("NumWorkers = %d, want 3", w.NumWorkers)
+	}
+
+	w.SetLimits(bots.Limits{Concurrency: 1, RateHigh: 100, RateMedium: 50, RateLow: 5})
+	if w.NumWorkers != 1 {
+		t.Fatalf("NumWorkers = %d after scale down, want 1", w.NumWorkers)
+	}
+	w.mu.Lock()
+	rate := w.botRateLocked()
+	w.mu.Unlock()
+	if rate.Limit != 50 {
+		t.Fatalf("medium rate = %v, want 50", rate.Limit)
+	}
+
+	w.Stop("test")
+	w.WG.Wait()
+}
+
+func TestWorkerMetricsGauges(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	mw := testManagedWorker(botRef{BotID: 7}, time.Now())
+	w := mw.Worker
+	w.metricsBot = mw.ref.label()
+	w.TaskChan = make(chan TaskItem, 10)
+	w.limits = bots.Limits{Concurrency: 2}
+
+	w.Start()
+	if got := testutil.ToFloat64(metrics.WorkerSendGoroutines.WithLabelValues("bot:7")); got != 2 {
+		t.Fatalf("worker_send_goroutines = %v, want 2", got)
+	}
+
+	w.Stop("test")
+	w.WG.Wait()
+	// Удаление серии возвращает false, если её уже нет
+	if metrics.WorkerSendGoroutines.DeleteLabelValues("bot:7") {
+		t.Fatal("stopped worker must not export send goroutines")
+	}
+	if metrics.WorkerQueueDepth.DeleteLabelValues("bot:7") {
+		t.Fatal("stopped worker must not export queue depth")
+	}
+}
+
+// statusRepo запоминает смены статуса задач
+type statusRepo struct {
+	WorkerRepo
+	changes map[string]models.TaskStatus
+}
+
+func (r *statusRepo) ChangeStatus(taskID string, to models.TaskStatus, _ tasks.StatusChange) error {
+	r.changes[taskID] = to
+	return nil
+}
+
+func TestWorkerHandoff(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	mw := testManagedWorker(botRef{BotID: 9}, time.Now())
+	w := mw.Worker
+	repo := &statusRepo{changes: make(map[string]models.TaskStatus)}
+	w.Repo = repo
+	w.TaskChan = make(chan TaskItem, 10)
+	w.limits = bots.Limits{Concurrency: 2}
+
+	bm := NewBotManager(nil, nil, configs.WorkerConfig{})
+	bm.workers[mw.ref.key()] = mw
+
+	started := time.Now().Add(-time.Minute)
+	w.stats["t1"] = &models.Stats{ExpectedCount: 10, ProcessedCount: 4, StartTime: started}
+	w.sources["t1"] = []TaskNATSMessage{{TaskID: "t1", ChunkIndex: 0, MoreChunks: true}, {TaskID: "t1", ChunkIndex: 1}}
+	w.Start()
+
+	parts := bm.Handoff(mw.ref.key())
+	if len(parts) != 2 {
+		t.Fatalf("handed %d parts, want 2", len(parts))
+	}
+	for _, part := range parts {
+		if part.Handoffs != 1 || part.StartedAt == nil || !part.StartedAt.Equal(started) {
+			t.Fatalf("unexpected handed part: %+v", part)
+		}
+	}
+	if repo.changes["t1"] != models.TaskQueued {
+		t.Fatalf("task status = %q, want queued", repo.changes["t1"])
+	}
+	if _, ok := bm.workers[mw.ref.key()]; ok {
+		t.Fatal("handed off worker must be removed from the manager")
+	}
+	if w.AddTask(TaskNATSMessage{TaskID: "t2"}) {
+		t.Fatal("stopped worker must not accept tasks")
+	}
+	if parts := bm.Handoff(mw.ref.key()); parts != nil {
+		t.Fatalf("second handoff returned %v", parts)
+	}
+}
+
+func TestBotManagerStopStopsWorkers(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	repo := &statusRepo{changes: make(map[string]models.TaskStatus)}
+	bm := NewBotManager(nil, nil, configs.WorkerConfig{})
+	idle := testManagedWorker(botRef{BotID: 1}, time.Now())
+	busy := testManagedWorker(botRef{BotID: 2}, time.Now())
+	busy.Repo = repo
+	busy.stats["t1"] = &models.Stats{ExpectedCount: 5}
+	for _, mw := range []*managedWorker{idle, busy} {
+		bm.workers[mw.ref.key()] = mw
+	}
+
+	bm.Stop()
+
+	if !idle.stopped || !busy.stopped {
+		t.Fatal("manager stop must stop every worker")
+	}
+	if len(bm.workers) != 0 {
+		t.Fatalf("%d workers left after stop", len(bm.workers))
+	}
+	if repo.changes["t1"] != models.TaskFailed {
+		t.Fatalf("in-flight task status = %q, want failed", repo.changes["t1"])
+	}
+}
+
+// machineRepo хранит статусы задач и проверяет переходы, как TasksRepository
+type machineRepo struct {
+	WorkerRepo
+	status map[string]models.TaskStatus
+}
+
+func (r *machineRepo) ChangeStatus(taskID string, to models.TaskStatus, _ tasks.StatusChange) error {
+	from := r.status[taskID]
+	if !from.CanTransitionTo(to) {
+		return &tasks.TransitionError{From: from, To: to}
+	}
+	r.status[taskID] = to
+	return nil
+}
+
+func (r *machineRepo) PublishCompleteStatus(string, models.TaskStatus, models.Stats) error {
+	return nil
+}
+
+func TestWorkerFinishTaskLeftQueued(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	// markRunning не записал running: задача завершается через running, а не остаётся в queued
+	repo := &machineRepo{status: map[string]models.TaskStatus{"t1": models.TaskQueued}}
+	w := testManagedWorker(botRef{BotID: 1}, time.Now()).Worker
+	w.Repo = repo
+	st := &models.Stats{ExpectedCount: 2, ProcessedCount: 2, TotalSent: 2}
+	w.stats["t1"] = st
+
+	w.finishTask("t1", st)
+
+	if repo.status["t1"] != models.TaskComplete {
+		t.Fatalf("task status = %q, want complete", repo.status["t1"])
+	}
+}
+
+func TestWorkerCancelTask(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	w := testManagedWorker(botRef{BotID: 1}, time.Now()).Worker
+	w.stats["t1"] = &models.Stats{ExpectedCount: 5, ProcessedCount: 1}
+	w.chunks["t1"] = &chunkProgress{received: 1}
+
+	if !w.CancelTask("t1") {
+		t.Fatal("running task was not cancelled")
+	}
+	if w.CancelTask("t2") {
+		t.Fatal("unknown task must not be cancelled")
+	}
+	if _, ok := w.stats["t1"]; ok {
+		t.Fatal("cancelled task must be forgotten")
+	}
+
+	// Часть, пришедшая после отмены, не заводит задачу заново
+	if !w.beginEnqueue(TaskNATSMessage{TaskID: "t1", ChunkIndex: 1}) {
+		t.Fatal("running worker must accept the part")
+	}
+	w.endEnqueue("t1")
+	if _, ok := w.stats["t1"]; ok {
+		t.Fatal("part of a cancelled task must not restart it")
+	}
+	if len(w.enqueuing) != 0 {
+		t.Fatalf("enqueuing = %v, want empty", w.enqueuing)
+	}
+
+	// Четыре получателя ещё в канале или в отправке: отмена помнится, пока они не выйдут
+	for i := 0; i < 3; i++ {
+		if !w.skipCancelled("t1") {
+			t.Fatal("recipient of a cancelled task must be skipped")
+		}
+	}
+	if _, ok := w.cancelled["t1"]; !ok {
+		t.Fatal("cancellation forgotten while recipients are still queued")
+	}
+	w.incrementSent(TaskItem{TaskID: "t1"})
+	if len(w.cancelled) != 0 {
+		t.Fatalf("cancelled = %v, want empty after the task drained", w.cancelled)
+	}
+}
@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/queue"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// SubjectTaskCancel — API сообщает, что задача отменена или не опубликована целиком;
+// экземпляр, рассылающий её, пропускает оставшихся получателей
+const SubjectTaskCancel = "tasks.cancel"
+
+// TaskCancelEvent — тело сообщения SubjectTaskCancel
+type TaskCancelEvent struct {
+	TaskID string `json:"task_id"`
+}
+
+// SubscribeTaskCancel прекращает рассылку задач, которые API отменил или пометил failed
+func SubscribeTaskCancel(natsClient *queue.NATSClient, manager *BotManager) (*nats.Subscription, error) {
+	return natsClient.Conn.Subscribe(SubjectTaskCancel, func(msg *nats.Msg) {
+		var event TaskCancelEvent
+		if err := json.Unmarshal(msg.Data, &event); err != nil || event.TaskID == "" {
+			logger.Log.Warn("[Worker] Некорректное сообщение об отмене задачи",
+				zap.ByteString("data", msg.Data), zap.Error(err))
+			return
+		}
+		manager.CancelTask(event.TaskID)
+	})
+}
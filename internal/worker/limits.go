@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/queue"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// SubjectBotLimits — API сообщает об изменении ограничений бота; каждый экземпляр
+// применяет их к своему воркеру бота без перезапуска
+const SubjectBotLimits = "bots.limits.updated"
+
+// BotLimitsEvent — тело сообщения SubjectBotLimits
+type BotLimitsEvent struct {
+	BotID uint `json:"bot_id"`
+}
+
+// SubscribeLimitUpdates применяет изменённые через API ограничения ботов к работающим воркерам
+func SubscribeLimitUpdates(natsClient *queue.NATSClient, manager *BotManager) (*nats.Subscription, error) {
+	return natsClient.Conn.Subscribe(SubjectBotLimits, func(msg *nats.Msg) {
+		var event BotLimitsEvent
+		if err := json.Unmarshal(msg.Data, &event); err != nil || event.BotID == 0 {
+			logger.Log.Warn("[Worker] Некорректное сообщение об ограничениях бота",
+				zap.ByteString("data", msg.Data), zap.Error(err))
+			return
+		}
+		manager.ApplyLimits(event.BotID)
+	})
+}
@@ -0,0 +1,374 @@
+package worker
+
+import (
+	"GoBlast/internal/leases"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/queue"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// LeaseTTL — срок аренды бота; упавший экземпляр теряет ботов не позже чем через LeaseTTL
+	LeaseTTL = 30 * time.Second
+	// leaseRenewEvery — период продления аренд (с запасом на задержки БД)
+	leaseRenewEvery = LeaseTTL / 3
+	// forwardTimeout — ожидание подтверждения от владельца бота
+	forwardTimeout = 5 * time.Second
+	// forwardRetryDelay — пауза перед повторной попыткой, если владелец не ответил
+	forwardRetryDelay = 2 * time.Second
+	// capacityRetryDelay — пауза перед повтором задачи, для которой не нашлось свободного воркера
+	capacityRetryDelay = 2 * time.Second
+	// maxDelayedTasks — сколько частей задач может ждать свободного воркера; остальные уходят в DLQ
+	maxDelayedTasks = 1000
+)
+
+// botSubject — subject, на который владелец бота принимает его задачи от других экземпляров
+func botSubject(key string) string {
+	return "tasks.bot." + key
+}
+
+// key — ключ аренды бота
+func (r botRef) key() string {
+	if r.BotID != 0 {
+		return fmt.Sprintf("bot-%d", r.BotID)
+	}
+	return fmt.Sprintf("user-%d", r.UserID)
+}
+
+// newInstanceID возвращает уникальный идентификатор экземпляра воркера
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	buf := make([]byte, 4)
+	_, _ = rand.Read(buf)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(buf))
+}
+
+// ShardRouter распределяет задачи по ботам между экземплярами воркера.
+// Задачи из общей очереди tasks.create может получить любой экземпляр; бот же
+// обслуживает только владелец его аренды (таблица bot_leases). Остальные экземпляры
+// пересылают задачу владельцу запросом на tasks.bot.<ключ>. Если владелец не отвечает,
+// аренда через LeaseTTL истекает, и бота забирает экземпляр, получивший его задачу.
+// Экземпляр, потерявший аренду, останавливает воркер бота и передаёт его незавершённые
+// задачи новому владельцу.
+type ShardRouter struct {
+	id      string
+	nc      *queue.NATSClient
+	leases  *leases.LeaseRepository
+	handle  func(task TaskNATSMessage, raw []byte) error // обработка задачи своего бота
+	release func(key string) []TaskNATSMessage           // остановка воркера бота, аренда которого потеряна
+	reject  func(raw []byte, reason string)
+
+	mu    sync.Mutex
+	owned map[string]*nats.Subscription // ключ бота -> подписка на его subject
+	stop  chan struct{}
+	wg    sync.WaitGroup
+
+	delayed atomic.Int32 // частей, ждущих свободного воркера
+
+	// Пересылка повторяется, если подтверждение не дошло: повтор части задачи отбрасывается
+	seenMu sync.Mutex
+	seen   map[string]time.Time // task_id/часть -> когда принята
+}
+
+// NewShardRouter создаёт маршрутизатор; handle обрабатывает задачи своих ботов,
+// release останавливает воркер бота после потери аренды (BotManager.Handoff)
+func NewShardRouter(natsClient *queue.NATSClient, db *gorm.DB, handle func(task TaskNATSMessage, raw []byte) error,
+	release func(key string) []TaskNATSMessage) *ShardRouter {
+	return &ShardRouter{
+		id:      newInstanceID(),
+		nc:      natsClient,
+		leases:  leases.NewLeaseRepository(db),
+		handle:  handle,
+		release: release,
+		reject: func(raw []byte, reason string) {
+			deadLetter(natsClient, raw, reason)
+		},
+		owned: make(map[string]*nats.Subscription),
+		stop:  make(chan struct{}),
+		seen:  make(map[string]time.Time),
+	}
+}
+
+// ID — идентификатор экземпляра (владелец в bot_leases)
+func (sr *ShardRouter) ID() string {
+	return sr.id
+}
+
+// Start запускает продление аренд
+func (sr *ShardRouter) Start() {
+	sr.wg.Add(1)
+	go func() {
+		defer sr.wg.Done()
+		ticker := time.NewTicker(leaseRenewEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sr.stop:
+				return
+			case <-ticker.C:
+				sr.renew()
+				sr.forgetSeen()
+			}
+		}
+	}()
+	logger.Log.Info("[Shard] Экземпляр воркера зарегистрирован", zap.String("instance", sr.id))
+}
+
+// Stop отписывается от ботов и освобождает аренды, чтобы их сразу забрали другие экземпляры
+func (sr *ShardRouter) Stop() {
+	close(sr.stop)
+	sr.wg.Wait()
+
+	sr.mu.Lock()
+	for key, sub := range sr.owned {
+		_ = sub.Unsubscribe()
+		delete(sr.owned, key)
+	}
+	sr.mu.Unlock()
+
+	if err := sr.leases.ReleaseAll(sr.id); err != nil {
+		logger.Log.Error("[Shard] Ошибка освобождения аренд", zap.Error(err))
+	}
+}
+
+// Route обрабатывает задачу сам, если бот за этим экземпляром (или его удалось арендовать),
+// иначе пересылает её владельцу
+func (sr *ShardRouter) Route(task TaskNATSMessage, raw []byte) {
+	key := refOf(task).key()
+	owned, err := sr.claim(key)
+	if err != nil {
+		logger.Log.Error("[Shard] Ошибка аренды бота", zap.String("bot", key), zap.Error(err))
+		sr.reject(raw, "lease: "+err.Error())
+		return
+	}
+	if owned {
+		sr.accept(task, raw)
+		return
+	}
+	// Пересылка может ждать истечения аренды упавшего владельца: не держим очередь tasks.create
+	go sr.forward(key, task, raw)
+}
+
+// claim возвращает true, если бот key принадлежит этому экземпляру (при необходимости арендуя его)
+func (sr *ShardRouter) claim(key string) (bool, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if _, ok := sr.owned[key]; ok {
+		return true, nil
+	}
+	acquired, err := sr.leases.Acquire(key, sr.id, LeaseTTL)
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	// Подтверждаем приём сразу, а обрабатываем в фоне: иначе долгая задача
+	// задержит подтверждения следующих и отправитель повторит пересылку
+	sub, err := sr.nc.Conn.Subscribe(botSubject(key), func(msg *nats.Msg) {
+		_ = msg.Respond([]byte("ok"))
+		var task TaskNATSMessage
+		if e := decodeTask(msg.Data, &task); e != nil {
+			sr.reject(msg.Data, e.Error())
+			return
+		}
+		go sr.accept(task, msg.Data)
+	})
+	if err != nil {
+		_ = sr.leases.Release(key, sr.id)
+		return false, err
+	}
+	sr.owned[key] = sub
+
+	logger.Log.Info("[Shard] Бот закреплён за экземпляром",
+		zap.String("bot", key),
+		zap.String("instance", sr.id))
+	return true, nil
+}
+
+// forward передаёт задачу владельцу бота. Пока владелец не отвечает, повторяет попытки
+// и пробует забрать аренду сам: она освободится не позже чем через LeaseTTL.
+func (sr *ShardRouter) forward(key string, task TaskNATSMessage, raw []byte) {
+	deadline := time.Now().Add(2 * LeaseTTL)
+	for {
+		_, err := sr.nc.Conn.Request(botSubject(key), raw, forwardTimeout)
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, nats.ErrNoResponders) && !errors.Is(err, nats.ErrTimeout) {
+			logger.Log.Error("[Shard] Ошибка пересылки задачи владельцу бота",
+				zap.String("bot", key), zap.String("task_id", task.TaskID), zap.Error(err))
+		}
+
+		owned, claimErr := sr.claim(key)
+		if claimErr != nil {
+			logger.Log.Error("[Shard] Ошибка аренды бота", zap.String("bot", key), zap.Error(claimErr))
+		}
+		if owned {
+			sr.accept(task, raw)
+			return
+		}
+
+		if time.Now().After(deadline) {
+			sr.reject(raw, "owner of "+key+" does not respond")
+			return
+		}
+		select {
+		case <-sr.stop:
+			sr.reject(raw, "worker stopped while forwarding to "+key)
+			return
+		case <-time.After(forwardRetryDelay):
+		}
+	}
+}
+
+// accept передаёт задачу на обработку, отбрасывая повторно пересланные части.
+// Часть, которую не принял остановленный воркер, маршрутизируется заново; часть,
+// для которой нет свободного воркера, повторяется позже.
+func (sr *ShardRouter) accept(task TaskNATSMessage, raw []byte) {
+	// Переданная после потери аренды часть — новая, даже если экземпляр видел её раньше
+	id := fmt.Sprintf("%s/%d/%d", task.TaskID, task.ChunkIndex, task.Handoffs)
+
+	sr.seenMu.Lock()
+	_, dup := sr.seen[id]
+	if !dup {
+		sr.seen[id] = time.Now()
+	}
+	sr.seenMu.Unlock()
+
+	if dup {
+		logger.Log.Warn("[Shard] Повторно пересланная часть задачи отброшена",
+			zap.String("task_id", task.TaskID), zap.Int("chunk_index", task.ChunkIndex))
+		return
+	}
+	if err := sr.handle(task, raw); err != nil {
+		sr.seenMu.Lock()
+		delete(sr.seen, id)
+		sr.seenMu.Unlock()
+
+		if errors.Is(err, ErrNoCapacity) {
+			sr.delay(task, raw)
+			return
+		}
+		logger.Log.Warn("[Shard] Задача не принята воркером, маршрутизируется заново",
+			zap.String("task_id", task.TaskID), zap.Int("chunk_index", task.ChunkIndex), zap.Error(err))
+		sr.Route(task, raw)
+	}
+}
+
+// delay повторяет маршрутизацию задачи через capacityRetryDelay, не занимая обработчик NATS.
+// Ждущих частей не больше maxDelayedTasks: сверх этого задача уходит в DLQ.
+func (sr *ShardRouter) delay(task TaskNATSMessage, raw []byte) {
+	if sr.delayed.Add(1) > maxDelayedTasks {
+		sr.delayed.Add(-1)
+		logger.Log.Error("[Shard] Слишком много задач ждут свободного воркера, задача отклонена",
+			zap.String("task_id", task.TaskID), zap.Int("chunk_index", task.ChunkIndex))
+		sr.reject(raw, "no free bot worker slots")
+		return
+	}
+
+	sr.wg.Add(1)
+	go func() {
+		defer sr.wg.Done()
+		defer sr.delayed.Add(-1)
+
+		select {
+		case <-sr.stop:
+			sr.reject(raw, "worker stopped while waiting for a free bot worker slot")
+			return
+		case <-time.After(capacityRetryDelay):
+		}
+		sr.Route(task, raw)
+	}()
+}
+
+// forgetSeen удаляет отметки о принятых частях старше окна повторной пересылки
+func (sr *ShardRouter) forgetSeen() {
+	sr.seenMu.Lock()
+	defer sr.seenMu.Unlock()
+	for id, at := range sr.seen {
+		if time.Since(at) > 4*LeaseTTL {
+			delete(sr.seen, id)
+		}
+	}
+}
+
+// renew продлевает аренды; ботов, аренду которых перехватили, экземпляр отпускает
+// и передаёт их задачи новому владельцу
+func (sr *ShardRouter) renew() {
+	// Остановка воркера ждёт текущих отправок: не задерживаем продление остальных аренд
+	for _, key := range sr.renewOwned() {
+		sr.wg.Add(1)
+		go func(key string) {
+			defer sr.wg.Done()
+			sr.handBack(key)
+		}(key)
+	}
+}
+
+// renewOwned продлевает аренды и возвращает ключи ботов, аренда которых потеряна
+func (sr *ShardRouter) renewOwned() []string {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	keys := make([]string, 0, len(sr.owned))
+	for key := range sr.owned {
+		keys = append(keys, key)
+	}
+	renewed, err := sr.leases.Renew(sr.id, keys, LeaseTTL)
+	if err != nil {
+		// Аренды ещё действуют LeaseTTL: попробуем на следующем тике
+		logger.Log.Error("[Shard] Ошибка продления аренд", zap.Error(err))
+		return nil
+	}
+
+	still := make(map[string]bool, len(renewed))
+	for _, key := range renewed {
+		still[key] = true
+	}
+	var lost []string
+	for key, sub := range sr.owned {
+		if still[key] {
+			continue
+		}
+		_ = sub.Unsubscribe()
+		delete(sr.owned, key)
+		lost = append(lost, key)
+	}
+	return lost
+}
+
+// handBack останавливает воркер бота key, аренду которого перехватили, и пересылает
+// его незавершённые задачи новому владельцу: бот не рассылает с двух экземпляров сразу
+func (sr *ShardRouter) handBack(key string) {
+	var parts []TaskNATSMessage
+	if sr.release != nil {
+		parts = sr.release(key)
+	}
+	logger.Log.Warn("[Shard] Аренда бота потеряна, задачи переданы новому владельцу",
+		zap.String("bot", key),
+		zap.Int("parts", len(parts)))
+
+	for _, task := range parts {
+		raw, err := json.Marshal(task)
+		if err != nil {
+			logger.Log.Error("[Shard] Ошибка сериализации задачи", zap.String("task_id", task.TaskID), zap.Error(err))
+			continue
+		}
+		sr.Route(task, raw)
+	}
+}
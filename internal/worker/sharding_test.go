@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"GoBlast/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+func TestBotRefKey(t *testing.T) {
+	cases := []struct {
+		task TaskNATSMessage
+		want string
+	}{
+		{TaskNATSMessage{UserID: 7, BotID: 42}, "bot-42"},
+		{TaskNATSMessage{UserID: 7}, "user-7"},
+	}
+	for _, c := range cases {
+		if got := refOf(c.task).key(); got != c.want {
+			t.Errorf("key = %q, want %q", got, c.want)
+		}
+	}
+	if got := botSubject("bot-42"); got != "tasks.bot.bot-42" {
+		t.Errorf("botSubject = %q", got)
+	}
+}
+
+func TestShardRouterAcceptDropsDuplicates(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	var handled []int
+	sr := &ShardRouter{
+		seen: make(map[string]time.Time),
+		handle: func(task TaskNATSMessage, _ []byte) error {
+			handled = append(handled, task.ChunkIndex)
+			return nil
+		},
+	}
+
+	sr.accept(TaskNATSMessage{TaskID: "t1", ChunkIndex: 0}, nil)
+	sr.accept(TaskNATSMessage{TaskID: "t1", ChunkIndex: 1}, nil)
+	sr.accept(TaskNATSMessage{TaskID: "t1", ChunkIndex: 0}, nil)              // повторная пересылка
+	sr.accept(TaskNATSMessage{TaskID: "t1", ChunkIndex: 0, Handoffs: 1}, nil) // передана после потери аренды
+
+	if len(handled) != 3 || handled[0] != 0 || handled[1] != 1 || handled[2] != 0 {
+		t.Fatalf("handled chunks = %v, want [0 1 0]", handled)
+	}
+}
@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/queue"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// SubjectStatus — запрос состояния воркеров; отвечает каждый экземпляр
+const SubjectStatus = "workers.status"
+
+// ServeStatus отвечает на запросы SubjectStatus состоянием менеджера экземпляра instance
+func ServeStatus(natsClient *queue.NATSClient, manager *BotManager, instance string) (*nats.Subscription, error) {
+	return natsClient.Conn.Subscribe(SubjectStatus, func(msg *nats.Msg) {
+		status := manager.Status()
+		status.Instance = instance
+
+		data, err := json.Marshal(status)
+		if err != nil {
+			logger.Log.Error("[Status] Ошибка сериализации состояния воркеров", zap.Error(err))
+			return
+		}
+		if err := msg.Respond(data); err != nil {
+			logger.Log.Error("[Status] Ошибка ответа на запрос состояния", zap.Error(err))
+		}
+	})
+}
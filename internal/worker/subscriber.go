@@ -1,31 +1,61 @@
 package worker
 
 import (
-	"GoBlast/internal/api/middleware"
+	"GoBlast/internal/bots"
+	"GoBlast/internal/tasks"
 	"GoBlast/internal/users"
-	"GoBlast/pkg/encryption"
 	"GoBlast/pkg/logger"
 	"GoBlast/pkg/queue"
-	"encoding/base64"
+	"GoBlast/pkg/storage/models"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// Действия задачи
+const (
+	ActionSend   = "send"   // рассылка нового сообщения
+	ActionEdit   = "edit"   // редактирование ранее отправленных сообщений
+	ActionRecall = "recall" // удаление ранее отправленных сообщений
+)
+
 // TaskNATSMessage — структура задачи, приходящей из NATS.
 type TaskNATSMessage struct {
-	TaskID     string  `json:"task_id"` // ID задачи
-	UserID     uint    `json:"user_id"`
-	Recipients []int64 `json:"recipients"`
-	Content    Content `json:"content"`
-	Priority   string  `json:"priority,omitempty"`
+	TaskID        string    `json:"task_id"` // ID задачи
+	UserID        uint      `json:"user_id"`
+	BotID         uint      `json:"bot_id,omitempty"`    // бот рассылки; 0 — бот из AuthUser.Token
+	Action        string    `json:"action,omitempty"`    // send (по умолчанию), edit, recall
+	ParentID      string    `json:"parent_id,omitempty"` // для edit/recall: задача, чьи сообщения меняем
+	Recipients    []int64   `json:"recipients"`
+	AudienceID    uint      `json:"audience_id,omitempty"`    // получатели из аудитории (вместо Recipients)
+	TagExpr       string    `json:"tag_expr,omitempty"`       // фильтр участников аудитории по тегам
+	ToSubscribers bool      `json:"to_subscribers,omitempty"` // получатели — активные подписчики бота
+	LanguageCode  string    `json:"language_code,omitempty"`  // фильтр подписчиков по языку
+	Content       Content   `json:"content"`
+	Variants      []Variant `json:"variants,omitempty"` // варианты A/B-теста (вместо Content)
+	Priority      string    `json:"priority,omitempty"`
+	ChunkIndex    int       `json:"chunk_index,omitempty"` // номер части большого списка получателей
+	MoreChunks    bool      `json:"more_chunks,omitempty"` // false у последней (или единственной) части
+	// Задача, переданная другим экземпляром после потери аренды бота: сколько раз
+	// её передавали и когда прежний владелец начал рассылку
+	Handoffs  int        `json:"handoffs,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
 	// Schedule ... (если нужно)
 }
 
+// Variant — вариант контента в A/B-тесте
+type Variant struct {
+	Name    string  `json:"name"`
+	Weight  int     `json:"weight"`
+	Content Content `json:"content"`
+}
+
 // Content — описание контента (тип, текст/медиа и т. д.)
 type Content struct {
 	Type     string `json:"type"`
@@ -33,54 +63,169 @@ type Content struct {
 	MediaURL string `json:"media_url"`
 	MediaID  string `json:"media_id"`
 	Caption  string `json:"caption"`
+
+	Poll     *Poll     `json:"poll,omitempty"`
+	Location *Location `json:"location,omitempty"`
+	Venue    *Venue    `json:"venue,omitempty"`
+	Contact  *Contact  `json:"contact,omitempty"`
+	Emoji    string    `json:"emoji,omitempty"`
+
+	FromChatID int64 `json:"from_chat_id,omitempty"`
+	MessageID  int   `json:"message_id,omitempty"`
+
+	Buttons [][]Button `json:"buttons,omitempty"`
+
+	TrackedLinks map[string]uint `json:"tracked_links,omitempty"` // url -> ID отслеживаемой ссылки
+}
+
+// Button — inline-кнопка со ссылкой
+type Button struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Poll — опрос (обычный или викторина)
+type Poll struct {
+	Question        string   `json:"question"`
+	Options         []string `json:"options"`
+	Type            string   `json:"type,omitempty"`
+	CorrectOption   *int     `json:"correct_option,omitempty"`
+	MultipleAnswers bool     `json:"multiple_answers,omitempty"`
+	Anonymous       *bool    `json:"anonymous,omitempty"`
+	Explanation     string   `json:"explanation,omitempty"`
+}
+
+// Location — точка на карте
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Venue — место с названием и адресом
+type Venue struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Title     string  `json:"title"`
+	Address   string  `json:"address"`
+}
+
+// Contact — карточка контакта
+type Contact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name,omitempty"`
 }
 
-func SubscribeTasks(natsClient *queue.NATSClient, db *gorm.DB, botManager *BotManager) error {
+// errBotUnauthorized — бот задачи отключён: Telegram отклонил его токен
+var errBotUnauthorized = errors.New("токен бота отклонён Telegram, бот отключён")
+
+// SubscribeTasks принимает задачи из общей очереди tasks.create и передаёт их
+// ShardRouter, который отдаёт задачу экземпляру-владельцу её бота
+func SubscribeTasks(natsClient *queue.NATSClient, router *ShardRouter) error {
 	_, err := natsClient.Conn.QueueSubscribe("tasks.create", "worker-group", func(msg *nats.Msg) {
 		var natsMsg TaskNATSMessage
-		if e := json.Unmarshal(msg.Data, &natsMsg); e != nil {
-			logger.Log.Error("Ошибка десериализации сообщения NATS", zap.Error(e))
+		if e := decodeTask(msg.Data, &natsMsg); e != nil {
+			logger.Log.Error("Некорректное сообщение NATS", zap.Error(e))
+			deadLetter(natsClient, msg.Data, e.Error())
 			return
 		}
 		logger.Log.Info("[Subscriber] Получено сообщение NATS",
 			zap.String("task_id", natsMsg.TaskID),
 			zap.Uint("user_id", natsMsg.UserID),
+			zap.Uint("bot_id", natsMsg.BotID),
+			zap.String("action", natsMsg.Action),
 			zap.Int("recipients_count", len(natsMsg.Recipients)),
 			zap.String("priority", natsMsg.Priority))
 
-		// Валидация
-		if e := validateTaskMessage(natsMsg); e != nil {
-			logger.Log.Error("Некорректное сообщение NATS", zap.Error(e))
-			return
-		}
+		router.Route(natsMsg, msg.Data)
+	})
+	if err != nil {
+		return err
+	}
 
-		// Ищем в БД токен бота
-		userRepo := users.NewAuthUserRepository(db)
-		userData, e := userRepo.FindByID(natsMsg.UserID)
-		if e != nil {
-			logger.Log.Error("Ошибка получения пользователя",
-				zap.Error(e),
-				zap.Uint("user_id", natsMsg.UserID))
-			return
-		}
+	logger.Log.Info("Подписка на NATS успешно выполнена")
+	return nil
+}
+
+// decodeTask разбирает и проверяет сообщение задачи
+func decodeTask(data []byte, task *TaskNATSMessage) error {
+	if err := json.Unmarshal(data, task); err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	if err := validateTaskMessage(*task); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	return nil
+}
 
-		botToken, e := decryptToken(userData.Token)
+// TaskStarter возвращает обработчик задач ботов, закреплённых за этим экземпляром:
+// токен бота ищется в БД, задача передаётся в BotManager. Ошибку возвращает, только
+// если задачу нужно передать заново (ErrWorkerStopped) или повторить позже (ErrNoCapacity).
+func TaskStarter(natsClient *queue.NATSClient, db *gorm.DB, botManager *BotManager) func(TaskNATSMessage, []byte) error {
+	return func(natsMsg TaskNATSMessage, raw []byte) error {
+		// Ищем в БД токен бота задачи
+		botToken, e := resolveBotToken(db, natsMsg)
 		if e != nil {
-			logger.Log.Error("Ошибка дешифрования токена",
+			logger.Log.Error("Ошибка получения токена бота",
 				zap.Error(e),
-				zap.Uint("user_id", natsMsg.UserID))
-			return
+				zap.Uint("user_id", natsMsg.UserID),
+				zap.Uint("bot_id", natsMsg.BotID))
+			deadLetter(natsClient, raw, "bot token: "+e.Error())
+			if errors.Is(e, errBotUnauthorized) {
+				// Задача пришла уже после отключения бота: прерываем её сразу
+				if _, e := tasks.NewTasksRepository(db).AbortBotTasks(natsMsg.UserID, natsMsg.BotID, reasonBotUnauthorized); e != nil {
+					logger.Log.Error("Ошибка прерывания задач бота", zap.Error(e))
+				}
+			}
+			return nil
 		}
 
 		// Передаём задачу в BotManager
-		botManager.StartTask(botToken, natsMsg, db)
-	})
+		err := botManager.StartTask(botToken, natsMsg)
+		if err == nil || errors.Is(err, ErrWorkerStopped) || errors.Is(err, ErrNoCapacity) {
+			return err
+		}
+		logger.Log.Error("Ошибка передачи задачи воркеру бота",
+			zap.String("task_id", natsMsg.TaskID), zap.Error(err))
+		deadLetter(natsClient, raw, "start task: "+err.Error())
+		return nil
+	}
+}
+
+// resolveBotToken возвращает расшифрованный токен бота задачи: бота организации из таблицы bots
+// или, если BotID не задан, бота, указанного пользователем при регистрации
+func resolveBotToken(db *gorm.DB, task TaskNATSMessage) (string, error) {
+	userData, err := users.NewAuthUserRepository(db).FindByID(task.UserID)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if task.BotID == 0 {
+		if userData.Token == "" {
+			return "", errors.New("у пользователя нет бота по умолчанию")
+		}
+		if userData.BotStatus == models.BotUnauthorized {
+			return "", errBotUnauthorized
+		}
+		return bots.DecryptToken(userData.Token)
+	}
+	if userData.OrgID == nil {
+		return "", errors.New("пользователь не состоит в организации")
 	}
 
-	logger.Log.Info("Подписка на NATS успешно выполнена")
-	return nil
+	botRepo := bots.NewBotRepository(db)
+	getBot := botRepo.Get
+	if task.ParentID != "" {
+		// Правка, отзыв и победитель A/B-теста идут от бота родительской задачи, даже удалённого
+		getBot = botRepo.GetIncludingDeleted
+	}
+	bot, err := getBot(*userData.OrgID, task.BotID)
+	if err != nil {
+		return "", err
+	}
+	if bot.Status == models.BotUnauthorized {
+		return "", errBotUnauthorized
+	}
+	return bots.DecryptToken(bot.Token)
 }
 
 // validateTaskMessage проверяет ключевые поля
@@ -91,24 +236,20 @@ func validateTaskMessage(task TaskNATSMessage) error {
 	if task.UserID == 0 {
 		return errors.New("пустой user_id")
 	}
-	if len(task.Recipients) == 0 {
-		return errors.New("пустой список получателей")
-	}
-	if strings.TrimSpace(task.Content.Type) == "" {
-		return errors.New("пустой тип контента")
+	switch task.Action {
+	case "", ActionSend:
+		if len(task.Recipients) == 0 && task.AudienceID == 0 && !task.ToSubscribers {
+			return errors.New("пустой список получателей")
+		}
+		if strings.TrimSpace(task.Content.Type) == "" {
+			return errors.New("пустой тип контента")
+		}
+	case ActionEdit, ActionRecall:
+		if task.ParentID == "" {
+			return errors.New("пустой parent_id")
+		}
+	default:
+		return errors.New("неизвестное действие: " + task.Action)
 	}
 	return nil
 }
-
-// decryptToken расшифровывает токен бота из userData.Token
-func decryptToken(encryptedToken string) (string, error) {
-	decoded, err := base64.StdEncoding.DecodeString(encryptedToken)
-	if err != nil {
-		return "", err
-	}
-	plain, err := encryption.Decrypt(decoded, []byte(middleware.EncryptionKey))
-	if err != nil {
-		return "", err
-	}
-	return string(plain), nil
-}
@@ -1,7 +1,9 @@
 package worker
 
 import (
+	"encoding/json"
 	"errors"
+	"strconv"
 
 	"GoBlast/pkg/logger"
 	"go.uber.org/zap"
@@ -10,13 +12,13 @@ import (
 
 // sendPhoto отправляет фото.
 // Принимает *полный* TaskItem (в частности, item.TaskID можно использовать для логирования).
-func (w *Worker) sendPhoto(item TaskItem) error {
+func (w *Worker) sendPhoto(item TaskItem) (*tele.Message, error) {
 	c := item.Content
 	if c.MediaID == "" && c.MediaURL == "" {
 		logger.Log.Warn("[Worker] sendPhoto: нет MediaID или MediaURL",
 			zap.String("task_id", item.TaskID),
 			zap.Int64("recipient", item.Recipient))
-		return errors.New("photo: no MediaID or MediaURL")
+		return nil, errors.New("photo: no MediaID or MediaURL")
 	}
 
 	var photo *tele.Photo
@@ -32,18 +34,17 @@ func (w *Worker) sendPhoto(item TaskItem) error {
 		}
 	}
 
-	_, err := w.Bot.Send(tele.ChatID(item.Recipient), photo)
-	return err
+	return w.Bot.Send(tele.ChatID(item.Recipient), photo, replyMarkup(item.Content))
 }
 
 // sendAnimation отправляет анимацию (GIF).
-func (w *Worker) sendAnimation(item TaskItem) error {
+func (w *Worker) sendAnimation(item TaskItem) (*tele.Message, error) {
 	c := item.Content
 	if c.MediaID == "" && c.MediaURL == "" {
 		logger.Log.Warn("[Worker] sendAnimation: нет MediaID или MediaURL",
 			zap.String("task_id", item.TaskID),
 			zap.Int64("recipient", item.Recipient))
-		return errors.New("animation: no MediaID or MediaURL")
+		return nil, errors.New("animation: no MediaID or MediaURL")
 	}
 
 	var anim *tele.Animation
@@ -59,18 +60,17 @@ func (w *Worker) sendAnimation(item TaskItem) error {
 		}
 	}
 
-	_, err := w.Bot.Send(tele.ChatID(item.Recipient), anim)
-	return err
+	return w.Bot.Send(tele.ChatID(item.Recipient), anim, replyMarkup(item.Content))
 }
 
 // sendVideo отправляет видео.
-func (w *Worker) sendVideo(item TaskItem) error {
+func (w *Worker) sendVideo(item TaskItem) (*tele.Message, error) {
 	c := item.Content
 	if c.MediaID == "" && c.MediaURL == "" {
 		logger.Log.Warn("[Worker] sendVideo: нет MediaID или MediaURL",
 			zap.String("task_id", item.TaskID),
 			zap.Int64("recipient", item.Recipient))
-		return errors.New("video: no MediaID or MediaURL")
+		return nil, errors.New("video: no MediaID or MediaURL")
 	}
 
 	var video *tele.Video
@@ -86,18 +86,17 @@ func (w *Worker) sendVideo(item TaskItem) error {
 		}
 	}
 
-	_, err := w.Bot.Send(tele.ChatID(item.Recipient), video)
-	return err
+	return w.Bot.Send(tele.ChatID(item.Recipient), video, replyMarkup(item.Content))
 }
 
 // sendDocument отправляет документ (файл).
-func (w *Worker) sendDocument(item TaskItem) error {
+func (w *Worker) sendDocument(item TaskItem) (*tele.Message, error) {
 	c := item.Content
 	if c.MediaID == "" && c.MediaURL == "" {
 		logger.Log.Warn("[Worker] sendDocument: нет MediaID или MediaURL",
 			zap.String("task_id", item.TaskID),
 			zap.Int64("recipient", item.Recipient))
-		return errors.New("document: no MediaID or MediaURL")
+		return nil, errors.New("document: no MediaID or MediaURL")
 	}
 
 	var doc *tele.Document
@@ -113,18 +112,17 @@ func (w *Worker) sendDocument(item TaskItem) error {
 		}
 	}
 
-	_, err := w.Bot.Send(tele.ChatID(item.Recipient), doc)
-	return err
+	return w.Bot.Send(tele.ChatID(item.Recipient), doc, replyMarkup(item.Content))
 }
 
 // sendAudio отправляет аудио.
-func (w *Worker) sendAudio(item TaskItem) error {
+func (w *Worker) sendAudio(item TaskItem) (*tele.Message, error) {
 	c := item.Content
 	if c.MediaID == "" && c.MediaURL == "" {
 		logger.Log.Warn("[Worker] sendAudio: нет MediaID или MediaURL",
 			zap.String("task_id", item.TaskID),
 			zap.Int64("recipient", item.Recipient))
-		return errors.New("audio: no MediaID or MediaURL")
+		return nil, errors.New("audio: no MediaID or MediaURL")
 	}
 
 	var audio *tele.Audio
@@ -140,18 +138,17 @@ func (w *Worker) sendAudio(item TaskItem) error {
 		}
 	}
 
-	_, err := w.Bot.Send(tele.ChatID(item.Recipient), audio)
-	return err
+	return w.Bot.Send(tele.ChatID(item.Recipient), audio, replyMarkup(item.Content))
 }
 
 // sendCircle отправляет круговое видео (VideoNote).
-func (w *Worker) sendCircle(item TaskItem) error {
+func (w *Worker) sendCircle(item TaskItem) (*tele.Message, error) {
 	c := item.Content
 	if c.MediaID == "" && c.MediaURL == "" {
 		logger.Log.Warn("[Worker] sendCircle: нет MediaID или MediaURL",
 			zap.String("task_id", item.TaskID),
 			zap.Int64("recipient", item.Recipient))
-		return errors.New("circle: no MediaID or MediaURL")
+		return nil, errors.New("circle: no MediaID or MediaURL")
 	}
 
 	var vn *tele.VideoNote
@@ -169,6 +166,198 @@ func (w *Worker) sendCircle(item TaskItem) error {
 		}
 	}
 
-	_, err := w.Bot.Send(tele.ChatID(item.Recipient), vn)
-	return err
+	return w.Bot.Send(tele.ChatID(item.Recipient), vn, replyMarkup(item.Content))
+}
+
+// sendSticker отправляет стикер.
+func (w *Worker) sendSticker(item TaskItem) (*tele.Message, error) {
+	c := item.Content
+	if c.MediaID == "" && c.MediaURL == "" {
+		logger.Log.Warn("[Worker] sendSticker: нет MediaID или MediaURL",
+			zap.String("task_id", item.TaskID),
+			zap.Int64("recipient", item.Recipient))
+		return nil, errors.New("sticker: no MediaID or MediaURL")
+	}
+
+	var sticker *tele.Sticker
+	if c.MediaID != "" {
+		sticker = &tele.Sticker{File: tele.File{FileID: c.MediaID}}
+	} else {
+		sticker = &tele.Sticker{File: tele.FromURL(c.MediaURL)}
+	}
+
+	return w.Bot.Send(tele.ChatID(item.Recipient), sticker, replyMarkup(item.Content))
+}
+
+// sendPoll отправляет опрос или викторину.
+func (w *Worker) sendPoll(item TaskItem) (*tele.Message, error) {
+	p := item.Content.Poll
+	if p == nil {
+		logger.Log.Warn("[Worker] sendPoll: нет описания опроса",
+			zap.String("task_id", item.TaskID),
+			zap.Int64("recipient", item.Recipient))
+		return nil, errors.New("poll: no poll")
+	}
+
+	poll := &tele.Poll{
+		Type:            tele.PollRegular,
+		Question:        p.Question,
+		MultipleAnswers: p.MultipleAnswers,
+		Anonymous:       true,
+	}
+	if p.Anonymous != nil {
+		poll.Anonymous = *p.Anonymous
+	}
+	if p.Type == "quiz" {
+		poll.Type = tele.PollQuiz
+		poll.Explanation = p.Explanation
+		poll.ParseMode = tele.ModeHTML
+		if p.CorrectOption != nil {
+			poll.CorrectOption = *p.CorrectOption
+		}
+	}
+	poll.AddOptions(p.Options...)
+
+	return w.Bot.Send(tele.ChatID(item.Recipient), poll, replyMarkup(item.Content))
+}
+
+// sendLocation отправляет точку на карте.
+func (w *Worker) sendLocation(item TaskItem) (*tele.Message, error) {
+	l := item.Content.Location
+	if l == nil {
+		logger.Log.Warn("[Worker] sendLocation: нет координат",
+			zap.String("task_id", item.TaskID),
+			zap.Int64("recipient", item.Recipient))
+		return nil, errors.New("location: no location")
+	}
+
+	location := &tele.Location{
+		Lat: float32(l.Latitude),
+		Lng: float32(l.Longitude),
+	}
+
+	return w.Bot.Send(tele.ChatID(item.Recipient), location, replyMarkup(item.Content))
+}
+
+// sendVenue отправляет место (координаты + название и адрес).
+func (w *Worker) sendVenue(item TaskItem) (*tele.Message, error) {
+	v := item.Content.Venue
+	if v == nil {
+		logger.Log.Warn("[Worker] sendVenue: нет описания места",
+			zap.String("task_id", item.TaskID),
+			zap.Int64("recipient", item.Recipient))
+		return nil, errors.New("venue: no venue")
+	}
+
+	venue := &tele.Venue{
+		Location: tele.Location{
+			Lat: float32(v.Latitude),
+			Lng: float32(v.Longitude),
+		},
+		Title:   v.Title,
+		Address: v.Address,
+	}
+
+	return w.Bot.Send(tele.ChatID(item.Recipient), venue, replyMarkup(item.Content))
+}
+
+// sendContact отправляет карточку контакта.
+func (w *Worker) sendContact(item TaskItem) (*tele.Message, error) {
+	c := item.Content.Contact
+	if c == nil {
+		logger.Log.Warn("[Worker] sendContact: нет контакта",
+			zap.String("task_id", item.TaskID),
+			zap.Int64("recipient", item.Recipient))
+		return nil, errors.New("contact: no contact")
+	}
+
+	contact := &sendableContact{Contact: tele.Contact{
+		PhoneNumber: c.PhoneNumber,
+		FirstName:   c.FirstName,
+		LastName:    c.LastName,
+	}}
+
+	return w.Bot.Send(tele.ChatID(item.Recipient), contact, replyMarkup(item.Content))
+}
+
+// sendDice отправляет анимированный эмодзи со случайным значением.
+func (w *Worker) sendDice(item TaskItem) (*tele.Message, error) {
+	dice := tele.Cube
+	if item.Content.Emoji != "" {
+		dice = &tele.Dice{Type: tele.DiceType(item.Content.Emoji)}
+	}
+
+	return w.Bot.Send(tele.ChatID(item.Recipient), dice, replyMarkup(item.Content))
+}
+
+// sendableContact — в telebot у tele.Contact нет метода Send, поэтому
+// отправляем его через sendContact сами. Из опций переносятся только кнопки.
+type sendableContact struct {
+	tele.Contact
+}
+
+func (c *sendableContact) Send(b *tele.Bot, to tele.Recipient, opt *tele.SendOptions) (*tele.Message, error) {
+	params := map[string]string{
+		"chat_id":      to.Recipient(),
+		"phone_number": c.PhoneNumber,
+		"first_name":   c.FirstName,
+	}
+	if c.LastName != "" {
+		params["last_name"] = c.LastName
+	}
+	if opt != nil && opt.ReplyMarkup != nil {
+		markup, err := json.Marshal(opt.ReplyMarkup)
+		if err != nil {
+			return nil, err
+		}
+		params["reply_markup"] = string(markup)
+	}
+
+	data, err := b.Raw("sendContact", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Result *tele.Message `json:"result"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// sendCopy копирует существующее сообщение (без ссылки на источник),
+// сохраняя форматирование, медиа и кнопки.
+func (w *Worker) sendCopy(item TaskItem) (*tele.Message, error) {
+	c := item.Content
+	if c.FromChatID == 0 || c.MessageID == 0 {
+		logger.Log.Warn("[Worker] sendCopy: нет FromChatID или MessageID",
+			zap.String("task_id", item.TaskID),
+			zap.Int64("recipient", item.Recipient))
+		return nil, errors.New("copy: no FromChatID or MessageID")
+	}
+
+	return w.Bot.Copy(tele.ChatID(item.Recipient), sourceMessage(c), replyMarkup(item.Content))
+}
+
+// sendForward пересылает существующее сообщение со ссылкой на источник.
+func (w *Worker) sendForward(item TaskItem) (*tele.Message, error) {
+	c := item.Content
+	if c.FromChatID == 0 || c.MessageID == 0 {
+		logger.Log.Warn("[Worker] sendForward: нет FromChatID или MessageID",
+			zap.String("task_id", item.TaskID),
+			zap.Int64("recipient", item.Recipient))
+		return nil, errors.New("forward: no FromChatID or MessageID")
+	}
+
+	return w.Bot.Forward(tele.ChatID(item.Recipient), sourceMessage(c))
+}
+
+// sourceMessage возвращает ссылку на исходное сообщение для copy/forward.
+func sourceMessage(c Content) tele.StoredMessage {
+	return tele.StoredMessage{
+		MessageID: strconv.Itoa(c.MessageID),
+		ChatID:    c.FromChatID,
+	}
 }
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestSendContactKeepsButtons(t *testing.T) {
+	var params map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer srv.Close()
+
+	bot, err := tele.NewBot(tele.Settings{Token: "1:test", URL: srv.URL, Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Worker{Bot: bot}
+
+	item := TaskItem{Recipient: 42, Content: Content{
+		Type:    "contact",
+		Contact: &Contact{PhoneNumber: "+10000000000", FirstName: "Support"},
+		Buttons: [][]Button{{{Text: "Сайт", URL: "https://example.com"}}},
+	}}
+	if _, err := w.sendContact(item); err != nil {
+		t.Fatal(err)
+	}
+
+	var markup tele.ReplyMarkup
+	if err := json.Unmarshal([]byte(params["reply_markup"]), &markup); err != nil {
+		t.Fatalf("reply_markup %q: %v", params["reply_markup"], err)
+	}
+	if len(markup.InlineKeyboard) != 1 || markup.InlineKeyboard[0][0].URL != "https://example.com" {
+		t.Fatalf("reply_markup = %q", params["reply_markup"])
+	}
+}
@@ -2,8 +2,10 @@ package worker
 
 import (
 	"GoBlast/pkg/logger"
-	"fmt"
+	"GoBlast/pkg/metrics"
+	"errors"
 	"go.uber.org/zap"
+	tele "gopkg.in/telebot.v4"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,12 +15,13 @@ import (
 // ErrorMapping — карта действий для различных типов ошибок.
 // Допустим, если "chat not found (400)", мы распознаём как "NOT_FOUND".
 var ErrorMapping = map[string]func(*Worker, TaskItem, error){
-	"FLOOD_WAIT":     handleFloodWait,
-	"UNAUTHORIZED":   handleUnauthorized,
-	"NOT_FOUND":      handleNotFound, // если хотим
-	"BAD_REQUEST":    handleBadRequest,
-	"INTERNAL_ERROR": handleInternalError,
-	"default":        handleDefaultError,
+	"FLOOD_WAIT":          handleFloodWait,
+	"UNAUTHORIZED":        handleUnauthorized,
+	"NOT_FOUND":           handleNotFound, // если хотим
+	"BAD_REQUEST":         handleBadRequest,
+	"blocked by the user": handleBlocked,
+	"INTERNAL_ERROR":      handleInternalError,
+	"default":             handleDefaultError,
 }
 
 // handleTgError разбирает err.Error(), ищет ключевые слова, вызывает соответствующий хендлер.
@@ -33,6 +36,19 @@ func (w *Worker) handleTgError(item TaskItem, err error) error {
 		zap.Int64("recipient", item.Recipient),
 		zap.String("error", msg))
 
+	// Отозванный токен: telebot сообщает "Unauthorized (401)", ключ ErrorMapping его не находит
+	if isUnauthorized(err) {
+		handleUnauthorized(w, item, err)
+		return err
+	}
+
+	// 429 Too Many Requests: telebot возвращает FloodError с retry_after
+	var flood tele.FloodError
+	if errors.As(err, &flood) {
+		handleFloodWait(w, item, err)
+		return err
+	}
+
 	// Если видим «chat not found (400)», считаем это "NOT_FOUND"
 	if strings.Contains(msg, "chat not found (400)") {
 		handleNotFound(w, item, err)
@@ -53,9 +69,13 @@ func (w *Worker) handleTgError(item TaskItem, err error) error {
 	return err
 }
 
-func parseFloodWait(msg string) int {
+func parseFloodWait(err error) int {
+	var flood tele.FloodError
+	if errors.As(err, &flood) {
+		return flood.RetryAfter
+	}
 	re := regexp.MustCompile(`FLOOD_WAIT_(\d+)`)
-	matches := re.FindStringSubmatch(msg)
+	matches := re.FindStringSubmatch(err.Error())
 	if len(matches) == 2 {
 		sec, _ := strconv.Atoi(matches[1])
 		return sec
@@ -63,30 +83,37 @@ func parseFloodWait(msg string) int {
 	return 0
 }
 func handleFloodWait(w *Worker, item TaskItem, err error) {
-	waitSeconds := parseFloodWait(err.Error())
+	waitSeconds := parseFloodWait(err)
+	metrics.WorkerFloodWaits.WithLabelValues(w.metricsBot).Inc()
+	metrics.WorkerFloodWaitSeconds.WithLabelValues(w.metricsBot).Add(float64(waitSeconds))
 	if waitSeconds > 0 {
-		logger.Log.Warn("[Worker] FLOOD WAIT обнаружен, ожидаем",
+		logger.Log.Warn("[Worker] FLOOD WAIT обнаружен, получатель отложен",
 			zap.String("task_id", item.TaskID),
 			zap.Int64("recipient", item.Recipient),
 			zap.Int("wait_seconds", waitSeconds))
-
-		time.Sleep(time.Duration(waitSeconds) * time.Second)
 	}
-	// По окончании всё равно incrementFailed
-	w.incrementFailed(item.TaskID, item.Content.Type, err)
+	// Горутина отправки не ждёт: получатель повторяется не раньше retry_after,
+	// после последней попытки — incrementFailed
+	if !w.retryLater(item, time.Duration(waitSeconds)*time.Second) {
+		w.incrementFailed(item, err)
+	}
 }
 
-// handleUnauthorized
+// isUnauthorized — Telegram отклонил токен бота (401)
+func isUnauthorized(err error) bool {
+	return errors.Is(err, tele.ErrUnauthorized) || strings.Contains(err.Error(), "Unauthorized (401)")
+}
+
+// handleUnauthorized — токен бота отозван: остальным получателям писать бессмысленно,
+// поэтому воркер останавливается, а бот отключается вместе с его задачами
 func handleUnauthorized(w *Worker, item TaskItem, err error) {
-	logger.Log.Error("[Worker] UNAUTHORIZED ошибка, завершение обработки получателя",
+	logger.Log.Error("[Worker] UNAUTHORIZED: токен бота отклонён, воркер останавливается",
 		zap.String("task_id", item.TaskID),
 		zap.Int64("recipient", item.Recipient),
 		zap.Error(err))
 
-	notifyAdmin(fmt.Sprintf("UNAUTHORIZED для задачи %s, получатель %d",
-		item.TaskID, item.Recipient))
-
-	w.incrementFailed(item.TaskID, item.Content.Type, err)
+	w.incrementFailed(item, err)
+	w.disable(reasonBotUnauthorized)
 }
 
 // handleNotFound — как пример
@@ -95,7 +122,22 @@ func handleNotFound(w *Worker, item TaskItem, err error) {
 		zap.String("task_id", item.TaskID),
 		zap.Int64("recipient", item.Recipient),
 		zap.Error(err))
-	w.incrementFailed(item.TaskID, item.Content.Type, err)
+	w.incrementFailed(item, err)
+}
+
+// handleBlocked — получатель заблокировал бота: больше ему не пишем
+func handleBlocked(w *Worker, item TaskItem, err error) {
+	logger.Log.Warn("[Worker] Бот заблокирован получателем",
+		zap.String("task_id", item.TaskID),
+		zap.Int64("recipient", item.Recipient),
+		zap.Error(err))
+
+	if e := w.Repo.MarkBlocked(item.UserID, item.BotID, item.Recipient); e != nil {
+		logger.Log.Error("[Worker] Ошибка пометки подписчика заблокировавшим",
+			zap.Int64("recipient", item.Recipient),
+			zap.Error(e))
+	}
+	w.incrementFailed(item, err)
 }
 
 // handleBadRequest
@@ -104,7 +146,7 @@ func handleBadRequest(w *Worker, item TaskItem, err error) {
 		zap.String("task_id", item.TaskID),
 		zap.Int64("recipient", item.Recipient),
 		zap.Error(err))
-	w.incrementFailed(item.TaskID, item.Content.Type, err)
+	w.incrementFailed(item, err)
 }
 
 // handleInternalError
@@ -114,11 +156,10 @@ func handleInternalError(w *Worker, item TaskItem, err error) {
 		zap.Int64("recipient", item.Recipient),
 		zap.Error(err))
 
-	// повторно отправим через 5 секунд
-	go func() {
-		time.Sleep(5 * time.Second)
-		w.TaskChan <- item
-	}()
+	// повторно отправим по политике повторов (если воркер к тому времени не остановлен)
+	if !w.retryLater(item, 0) {
+		w.incrementFailed(item, err)
+	}
 }
 
 // handleDefaultError
@@ -128,11 +169,5 @@ func handleDefaultError(w *Worker, item TaskItem, err error) {
 		zap.Int64("recipient", item.Recipient),
 		zap.Error(err))
 
-	w.incrementFailed(item.TaskID, item.Content.Type, err)
-}
-
-// notifyAdmin — отправить уведомление
-func notifyAdmin(message string) {
-	logger.Log.Warn("[AdminNotify] Уведомление администратора",
-		zap.String("message", message))
+	w.incrementFailed(item, err)
 }
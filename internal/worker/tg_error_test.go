@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"GoBlast/internal/bots"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestIsUnauthorized(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{tele.ErrUnauthorized, true},
+		{fmt.Errorf("telebot: %w", tele.ErrUnauthorized), true},
+		{errors.New("telegram: Unauthorized (401)"), true},
+		{errors.New("telegram: Bad Request: chat not found (400)"), false},
+		{tele.ErrBlockedByUser, false},
+	}
+	for _, c := range cases {
+		if got := isUnauthorized(c.err); got != c.want {
+			t.Errorf("isUnauthorized(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	cases := map[error]string{
+		errors.New("telegram: Bad Request: chat not found (400)"):            "NOT_FOUND",
+		errors.New("FLOOD_WAIT_30"):                                          "FLOOD_WAIT",
+		errors.New("telegram: Forbidden: bot was blocked by the user (403)"): "BLOCKED",
+		tele.ErrUnauthorized:                                                 "UNAUTHORIZED",
+		errors.New("something else"):                                         "other",
+	}
+	for err, want := range cases {
+		if got := errorCode(err); got != want {
+			t.Errorf("errorCode(%v) = %q, want %q", err, got, want)
+		}
+	}
+}
+
+func TestHandleFloodWaitDefersRecipient(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	w := testManagedWorker(botRef{BotID: 1}, time.Now()).Worker
+	w.TaskChan = make(chan TaskItem, 1)
+	w.limits = bots.Limits{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	w.stats["t1"] = &models.Stats{ExpectedCount: 1}
+	defer close(w.quit)
+
+	start := time.Now()
+	handleFloodWait(w, TaskItem{TaskID: "t1", Recipient: 42}, tele.FloodError{RetryAfter: 30})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("flood wait blocked the send goroutine for %v", elapsed)
+	}
+	if w.stats["t1"].TotalRetried != 1 {
+		t.Fatalf("TotalRetried = %d, want 1", w.stats["t1"].TotalRetried)
+	}
+
+	// Получатель возвращается в очередь не раньше retry_after, а не через Backoff
+	select {
+	case item := <-w.TaskChan:
+		t.Fatalf("recipient %d requeued before retry_after", item.Recipient)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,165 @@
+package worker
+
+import (
+	"GoBlast/internal/bots"
+	"GoBlast/internal/subscribers"
+	"GoBlast/internal/users"
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/queue"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+	tele "gopkg.in/telebot.v4"
+	"gorm.io/gorm"
+)
+
+// UpdatesPolling — режим, в котором планировщик сам опрашивает Telegram (getUpdates)
+const UpdatesPolling = "polling"
+
+// botUpdatesEvent — событие смены режима обновлений от API (subject "bots.updates").
+// Задан BotID (бот из /api/bots) или UserID (бот по умолчанию аккаунта).
+type botUpdatesEvent struct {
+	UserID uint   `json:"user_id"`
+	BotID  uint   `json:"bot_id"`
+	Mode   string `json:"mode"`
+}
+
+// UpdatesManager держит long polling для ботов пользователей в режиме polling
+// и учитывает подписчиков из полученных обновлений.
+// Запускается командой goblast scheduler: Telegram допускает только один
+// getUpdates на токен, а планировщик работает в одном экземпляре.
+type UpdatesManager struct {
+	mu       sync.Mutex
+	pollers  map[botRef]*tele.Bot
+	userRepo *users.AuthUserRepository
+	botRepo  *bots.BotRepository
+	repo     *subscribers.SubscriberRepository
+}
+
+func NewUpdatesManager(db *gorm.DB) *UpdatesManager {
+	return &UpdatesManager{
+		pollers:  make(map[botRef]*tele.Bot),
+		userRepo: users.NewAuthUserRepository(db),
+		botRepo:  bots.NewBotRepository(db),
+		repo:     subscribers.NewSubscriberRepository(db),
+	}
+}
+
+// Start запускает polling для всех ботов в режиме polling и подписывается на смену режима
+func (um *UpdatesManager) Start(natsClient *queue.NATSClient) error {
+	list, err := um.userRepo.FindByUpdatesMode(UpdatesPolling)
+	if err != nil {
+		return err
+	}
+	for _, user := range list {
+		um.startPolling(botRef{UserID: user.ID})
+	}
+	botList, err := um.botRepo.FindByUpdatesMode(UpdatesPolling)
+	if err != nil {
+		return err
+	}
+	for _, bot := range botList {
+		um.startPolling(botRef{BotID: bot.ID})
+	}
+
+	_, err = natsClient.Conn.Subscribe("bots.updates", func(msg *nats.Msg) {
+		var event botUpdatesEvent
+		if e := json.Unmarshal(msg.Data, &event); e != nil {
+			logger.Log.Error("Ошибка десериализации bots.updates", zap.Error(e))
+			return
+		}
+		ref := botRef{UserID: event.UserID}
+		if event.BotID != 0 {
+			ref = botRef{BotID: event.BotID}
+		}
+		if event.Mode == UpdatesPolling {
+			um.startPolling(ref)
+		} else {
+			um.stopPolling(ref)
+		}
+	})
+	return err
+}
+
+// Stop останавливает все опросы
+func (um *UpdatesManager) Stop() {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	for ref, bot := range um.pollers {
+		bot.Stop()
+		delete(um.pollers, ref)
+	}
+}
+
+// encryptedToken загружает зашифрованный токен бота: из /api/bots или бота по умолчанию аккаунта
+func (um *UpdatesManager) encryptedToken(ref botRef) (string, error) {
+	if ref.BotID != 0 {
+		bot, err := um.botRepo.FindByID(ref.BotID)
+		if err != nil {
+			return "", err
+		}
+		return bot.Token, nil
+	}
+	user, err := um.userRepo.FindByID(ref.UserID)
+	if err != nil {
+		return "", err
+	}
+	return user.Token, nil
+}
+
+func (um *UpdatesManager) startPolling(ref botRef) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	if _, ok := um.pollers[ref]; ok {
+		return
+	}
+	fields := []zap.Field{zap.Uint("user_id", ref.UserID), zap.Uint("bot_id", ref.BotID)}
+
+	encrypted, err := um.encryptedToken(ref)
+	if err != nil {
+		logger.Log.Error("Ошибка получения бота", append(fields, zap.Error(err))...)
+		return
+	}
+	botToken, err := bots.DecryptToken(encrypted)
+	if err != nil {
+		logger.Log.Error("Ошибка дешифрования токена", append(fields, zap.Error(err))...)
+		return
+	}
+
+	// Обновления перехватываются до маршрутизации telebot: обработчики не нужны
+	poller := tele.NewMiddlewarePoller(&tele.LongPoller{
+		Timeout:        10 * time.Second,
+		AllowedUpdates: subscribers.AllowedUpdates,
+	}, func(upd *tele.Update) bool {
+		if err := um.repo.Ingest(ref.UserID, ref.BotID, *upd); err != nil {
+			logger.Log.Error("Ошибка учёта подписчика", append(fields, zap.Error(err))...)
+		}
+		return false
+	})
+
+	bot, err := tele.NewBot(tele.Settings{Token: botToken, Poller: poller})
+	if err != nil {
+		logger.Log.Error("Ошибка создания бота для polling", append(fields, zap.Error(err))...)
+		return
+	}
+	um.pollers[ref] = bot
+	go bot.Start()
+
+	logger.Log.Info("[Updates] Запущен polling бота", fields...)
+}
+
+func (um *UpdatesManager) stopPolling(ref botRef) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	bot, ok := um.pollers[ref]
+	if !ok {
+		return
+	}
+	bot.Stop()
+	delete(um.pollers, ref)
+
+	logger.Log.Info("[Updates] Polling бота остановлен", zap.Uint("user_id", ref.UserID), zap.Uint("bot_id", ref.BotID))
+}
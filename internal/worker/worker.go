@@ -1,13 +1,20 @@
 package worker
 
 import (
+	"GoBlast/internal/bots"
+	"GoBlast/internal/tasks"
+	"GoBlast/pkg/abtest"
+	"GoBlast/pkg/linktrack"
 	"GoBlast/pkg/logger"
+	"GoBlast/pkg/metrics"
+	"GoBlast/pkg/ratelimit"
 	"GoBlast/pkg/storage/models"
 	"context"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
-	"golang.org/x/time/rate"
 	tele "gopkg.in/telebot.v4"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,40 +23,120 @@ import (
 // WorkerRepo — интерфейс для репозитория, чтобы обновлять статус и статистику в БД,
 // а также, если нужно, публиковать событие о завершении задачи.
 type WorkerRepo interface {
-	UpdateStatusAndStats(taskID, newStatus string, stats models.Stats) error
-	PublishCompleteStatus(taskID string, finalStats models.Stats) error
+	ChangeStatus(taskID string, to models.TaskStatus, change tasks.StatusChange) error
+	PublishCompleteStatus(taskID string, status models.TaskStatus, finalStats models.Stats) error
+	SaveMessage(taskID string, recipient int64, messageID int) error
+	ListMessages(taskID string) ([]models.TaskMessage, error)
+	MembersPage(audienceID uint, tagExpr string, afterID uint, limit int) ([]models.AudienceMember, error)
+	SubscribersPage(userID, botID uint, languageCode string, afterID uint, limit int) ([]models.Subscriber, error)
+	SuppressedAmong(userID, botID uint, chatIDs []int64) (map[int64]bool, error)
+	DeliveredAmong(taskID string, recipients []int64) (map[int64]bool, error)
+	MarkBlocked(userID, botID uint, chatID int64) error
 }
 
 // BotInterface — упрощённый интерфейс телеграм-бота (для тестирования).
 type BotInterface interface {
 	Send(to tele.Recipient, what interface{}, options ...interface{}) (*tele.Message, error)
+	Copy(to tele.Recipient, msg tele.Editable, options ...interface{}) (*tele.Message, error)
+	Forward(to tele.Recipient, msg tele.Editable, options ...interface{}) (*tele.Message, error)
+	Edit(msg tele.Editable, what interface{}, options ...interface{}) (*tele.Message, error)
+	EditCaption(msg tele.Editable, caption string, options ...interface{}) (*tele.Message, error)
+	EditReplyMarkup(msg tele.Editable, markup *tele.ReplyMarkup) (*tele.Message, error)
+	Delete(msg tele.Editable) error
 }
 
 // TaskItem описывает один «подзадачу» (конкретному получателю).
 type TaskItem struct {
 	TaskID    string
+	UserID    uint   // автор задачи и владелец бота по умолчанию (для учёта заблокировавших бота)
+	BotID     uint   // бот из /api/bots; 0 — бот по умолчанию аккаунта UserID
+	Action    string // ActionSend, ActionEdit или ActionRecall
+	Variant   string // имя варианта A/B-теста (пусто, если вариантов нет)
 	Recipient int64
+	MessageID int // ID ранее отправленного сообщения (для edit/recall)
 	Content   Content
+	Attempt   int // номер попытки отправки, с 1
+}
+
+// storedMessage возвращает ссылку на ранее отправленное сообщение получателю.
+func (item TaskItem) storedMessage() tele.StoredMessage {
+	return tele.StoredMessage{
+		MessageID: strconv.Itoa(item.MessageID),
+		ChatID:    item.Recipient,
+	}
 }
 
 // Worker отвечает за рассылку сообщений от имени одного бота (botToken).
 type Worker struct {
-	Bot         BotInterface
-	WG          sync.WaitGroup
-	RateLimiter *rate.Limiter
-	TaskChan    chan TaskItem
-	NumWorkers  int
-	Repo        WorkerRepo
+	Bot        BotInterface
+	WG         sync.WaitGroup
+	Limiter    ratelimit.Limiter // бюджеты бота и чатов, общие для всех реплик
+	TaskChan   chan TaskItem
+	NumWorkers int // текущее число горутин отправки; под mu
+	Repo       WorkerRepo
+
+	botKey     string      // ключ бюджета бота в Limiter
+	metricsBot string      // метка bot в метриках (botRef.label()), задаётся до Start
+	limits     bots.Limits // конкурентность, скорости и повторы; под mu, меняются на лету
+	priority   string      // приоритет последней задачи; под mu
+	loops      []chan struct{}
+
+	mu        sync.Mutex
+	stats     map[string]*models.Stats     // key=TaskID -> накопленная статистика
+	enqueuing map[string]int               // key=TaskID -> сколько AddTask ещё выкладывают получателей
+	chunks    map[string]*chunkProgress    // key=TaskID -> получение частей задачи из NATS
+	sources   map[string][]TaskNATSMessage // key=TaskID -> принятые части (для передачи новому владельцу бота)
+	cancelled map[string]int64             // key=TaskID отменённой задачи -> сколько её получателей ещё в канале или в отправке
+
+	quit    chan struct{} // закрывается при остановке воркера
+	ctx     context.Context
+	cancel  context.CancelFunc // прерывает ожидание бюджета при остановке
+	stopped bool
+
+	lastActive time.Time // последняя полученная задача или обработанный получатель
+
+	// onUnauthorized вызывается (в отдельной горутине) после остановки воркера из-за ответа 401
+	onUnauthorized func(reason string)
+}
+
+// reasonBotUnauthorized — причина прерывания задач бота с отозванным токеном
+const reasonBotUnauthorized = "bot token was rejected by Telegram (401 Unauthorized): it was revoked or changed in @BotFather; update the bot token and create the task again"
 
-	mu    sync.Mutex
-	stats map[string]*models.Stats // key=TaskID -> накопленная статистика
+// chunkProgress отслеживает части задачи, опубликованной несколькими сообщениями.
+// Общее число частей становится известно с приходом последней из них.
+type chunkProgress struct {
+	received int
+	total    int // 0, пока последняя часть не пришла
 }
 
-// NewWorker создаёт воркер с начальным rate-limit (по умолчанию 10 msg/sec).
-func NewWorker(botToken string, numWorkers int, repo WorkerRepo) (*Worker, error) {
+func (p *chunkProgress) complete() bool {
+	return p.total > 0 && p.received == p.total
+}
+
+// audiencePageSize — сколько участников аудитории читается из БД за раз
+const audiencePageSize = 1000
+
+// queueDepthInterval — как часто глубина очереди воркера выгружается в метрики
+const queueDepthInterval = time.Second
+
+// chatRate — предел Telegram для одного чата
+var chatRate = ratelimit.Every(time.Second)
+
+// limiterKey — ключ бюджета бота: числовой ID бота из токена одинаков на всех репликах
+// и не раскрывает сам токен
+func limiterKey(botToken string) string {
+	if i := strings.IndexByte(botToken, ':'); i > 0 {
+		return "tg-" + botToken[:i]
+	}
+	return "tg-unknown"
+}
+
+// NewWorker создаёт воркер с ограничениями limits (до первой задачи — скорость приоритета medium).
+func NewWorker(botToken string, limits bots.Limits, repo WorkerRepo, limiter ratelimit.Limiter) (*Worker, error) {
 	logger.Log.Info("[Worker] Инициализация воркера",
-		zap.String("bot_token", botToken),
-		zap.Int("num_workers", numWorkers))
+		zap.String("bot", limiterKey(botToken)),
+		zap.Int("num_workers", limits.Concurrency),
+		zap.Int("queue_size", limits.QueueSize))
 
 	bot, err := tele.NewBot(tele.Settings{
 		Token:     botToken,
@@ -61,83 +148,667 @@ func NewWorker(botToken string, numWorkers int, repo WorkerRepo) (*Worker, error
 		return nil, err
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	w := &Worker{
-		Bot:         bot,
-		RateLimiter: rate.NewLimiter(rate.Every(time.Second/10), 1), // medium
-		TaskChan:    make(chan TaskItem),
-		NumWorkers:  numWorkers,
-		Repo:        repo,
-		stats:       make(map[string]*models.Stats),
+		Bot:        bot,
+		Limiter:    limiter,
+		TaskChan:   make(chan TaskItem, limits.QueueSize),
+		Repo:       repo,
+		botKey:     limiterKey(botToken),
+		metricsBot: limiterKey(botToken),
+		limits:     limits,
+		priority:   "medium",
+		stats:      make(map[string]*models.Stats),
+		enqueuing:  make(map[string]int),
+		chunks:     make(map[string]*chunkProgress),
+		sources:    make(map[string][]TaskNATSMessage),
+		cancelled:  make(map[string]int64),
+		quit:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+		lastActive: time.Now(),
 	}
 	return w, nil
 }
 
-// Start запускает N горутин (workerLoop), каждая читает из TaskChan и обрабатывает сообщения.
+// Start запускает limits.Concurrency горутин (workerLoop), каждая читает из TaskChan и обрабатывает сообщения.
 func (w *Worker) Start() {
-	logger.Log.Info("[Worker] Запуск воркера", zap.Int("num_workers", w.NumWorkers))
-	for i := 0; i < w.NumWorkers; i++ {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	logger.Log.Info("[Worker] Запуск воркера", zap.Int("num_workers", w.limits.Concurrency))
+	w.scaleLocked(w.limits.Concurrency)
+	w.WG.Add(1)
+	go w.monitorQueue()
+}
+
+// SetLimits применяет новые ограничения к работающему воркеру: число горутин,
+// скорости и повторы меняются сразу, размер очереди — при следующем запуске воркера
+func (w *Worker) SetLimits(limits bots.Limits) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return
+	}
+	w.limits = limits
+	w.scaleLocked(limits.Concurrency)
+	logger.Log.Info("[Worker] Ограничения обновлены",
+		zap.String("bot", w.botKey),
+		zap.Int("num_workers", limits.Concurrency),
+		zap.Float64("rate_high", limits.RateHigh),
+		zap.Float64("rate_medium", limits.RateMedium),
+		zap.Float64("rate_low", limits.RateLow),
+		zap.Int("retry_max_attempts", limits.MaxAttempts))
+}
+
+// scaleLocked доводит число горутин workerLoop до n; вызывается под mu
+func (w *Worker) scaleLocked(n int) {
+	for len(w.loops) < n {
+		stop := make(chan struct{})
+		w.loops = append(w.loops, stop)
 		w.WG.Add(1)
-		go w.workerLoop(i)
+		go w.workerLoop(len(w.loops)-1, stop)
+	}
+	for len(w.loops) > n {
+		last := len(w.loops) - 1
+		close(w.loops[last])
+		w.loops = w.loops[:last]
+	}
+	w.NumWorkers = len(w.loops)
+	metrics.WorkerSendGoroutines.WithLabelValues(w.metricsBot).Set(float64(w.NumWorkers))
+}
+
+// Stop останавливает горутины воркера и прерывает его незавершённые задачи с причиной reason.
+// Возвращает false, если воркер уже остановлен.
+func (w *Worker) Stop(reason string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return false
+	}
+	w.stopped = true
+	close(w.quit)
+	w.cancel()
+
+	for taskID, st := range w.stats {
+		st.Finish(time.Now())
+		change := tasks.StatusChange{Actor: tasks.ActorWorker, Reason: reason, Stats: st}
+		if err := w.Repo.ChangeStatus(taskID, models.TaskFailed, change); err != nil {
+			logger.Log.Error("[Worker] Ошибка прерывания задачи",
+				zap.String("task_id", taskID),
+				zap.Error(err))
+		}
+	}
+	w.resetTasksLocked()
+
+	logger.Log.Warn("[Worker] Воркер остановлен", zap.String("reason", reason))
+	return true
+}
+
+// Handoff останавливает воркер, не прерывая его задачи: дожидается отправок, которые уже идут,
+// возвращает задачи в queued и отдаёт принятые части их сообщений, чтобы рассылку продолжил
+// новый владелец бота. Он не отправляет повторно получателям из task_messages; правка и отзыв
+// повторяются целиком. Возвращает nil, если воркер уже остановлен.
+func (w *Worker) Handoff(reason string) []TaskNATSMessage {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return nil
+	}
+	w.stopped = true
+	close(w.quit)
+	w.cancel()
+	w.mu.Unlock()
+
+	// Горутина отправки дорабатывает текущего получателя и сохраняет ID сообщения,
+	// иначе новый владелец отправил бы его повторно
+	w.WG.Wait()
+
+	w.mu.Lock()
+	stats, sources := w.stats, w.sources
+	w.resetTasksLocked()
+	w.mu.Unlock()
+
+	var handed []TaskNATSMessage
+	for taskID, parts := range sources {
+		st := stats[taskID]
+		if st == nil {
+			continue
+		}
+		change := tasks.StatusChange{Actor: tasks.ActorWorker, Reason: reason}
+		if err := w.Repo.ChangeStatus(taskID, models.TaskQueued, change); err != nil {
+			logger.Log.Error("[Worker] Ошибка возврата задачи в очередь",
+				zap.String("task_id", taskID),
+				zap.Error(err))
+		}
+		startedAt := st.StartTime
+		for _, part := range parts {
+			part.Handoffs++
+			part.StartedAt = &startedAt
+			handed = append(handed, part)
+		}
+	}
+
+	logger.Log.Warn("[Worker] Воркер передал задачи",
+		zap.String("reason", reason),
+		zap.Int("parts", len(handed)))
+	return handed
+}
+
+// resetTasksLocked забывает задачи остановленного воркера; вызывается под mu
+func (w *Worker) resetTasksLocked() {
+	w.stats = make(map[string]*models.Stats)
+	w.enqueuing = make(map[string]int)
+	w.chunks = make(map[string]*chunkProgress)
+	w.sources = make(map[string][]TaskNATSMessage)
+	w.cancelled = make(map[string]int64)
+	metrics.DeleteWorkerGauges(w.metricsBot)
+}
+
+// CancelTask прекращает рассылку задачи, отменённой через API или не опубликованной целиком:
+// её получатели, ещё ждущие в очереди, пропускаются. Статус задачи уже сменил API.
+// false — воркер задачу не рассылает.
+func (w *Worker) CancelTask(taskID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	st := w.stats[taskID]
+	if st == nil {
+		return false
+	}
+	// Получатели, уже выложенные в канал, пропускаются по одному, пока отмена не забудется
+	w.cancelled[taskID] = st.ExpectedCount - st.ProcessedCount
+	delete(w.stats, taskID)
+	delete(w.chunks, taskID)
+	delete(w.sources, taskID)
+	logger.Log.Info("[Worker] Рассылка задачи остановлена по запросу API", zap.String("task_id", taskID))
+	w.forgetCancelledLocked(taskID)
+	return true
+}
+
+// skipCancelled сообщает, что получатель отменённой задачи не должен обрабатываться,
+// и учитывает, что он покинул канал
+func (w *Worker) skipCancelled(taskID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, cancelled := w.cancelled[taskID]; !cancelled {
+		return false
+	}
+	w.releaseCancelledLocked(taskID)
+	return true
+}
+
+// releaseCancelledLocked учитывает обработанного получателя отменённой задачи. Вызывается под mu.
+func (w *Worker) releaseCancelledLocked(taskID string) {
+	if _, cancelled := w.cancelled[taskID]; cancelled {
+		w.cancelled[taskID]--
+		w.forgetCancelledLocked(taskID)
 	}
 }
 
-// AddTask выставляет приоритет (меняет RateLimiter), заводит/дополняет статистику
-// и выкладывает всех получателей (TaskItem) в канал.
-func (w *Worker) AddTask(task TaskNATSMessage) {
+// forgetCancelledLocked забывает отмену, когда получателей задачи не осталось ни в канале,
+// ни в выкладке. Части, пришедшие позже, отсекает markRunning по статусу задачи. Вызывается под mu.
+func (w *Worker) forgetCancelledLocked(taskID string) {
+	if left, cancelled := w.cancelled[taskID]; cancelled && left <= 0 && w.enqueuing[taskID] == 0 {
+		delete(w.cancelled, taskID)
+	}
+}
+
+// WorkerStatus — состояние воркера одного бота для админки
+type WorkerStatus struct {
+	Bot           string    `json:"bot"` // bot:<id> или user:<id>
+	BotID         uint      `json:"bot_id,omitempty"`
+	UserID        uint      `json:"user_id,omitempty"`
+	Goroutines    int       `json:"goroutines"`
+	InFlightTasks int       `json:"in_flight_tasks"`
+	QueueDepth    int64     `json:"queue_depth"` // получателей, ещё не обработанных в текущих задачах
+	LastActive    time.Time `json:"last_active"`
+}
+
+// status снимает состояние воркера
+func (w *Worker) status() WorkerStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	st := WorkerStatus{
+		Goroutines:    w.NumWorkers,
+		InFlightTasks: len(w.stats),
+		LastActive:    w.lastActive,
+	}
+	for _, taskStats := range w.stats {
+		if left := taskStats.ExpectedCount - taskStats.ProcessedCount; left > 0 {
+			st.QueueDepth += left
+		}
+	}
+	return st
+}
+
+// idleSince возвращает true, если у воркера нет задач и он не получал работы с момента before
+func (w *Worker) idleSince(before time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.stopped && len(w.stats) == 0 && len(w.enqueuing) == 0 && len(w.chunks) == 0 &&
+		!w.lastActive.After(before)
+}
+
+// disable останавливает воркер бота с отозванным токеном и сообщает об этом BotManager
+func (w *Worker) disable(reason string) {
+	if w.Stop(reason) && w.onUnauthorized != nil {
+		go w.onUnauthorized(reason)
+	}
+}
+
+// enqueue выкладывает подзадачу в канал; false — воркер остановлен
+func (w *Worker) enqueue(item TaskItem) bool {
+	select {
+	case w.TaskChan <- item:
+		return true
+	case <-w.quit:
+		return false
+	}
+}
+
+// monitorQueue раз в queueDepthInterval выгружает глубину очереди в метрики, не занимая mu
+// на каждом получателе. Это единственный, кто пишет метрику, поэтому после остановки
+// воркера он удаляет её сам и серия не появляется снова.
+func (w *Worker) monitorQueue() {
+	defer w.WG.Done()
+	defer metrics.WorkerQueueDepth.DeleteLabelValues(w.metricsBot)
+
+	ticker := time.NewTicker(queueDepthInterval)
+	defer ticker.Stop()
+	for {
+		metrics.WorkerQueueDepth.WithLabelValues(w.metricsBot).Set(float64(len(w.TaskChan)))
+		select {
+		case <-w.quit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// AddTask выставляет приоритет (меняет скорость бота), заводит/дополняет статистику
+// и выкладывает всех получателей (TaskItem) в канал. Возвращает false, если воркер
+// остановлен раньше, чем принял задачу: её нужно передать заново.
+func (w *Worker) AddTask(task TaskNATSMessage) bool {
 	logger.Log.Info("[Worker] Получена задача",
 		zap.String("task_id", task.TaskID),
 		zap.Int("recipients_count", len(task.Recipients)),
+		zap.Uint("audience_id", task.AudienceID),
+		zap.Int("chunk_index", task.ChunkIndex),
+		zap.Bool("more_chunks", task.MoreChunks),
 		zap.String("priority", task.Priority))
 
 	// Настраиваем rate-limit в зависимости от приоритета
 	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		logger.Log.Warn("[Worker] Воркер остановлен, задача не принята", zap.String("task_id", task.TaskID))
+		return false
+	}
+	w.lastActive = time.Now()
 	switch strings.ToLower(task.Priority) {
 	case "high":
-		// 30 msg/sec
-		w.RateLimiter = rate.NewLimiter(rate.Every(time.Second/30), 1)
-		logger.Log.Info("Установлен высокий приоритет (30 msg/sec)")
+		w.priority = "high"
+		logger.Log.Info("Установлен высокий приоритет", zap.Float64("msg_per_sec", w.limits.RateHigh))
 	case "low":
-		// 2 msg/sec
-		w.RateLimiter = rate.NewLimiter(rate.Every(time.Second/2), 1)
-		logger.Log.Info("Установлен низкий приоритет (2 msg/sec)")
+		w.priority = "low"
+		logger.Log.Info("Установлен низкий приоритет", zap.Float64("msg_per_sec", w.limits.RateLow))
 	default:
-		// По умолчанию medium (10 msg/sec)
-		w.RateLimiter = rate.NewLimiter(rate.Every(time.Second/10), 1)
-		logger.Log.Info("Установлен средний приоритет (10 msg/sec)")
+		// По умолчанию medium
+		w.priority = "medium"
+		logger.Log.Info("Установлен средний приоритет", zap.Float64("msg_per_sec", w.limits.RateMedium))
+	}
+
+	_, started := w.stats[task.TaskID]
+	w.mu.Unlock()
+
+	if !started && !w.markRunning(task.TaskID) {
+		logger.Log.Warn("[Worker] Задача уже завершена, рассылка пропущена", zap.String("task_id", task.TaskID))
+		return true
+	}
+	if !w.beginEnqueue(task) {
+		logger.Log.Warn("[Worker] Воркер остановлен, задача не принята", zap.String("task_id", task.TaskID))
+		return false
+	}
+	defer w.endEnqueue(task.TaskID)
+
+	err := w.forEachBatch(task, func(items []TaskItem) bool {
+		w.mu.Lock()
+		st := w.stats[task.TaskID]
+		if st == nil {
+			// Задача прервана остановкой воркера
+			w.mu.Unlock()
+			return false
+		}
+		// Увеличиваем ExpectedCount до того, как получатели попадут в канал
+		st.ExpectedCount += int64(len(items))
+		w.mu.Unlock()
+
+		// Выкладываем получателей в канал
+		for _, item := range items {
+			if !w.enqueue(item) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Log.Error("[Worker] Ошибка подготовки задачи",
+			zap.String("task_id", task.TaskID),
+			zap.String("action", task.Action),
+			zap.Error(err))
+	}
+	return true
+}
+
+// markRunning отмечает начало рассылки задачи. Возвращает false, если задача
+// уже завершена (например, прервана вместе с ботом) и рассылать её не нужно.
+func (w *Worker) markRunning(taskID string) bool {
+	err := w.Repo.ChangeStatus(taskID, models.TaskRunning, tasks.StatusChange{Actor: tasks.ActorWorker})
+	if err == nil {
+		return true
+	}
+	var transitionErr *tasks.TransitionError
+	if errors.As(err, &transitionErr) && transitionErr.From.Terminal() {
+		return false
+	}
+	// Повторная доставка уже запущенной задачи или сбой БД: рассылку не теряем,
+	// а finishTask проведёт оставшуюся в queued задачу через running
+	logger.Log.Warn("[Worker] Ошибка смены статуса задачи на running",
+		zap.String("task_id", taskID),
+		zap.Error(err))
+	return true
+}
+
+// beginEnqueue заводит статистику задачи, учитывает пришедшую часть и отмечает, что её получатели
+// ещё выкладываются в канал. Пока идёт выкладка или не пришли все части, задача не может завершиться,
+// даже если все выложенные уже обработаны: ExpectedCount суммируется по всем частям.
+// Возвращает false, если воркер уже остановлен.
+func (w *Worker) beginEnqueue(task TaskNATSMessage) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return false
+	}
+	taskID := task.TaskID
+	w.enqueuing[taskID]++
+	if _, cancelled := w.cancelled[taskID]; cancelled {
+		// Задача отменена: без статистики forEachBatch не выложит получателей части
+		return true
 	}
 
 	// Заводим/получаем статистику для данного TaskID
-	st, exists := w.stats[task.TaskID]
-	if !exists {
-		st = &models.Stats{
+	if _, exists := w.stats[taskID]; !exists {
+		startTime := time.Now()
+		if task.StartedAt != nil {
+			startTime = *task.StartedAt
+		}
+		w.stats[taskID] = &models.Stats{
 			ByContentType: make(map[string]int64),
 			ErrorCounts:   make(map[string]int64),
-			StartTime:     time.Now(),
+			StartTime:     startTime,
+		}
+	}
+	w.sources[taskID] = append(w.sources[taskID], task)
+
+	progress, exists := w.chunks[taskID]
+	if !exists {
+		progress = &chunkProgress{}
+		w.chunks[taskID] = progress
+	}
+	progress.received++
+	if !task.MoreChunks {
+		progress.total = task.ChunkIndex + 1
+	}
+	return true
+}
+
+// endEnqueue снимает отметку выкладки и завершает задачу, если всё уже обработано
+// (или обрабатывать было нечего, например recall задачи без отправленных сообщений).
+func (w *Worker) endEnqueue(taskID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.enqueuing[taskID]--
+	if w.enqueuing[taskID] <= 0 {
+		delete(w.enqueuing, taskID)
+	}
+	if st := w.stats[taskID]; st != nil {
+		w.checkFinished(taskID, st)
+	} else {
+		w.forgetCancelledLocked(taskID)
+	}
+}
+
+// checkFinished завершает задачу, когда пришли все её части, все выложенные
+// получатели обработаны и новых не ожидается. Вызывается под w.mu.
+func (w *Worker) checkFinished(taskID string, st *models.Stats) {
+	w.lastActive = time.Now()
+	if p := w.chunks[taskID]; p != nil && !p.complete() {
+		return
+	}
+	if w.enqueuing[taskID] == 0 && st.ProcessedCount == st.ExpectedCount {
+		w.finishTask(taskID, st)
+	}
+}
+
+// forEachBatch раскладывает задачу на подзадачи и отдаёт их пачками в fn,
+// пока fn возвращает true. Для отправки — по списку получателей или постранично по аудитории/подписчикам из БД,
+// для edit/recall — по сообщениям, сохранённым при отправке родительской задачи.
+func (w *Worker) forEachBatch(task TaskNATSMessage, fn func([]TaskItem) bool) error {
+	switch task.Action {
+	case ActionEdit, ActionRecall:
+		messages, err := w.Repo.ListMessages(task.ParentID)
+		if err != nil {
+			return err
+		}
+		items := make([]TaskItem, 0, len(messages))
+		for _, m := range messages {
+			items = append(items, TaskItem{
+				TaskID:    task.TaskID,
+				UserID:    task.UserID,
+				BotID:     task.BotID,
+				Action:    task.Action,
+				Recipient: m.Recipient,
+				MessageID: m.MessageID,
+				Content:   task.Content,
+			})
+		}
+		fn(items)
+		return nil
+
+	default:
+		// Задачу передал прежний владелец бота: кому он уже отправил, не отправляем
+		send := func(recipients []int64) (bool, error) {
+			if task.Handoffs > 0 {
+				var err error
+				if recipients, err = w.dropDelivered(task, recipients); err != nil {
+					return false, err
+				}
+			}
+			return fn(sendItems(task, recipients)), nil
+		}
+
+		switch {
+		case task.ToSubscribers:
+			// Подписчики читаются уже без остановивших и заблокировавших бота
+			var afterID uint
+			for {
+				page, err := w.Repo.SubscribersPage(task.UserID, task.BotID, task.LanguageCode, afterID, audiencePageSize)
+				if err != nil {
+					return err
+				}
+				if len(page) == 0 {
+					return nil
+				}
+				recipients := make([]int64, 0, len(page))
+				for _, s := range page {
+					recipients = append(recipients, s.ChatID)
+				}
+				afterID = page[len(page)-1].ID
+				if ok, err := send(recipients); err != nil || !ok {
+					return err
+				}
+			}
+
+		case task.AudienceID != 0:
+			// Аудитория читается из Postgres страницами, а не хранится целиком в сообщении NATS
+			var afterID uint
+			for {
+				page, err := w.Repo.MembersPage(task.AudienceID, task.TagExpr, afterID, audiencePageSize)
+				if err != nil {
+					return err
+				}
+				if len(page) == 0 {
+					return nil
+				}
+				recipients := make([]int64, 0, len(page))
+				for _, m := range page {
+					recipients = append(recipients, m.ChatID)
+				}
+				afterID = page[len(page)-1].ID
+				recipients, err = w.dropSuppressed(task, recipients)
+				if err != nil {
+					return err
+				}
+				if ok, err := send(recipients); err != nil || !ok {
+					return err
+				}
+			}
+
+		default:
+			recipients, err := w.dropSuppressed(task, task.Recipients)
+			if err != nil {
+				return err
+			}
+			_, err = send(recipients)
+			return err
+		}
+	}
+}
+
+// dropSuppressed убирает получателей, которые остановили или заблокировали бота,
+// и учитывает их в TotalSkipped
+func (w *Worker) dropSuppressed(task TaskNATSMessage, recipients []int64) ([]int64, error) {
+	suppressed, err := w.Repo.SuppressedAmong(task.UserID, task.BotID, recipients)
+	if err != nil {
+		return nil, err
+	}
+	if len(suppressed) == 0 {
+		return recipients, nil
+	}
+
+	kept := make([]int64, 0, len(recipients)-len(suppressed))
+	for _, r := range recipients {
+		if !suppressed[r] {
+			kept = append(kept, r)
+		}
+	}
+
+	w.mu.Lock()
+	if st := w.stats[task.TaskID]; st != nil {
+		st.TotalSkipped += int64(len(recipients) - len(kept))
+	}
+	w.mu.Unlock()
+
+	logger.Log.Info("[Worker] Пропущены отписавшиеся получатели",
+		zap.String("task_id", task.TaskID),
+		zap.Int("skipped", len(recipients)-len(kept)))
+	return kept, nil
+}
+
+// dropDelivered убирает получателей, которым сообщение задачи уже отправил прежний
+// владелец бота, и учитывает их как отправленные
+func (w *Worker) dropDelivered(task TaskNATSMessage, recipients []int64) ([]int64, error) {
+	delivered, err := w.Repo.DeliveredAmong(task.TaskID, recipients)
+	if err != nil {
+		return nil, err
+	}
+	if len(delivered) == 0 {
+		return recipients, nil
+	}
+
+	kept := make([]int64, 0, len(recipients)-len(delivered))
+	done := make([]int64, 0, len(delivered))
+	for _, r := range recipients {
+		if delivered[r] {
+			done = append(done, r)
+		} else {
+			kept = append(kept, r)
+		}
+	}
+
+	w.mu.Lock()
+	if st := w.stats[task.TaskID]; st != nil {
+		for _, item := range sendItems(task, done) {
+			st.ExpectedCount++
+			st.ProcessedCount++
+			st.TotalSent++
+			st.ByContentType[item.Content.Type]++
+			if vs := variantStats(st, item.Variant); vs != nil {
+				vs.Sent++
+			}
 		}
-		w.stats[task.TaskID] = st
 	}
-	// Увеличиваем ExpectedCount
-	st.ExpectedCount += int64(len(task.Recipients))
 	w.mu.Unlock()
+	return kept, nil
+}
 
-	// Выкладываем всех получателей в канал
-	for _, recipient := range task.Recipients {
-		w.TaskChan <- TaskItem{
+// sendItems строит подзадачи отправки для получателей
+func sendItems(task TaskNATSMessage, recipients []int64) []TaskItem {
+	weights := make([]int, len(task.Variants))
+	for i, v := range task.Variants {
+		weights[i] = v.Weight
+	}
+
+	items := make([]TaskItem, 0, len(recipients))
+	for _, recipient := range recipients {
+		item := TaskItem{
 			TaskID:    task.TaskID,
+			UserID:    task.UserID,
+			BotID:     task.BotID,
+			Action:    ActionSend,
 			Recipient: recipient,
 			Content:   task.Content,
 		}
+		// Вариант выбирается детерминированно по хешу получателя и задачи
+		if idx := abtest.PickVariant(task.TaskID, recipient, weights); idx >= 0 {
+			item.Variant = task.Variants[idx].Name
+			item.Content = task.Variants[idx].Content
+		}
+		items = append(items, item)
 	}
+	return items
 }
 
-// workerLoop читает из TaskChan, соблюдает RateLimiter, отправляет сообщение
-// и при успехе/ошибке инкрементирует статистику (Sent/Failed).
-func (w *Worker) workerLoop(workerID int) {
+// workerLoop читает из TaskChan, соблюдает бюджеты Limiter, отправляет сообщение
+// и при успехе/ошибке инкрементирует статистику (Sent/Failed). Завершается при остановке воркера.
+func (w *Worker) workerLoop(workerID int, stop <-chan struct{}) {
 	defer w.WG.Done()
 	logger.Log.Info("[Worker] workerLoop запущен",
 		zap.Int("worker_id", workerID))
 
-	for item := range w.TaskChan {
+	for {
+		var item TaskItem
+		select {
+		case <-w.quit:
+			logger.Log.Info("[Worker] workerLoop завершается", zap.Int("worker_id", workerID))
+			return
+		case <-stop:
+			logger.Log.Info("[Worker] workerLoop остановлен при уменьшении конкурентности", zap.Int("worker_id", workerID))
+			return
+		case item = <-w.TaskChan:
+		}
+
+		if w.skipCancelled(item.TaskID) {
+			continue
+		}
+
 		logger.Log.Info("[Worker] Обработка получателя",
 			zap.Int("worker_id", workerID),
 			zap.String("task_id", item.TaskID),
@@ -145,35 +816,106 @@ func (w *Worker) workerLoop(workerID int) {
 			zap.String("content_type", item.Content.Type))
 
 		// Rate-limit
-		if err := w.RateLimiter.Wait(context.Background()); err != nil {
+		if err := w.waitBudget(item); err != nil {
 			logger.Log.Error("[Worker] Ошибка rate-limiter",
 				zap.Int("worker_id", workerID),
 				zap.Error(err))
-			w.incrementFailed(item.TaskID, item.Content.Type, err)
+			w.incrementFailed(item, err)
 			continue
 		}
 
-		// Попытка отправки
-		if err := w.sendMessage(item); err != nil {
-			// В sendMessage(...) при ошибке вызывается handleTgError(...), которая делает incrementFailed
+		// Попытка отправки (или редактирования/удаления)
+		if err := w.processItem(item); err != nil {
+			// При ошибке вызывается handleTgError(...), которая делает incrementFailed
 			// Здесь просто переходим к следующему
 			continue
 		}
 
-		// Успешная отправка
-		logger.Log.Info("[Worker] Успешно отправлено",
+		// Успешная обработка
+		logger.Log.Info("[Worker] Успешно обработано",
 			zap.Int("worker_id", workerID),
 			zap.String("task_id", item.TaskID),
+			zap.String("action", item.Action),
 			zap.Int64("recipient", item.Recipient),
 			zap.String("content_type", item.Content.Type))
-		w.incrementSent(item.TaskID, item.Content.Type)
+		w.incrementSent(item)
+	}
+}
+
+// waitBudget ждёт токены из бюджета чата получателя и общего бюджета бота
+func (w *Worker) waitBudget(item TaskItem) error {
+	w.mu.Lock()
+	botRate := w.botRateLocked()
+	w.mu.Unlock()
+
+	start := time.Now()
+	defer func() {
+		metrics.WorkerLimiterWait.WithLabelValues(w.metricsBot).Observe(time.Since(start).Seconds())
+	}()
+
+	chatKey := w.botKey + ".chat." + strconv.FormatInt(item.Recipient, 10)
+	if err := ratelimit.Wait(w.ctx, w.Limiter, chatKey, chatRate); err != nil {
+		return err
+	}
+	return ratelimit.Wait(w.ctx, w.Limiter, w.botKey, botRate)
+}
+
+// botRateLocked — скорость бота для приоритета последней задачи; вызывается под mu
+func (w *Worker) botRateLocked() ratelimit.Rate {
+	perSecond := w.limits.RateMedium
+	switch w.priority {
+	case "high":
+		perSecond = w.limits.RateHigh
+	case "low":
+		perSecond = w.limits.RateLow
+	}
+	return ratelimit.Rate{Limit: perSecond, Burst: 1}
+}
+
+// retryLater повторно ставит получателя в очередь через паузу по политике повторов
+// (не меньше minDelay). false — попытки исчерпаны.
+func (w *Worker) retryLater(item TaskItem, minDelay time.Duration) bool {
+	attempt := item.Attempt
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	w.mu.Lock()
+	limits := w.limits
+	if attempt >= limits.MaxAttempts {
+		w.mu.Unlock()
+		return false
+	}
+	if st := w.stats[item.TaskID]; st != nil {
+		st.TotalRetried++
 	}
+	w.mu.Unlock()
+	item.Attempt = attempt + 1
+	metrics.WorkerRetries.WithLabelValues(w.metricsBot).Inc()
+
+	delay := limits.RetryDelay(item.Attempt)
+	if delay < minDelay {
+		delay = minDelay
+	}
+	logger.Log.Info("[Worker] Повтор отправки",
+		zap.String("task_id", item.TaskID),
+		zap.Int64("recipient", item.Recipient),
+		zap.Int("attempt", item.Attempt),
+		zap.Duration("delay", delay))
 
-	logger.Log.Info("[Worker] workerLoop завершается", zap.Int("worker_id", workerID))
+	// Если воркер к тому времени остановлен, enqueue вернёт false
+	go func() {
+		select {
+		case <-time.After(delay):
+			w.enqueue(item)
+		case <-w.quit:
+		}
+	}()
+	return true
 }
 
 // sendMessage — единая точка для отправки сообщения любым способом.
-func (w *Worker) sendMessage(item TaskItem) error {
+func (w *Worker) sendMessage(item TaskItem) (*tele.Message, error) {
 	c := item.Content
 	logger.Log.Info("[Worker] sendMessage",
 		zap.String("task_id", item.TaskID),
@@ -182,99 +924,307 @@ func (w *Worker) sendMessage(item TaskItem) error {
 		zap.String("media_id", c.MediaID),
 		zap.String("media_url", c.MediaURL))
 
-	var err error
+	var (
+		msg *tele.Message
+		err error
+	)
+	start := time.Now()
 	switch c.Type {
 	case "text":
-		_, err = w.Bot.Send(tele.ChatID(item.Recipient), c.Text)
+		msg, err = w.Bot.Send(tele.ChatID(item.Recipient), c.Text, replyMarkup(c))
 
 	case "photo":
-		err = w.sendPhoto(item)
+		msg, err = w.sendPhoto(item)
 
 	case "animation":
-		err = w.sendAnimation(item)
+		msg, err = w.sendAnimation(item)
 
 	case "video":
-		err = w.sendVideo(item)
+		msg, err = w.sendVideo(item)
 
 	case "document":
-		err = w.sendDocument(item)
+		msg, err = w.sendDocument(item)
 
 	case "audio":
-		err = w.sendAudio(item)
+		msg, err = w.sendAudio(item)
 
 	case "circle":
-		err = w.sendCircle(item)
+		msg, err = w.sendCircle(item)
+
+	case "sticker":
+		msg, err = w.sendSticker(item)
+
+	case "poll":
+		msg, err = w.sendPoll(item)
+
+	case "location":
+		msg, err = w.sendLocation(item)
+
+	case "venue":
+		msg, err = w.sendVenue(item)
+
+	case "contact":
+		msg, err = w.sendContact(item)
+
+	case "dice":
+		msg, err = w.sendDice(item)
+
+	case "copy":
+		msg, err = w.sendCopy(item)
+
+	case "forward":
+		msg, err = w.sendForward(item)
 
 	default:
 		err = fmt.Errorf("неподдерживаемый тип контента: %s", c.Type)
 	}
+	w.observeTelegram(ActionSend, start, err)
+
+	return msg, w.handleTgError(item, err)
+}
+
+// observeTelegram учитывает в метриках длительность запроса к Telegram, начатого в start
+func (w *Worker) observeTelegram(action string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	metrics.TelegramRequestDuration.WithLabelValues(w.metricsBot, action, result).Observe(time.Since(start).Seconds())
+}
+
+// processItem выполняет действие задачи для одного получателя:
+// отправку (с сохранением ID сообщения), редактирование или удаление.
+func (w *Worker) processItem(item TaskItem) error {
+	switch item.Action {
+	case ActionEdit:
+		// Сохранённые при правке кнопки исходной рассылки остаются персональными редиректами
+		item.Content = trackContent(item.Content, item.Recipient)
+		start := time.Now()
+		err := w.editMessage(item)
+		w.observeTelegram(ActionEdit, start, err)
+		return w.handleTgError(item, err)
+
+	case ActionRecall:
+		start := time.Now()
+		err := w.Bot.Delete(item.storedMessage())
+		w.observeTelegram(ActionRecall, start, err)
+		return w.handleTgError(item, err)
+
+	default:
+		item.Content = trackContent(item.Content, item.Recipient)
+		msg, err := w.sendMessage(item)
+		if err != nil {
+			return err
+		}
+		if msg != nil {
+			if err := w.Repo.SaveMessage(item.TaskID, item.Recipient, msg.ID); err != nil {
+				logger.Log.Error("[Worker] Ошибка сохранения ID сообщения",
+					zap.String("task_id", item.TaskID),
+					zap.Int64("recipient", item.Recipient),
+					zap.Error(err))
+			}
+		}
+		return nil
+	}
+}
+
+// editMessage меняет текст, подпись и/или кнопки ранее отправленного сообщения.
+func (w *Worker) editMessage(item TaskItem) error {
+	c := item.Content
+	stored := item.storedMessage()
+	markup := replyMarkup(c)
+
+	var err error
+	switch {
+	case c.Text != "":
+		_, err = w.Bot.Edit(stored, c.Text, markup)
+	case c.Caption != "":
+		_, err = w.Bot.EditCaption(stored, c.Caption, markup)
+	case markup != nil:
+		_, err = w.Bot.EditReplyMarkup(stored, markup)
+	default:
+		err = errors.New("edit: nothing to change")
+	}
+	return err
+}
+
+// trackContent подменяет отслеживаемые ссылки в тексте, подписи и кнопках
+// на персональные редиректы получателя. Исходный Content не меняется.
+func trackContent(c Content, recipient int64) Content {
+	if len(c.TrackedLinks) == 0 {
+		return c
+	}
+
+	c.Text = linktrack.Rewrite(c.Text, c.TrackedLinks, recipient)
+	c.Caption = linktrack.Rewrite(c.Caption, c.TrackedLinks, recipient)
+	if len(c.Buttons) > 0 {
+		rows := make([][]Button, len(c.Buttons))
+		for i, row := range c.Buttons {
+			rows[i] = make([]Button, len(row))
+			for j, b := range row {
+				rows[i][j] = Button{Text: b.Text, URL: linktrack.Rewrite(b.URL, c.TrackedLinks, recipient)}
+			}
+		}
+		c.Buttons = rows
+	}
+	return c
+}
+
+// replyMarkup строит inline-клавиатуру из Content.Buttons (nil, если кнопок нет).
+func replyMarkup(c Content) *tele.ReplyMarkup {
+	if len(c.Buttons) == 0 {
+		return nil
+	}
 
-	return w.handleTgError(item, err)
+	rows := make([][]tele.InlineButton, 0, len(c.Buttons))
+	for _, row := range c.Buttons {
+		buttons := make([]tele.InlineButton, 0, len(row))
+		for _, b := range row {
+			buttons = append(buttons, tele.InlineButton{Text: b.Text, URL: b.URL})
+		}
+		rows = append(rows, buttons)
+	}
+	return &tele.ReplyMarkup{InlineKeyboard: rows}
 }
 
 // incrementSent — при успехе
-func (w *Worker) incrementSent(taskID, contentType string) {
+func (w *Worker) incrementSent(item TaskItem) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	st := w.stats[taskID]
+	st := w.stats[item.TaskID]
 	if st == nil {
+		w.releaseCancelledLocked(item.TaskID)
 		return
 	}
 	st.TotalSent++
 	st.ProcessedCount++
-	st.ByContentType[contentType]++
+	st.ByContentType[item.Content.Type]++
+	metrics.WorkerMessagesSent.WithLabelValues(w.metricsBot, contentTypeLabel(item)).Inc()
 
-	if st.ProcessedCount == st.ExpectedCount {
-		w.finishTask(taskID, st)
+	if vs := variantStats(st, item.Variant); vs != nil {
+		vs.Sent++
 	}
+
+	w.checkFinished(item.TaskID, st)
 }
 
 // incrementFailed — при ошибке
-func (w *Worker) incrementFailed(taskID, contentType string, err error) {
+func (w *Worker) incrementFailed(item TaskItem, err error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	st := w.stats[taskID]
+	st := w.stats[item.TaskID]
 	if st == nil {
+		w.releaseCancelledLocked(item.TaskID)
 		return
 	}
 	st.TotalFailed++
 	st.ProcessedCount++
 
+	vs := variantStats(st, item.Variant)
+	if vs != nil {
+		vs.Failed++
+	}
+
+	class := "other"
 	if err != nil {
-		msg := err.Error()
-		if strings.Contains(msg, "chat not found") {
-			st.ErrorCounts["NOT_FOUND"]++
-		} else if strings.Contains(msg, "FLOOD_WAIT") {
-			st.ErrorCounts["FLOOD_WAIT"]++
-		} else {
-			st.ErrorCounts["other"]++
+		class = errorCode(err)
+		st.ErrorCounts[class]++
+		if vs != nil {
+			vs.ErrorCounts[class]++
 		}
 	}
+	metrics.WorkerMessagesFailed.WithLabelValues(w.metricsBot, contentTypeLabel(item), class).Inc()
 
-	if st.ProcessedCount == st.ExpectedCount {
-		w.finishTask(taskID, st)
+	w.checkFinished(item.TaskID, st)
+}
+
+// contentTypeLabel — метка content_type получателя (у recall контента нет)
+func contentTypeLabel(item TaskItem) string {
+	if item.Content.Type == "" {
+		return "none"
+	}
+	return item.Content.Type
+}
+
+// errorCode сводит ошибку Telegram к коду для статистики.
+func errorCode(err error) string {
+	msg := err.Error()
+	if strings.Contains(msg, "chat not found") {
+		return "NOT_FOUND"
+	} else if strings.Contains(msg, "blocked by the user") {
+		return "BLOCKED"
+	} else if strings.Contains(msg, "FLOOD_WAIT") {
+		return "FLOOD_WAIT"
+	} else if isUnauthorized(err) {
+		return "UNAUTHORIZED"
+	}
+	return "other"
+}
+
+// variantStats возвращает (заводя при необходимости) статистику варианта A/B-теста.
+// Для задач без вариантов возвращает nil.
+func variantStats(st *models.Stats, variant string) *models.VariantStats {
+	if variant == "" {
+		return nil
+	}
+	if st.ByVariant == nil {
+		st.ByVariant = make(map[string]*models.VariantStats)
+	}
+	vs, ok := st.ByVariant[variant]
+	if !ok {
+		vs = &models.VariantStats{ErrorCounts: make(map[string]int64)}
+		st.ByVariant[variant] = vs
+	}
+	return vs
+}
+
+// finishStatus — итоговый статус задачи по её статистике
+func finishStatus(st *models.Stats) models.TaskStatus {
+	switch {
+	case st.TotalFailed == 0:
+		return models.TaskComplete
+	case st.TotalSent == 0:
+		return models.TaskFailed
+	default:
+		return models.TaskPartiallyFailed
 	}
 }
 
 // finishTask — когда ProcessedCount == ExpectedCount, задача завершается
 func (w *Worker) finishTask(taskID string, finalStats *models.Stats) {
+	status := finishStatus(finalStats)
+	metrics.WorkerTasksFinished.WithLabelValues(w.metricsBot, string(status)).Inc()
 	logger.Log.Info("[Worker] Задача завершена",
-		zap.String("task_id", taskID))
+		zap.String("task_id", taskID),
+		zap.String("status", string(status)))
 
-	elapsed := time.Since(finalStats.StartTime).Seconds()
-	finalStats.TimeSpent = elapsed
+	finalStats.Finish(time.Now())
 
 	// 1. Обновляем статус и статистику в БД
-	if err := w.Repo.UpdateStatusAndStats(taskID, "complete", *finalStats); err != nil {
-		logger.Log.Error("[Worker] Ошибка UpdateStatusAndStats",
+	change := tasks.StatusChange{Actor: tasks.ActorWorker, Stats: finalStats}
+	if status == models.TaskFailed {
+		change.Reason = "no recipient received the message"
+	}
+	err := w.Repo.ChangeStatus(taskID, status, change)
+	var transitionErr *tasks.TransitionError
+	if errors.As(err, &transitionErr) && transitionErr.From == models.TaskQueued {
+		// markRunning не записал running (сбой БД): проводим задачу через running
+		if err = w.Repo.ChangeStatus(taskID, models.TaskRunning, tasks.StatusChange{Actor: tasks.ActorWorker}); err == nil {
+			err = w.Repo.ChangeStatus(taskID, status, change)
+		}
+	}
+	if err != nil {
+		logger.Log.Error("[Worker] Ошибка смены статуса задачи",
 			zap.String("task_id", taskID),
 			zap.Error(err))
 	}
 
+	alertTaskFinished(taskID, finalStats)
+
 	// 2. Публикация события (если нужно)
-	if err := w.Repo.PublishCompleteStatus(taskID, *finalStats); err != nil {
+	if err := w.Repo.PublishCompleteStatus(taskID, status, *finalStats); err != nil {
 		logger.Log.Error("[Worker] Ошибка PublishCompleteStatus",
 			zap.String("task_id", taskID),
 			zap.Error(err))
@@ -288,4 +1238,6 @@ func (w *Worker) finishTask(taskID string, finalStats *models.Stats) {
 
 	// 4. Удаляем запись из stats
 	delete(w.stats, taskID)
+	delete(w.chunks, taskID)
+	delete(w.sources, taskID)
 }
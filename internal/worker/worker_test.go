@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/storage/models"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// chunkRepo — machineRepo без подавленных получателей
+type chunkRepo struct {
+	*machineRepo
+}
+
+func (chunkRepo) SuppressedAmong(uint, uint, []int64) (map[int64]bool, error) {
+	return nil, nil
+}
+
+func TestWorkerOutOfOrderChunks(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	repo := &machineRepo{status: map[string]models.TaskStatus{"t1": models.TaskQueued}}
+	w := testManagedWorker(botRef{BotID: 1}, time.Now()).Worker
+	w.Repo = chunkRepo{repo}
+	w.TaskChan = make(chan TaskItem, 10)
+
+	drain := func(n int) {
+		for i := 0; i < n; i++ {
+			w.incrementSent(<-w.TaskChan)
+		}
+	}
+
+	// Последняя часть приходит первой: её получатели обработаны, но задача ждёт часть 0
+	if !w.AddTask(TaskNATSMessage{TaskID: "t1", Action: ActionSend, ChunkIndex: 1, Recipients: []int64{3, 4, 5}}) {
+		t.Fatal("part 1 rejected")
+	}
+	drain(3)
+	st := w.stats["t1"]
+	if st == nil || repo.status["t1"] != models.TaskRunning {
+		t.Fatalf("task finished before part 0 arrived: status %q", repo.status["t1"])
+	}
+	if st.ExpectedCount != 3 || st.ProcessedCount != 3 {
+		t.Fatalf("after part 1: expected %d, processed %d", st.ExpectedCount, st.ProcessedCount)
+	}
+
+	if !w.AddTask(TaskNATSMessage{TaskID: "t1", Action: ActionSend, ChunkIndex: 0, MoreChunks: true, Recipients: []int64{1, 2}}) {
+		t.Fatal("part 0 rejected")
+	}
+	if st.ExpectedCount != 5 {
+		t.Fatalf("ExpectedCount = %d, want 5 summed over both parts", st.ExpectedCount)
+	}
+	drain(1)
+	if repo.status["t1"] != models.TaskRunning {
+		t.Fatalf("task finished with a recipient left: status %q", repo.status["t1"])
+	}
+	drain(1)
+	if repo.status["t1"] != models.TaskComplete || st.TotalSent != 5 {
+		t.Fatalf("status = %q, sent %d, want complete with 5", repo.status["t1"], st.TotalSent)
+	}
+	if _, ok := w.stats["t1"]; ok {
+		t.Fatal("finished task must be forgotten")
+	}
+}
@@ -0,0 +1,54 @@
+// pkg/abtest/abtest.go
+
+package abtest
+
+import (
+	"hash/fnv"
+	"strconv"
+)
+
+// Bucket детерминированно отображает пару (задача, получатель) в число [0, n).
+// Salt позволяет получить независимые разбиения для одной и той же пары.
+func Bucket(taskID string, recipient int64, salt string, n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(taskID))
+	_, _ = h.Write([]byte{':'})
+	_, _ = h.Write([]byte(strconv.FormatInt(recipient, 10)))
+	_, _ = h.Write([]byte{':'})
+	_, _ = h.Write([]byte(salt))
+	return h.Sum64() % n
+}
+
+// PickVariant выбирает индекс варианта для получателя пропорционально весам.
+// Нулевые и отрицательные веса не участвуют в выборе. Возвращает -1, если выбирать не из чего.
+func PickVariant(taskID string, recipient int64, weights []int) int {
+	var total uint64
+	for _, w := range weights {
+		if w > 0 {
+			total += uint64(w)
+		}
+	}
+	if total == 0 {
+		return -1
+	}
+
+	point := Bucket(taskID, recipient, "variant", total)
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		if point < uint64(w) {
+			return i
+		}
+		point -= uint64(w)
+	}
+	return -1
+}
+
+// InTestSlice сообщает, попадает ли получатель в тестовую выборку размером percent%.
+func InTestSlice(taskID string, recipient int64, percent int) bool {
+	return Bucket(taskID, recipient, "test", 100) < uint64(percent)
+}
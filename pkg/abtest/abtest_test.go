@@ -0,0 +1,60 @@
+// pkg/abtest/abtest_test.go
+
+package abtest
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPickVariantDeterministic(t *testing.T) {
+	weights := []int{50, 30, 20}
+	for recipient := int64(1); recipient <= 100; recipient++ {
+		first := PickVariant("task-1", recipient, weights)
+		second := PickVariant("task-1", recipient, weights)
+		if first != second {
+			t.Fatalf("recipient %d: got variants %d and %d for the same input", recipient, first, second)
+		}
+	}
+}
+
+func TestPickVariantWeights(t *testing.T) {
+	weights := []int{70, 0, 30}
+	counts := make([]int, len(weights))
+	const total = 20000
+	for recipient := int64(0); recipient < total; recipient++ {
+		idx := PickVariant("task-2", recipient, weights)
+		if idx < 0 {
+			t.Fatalf("recipient %d: no variant picked", recipient)
+		}
+		counts[idx]++
+	}
+
+	if counts[1] != 0 {
+		t.Fatalf("variant with zero weight got %d recipients", counts[1])
+	}
+	share := float64(counts[0]) / total
+	if math.Abs(share-0.7) > 0.02 {
+		t.Fatalf("variant 0 share = %.3f, want about 0.7", share)
+	}
+}
+
+func TestPickVariantNoWeights(t *testing.T) {
+	if idx := PickVariant("task-3", 1, []int{0, 0}); idx != -1 {
+		t.Fatalf("got %d, want -1", idx)
+	}
+}
+
+func TestInTestSlice(t *testing.T) {
+	const total = 10000
+	inSlice := 0
+	for recipient := int64(0); recipient < total; recipient++ {
+		if InTestSlice("task-4", recipient, 10) {
+			inSlice++
+		}
+	}
+	share := float64(inSlice) / total
+	if math.Abs(share-0.1) > 0.02 {
+		t.Fatalf("test slice share = %.3f, want about 0.1", share)
+	}
+}
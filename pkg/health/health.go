@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// checkTimeout — сколько ждать одну проверку готовности
+const checkTimeout = 3 * time.Second
+
+// Check проверяет одну зависимость процесса (БД, NATS и т. п.)
+type Check func(ctx context.Context) error
+
+// Checker отвечает на пробы оркестратора:
+// /healthz — процесс жив, /readyz — все зависимости доступны
+type Checker struct {
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+func New() *Checker {
+	return &Checker{checks: make(map[string]Check)}
+}
+
+// Add регистрирует проверку готовности под именем name
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Report — ответ /readyz
+type Report struct {
+	Status string            `json:"status"` // ok или fail
+	Checks map[string]string `json:"checks"` // имя проверки → ok или текст ошибки
+}
+
+// Run выполняет все проверки параллельно
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	results := make([]string, len(names))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			if err := check(ctx); err != nil {
+				results[i] = err.Error()
+				return
+			}
+			results[i] = "ok"
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: "ok", Checks: make(map[string]string, len(names))}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i] != "ok" {
+			report.Status = "fail"
+		}
+	}
+	return report
+}
+
+// LiveHandler всегда отвечает 200, пока процесс обслуживает HTTP
+func (c *Checker) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+}
+
+// ReadyHandler отвечает 503, если хотя бы одна проверка не прошла
+func (c *Checker) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+		code := http.StatusOK
+		if report.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	})
+}
+
+// Register добавляет /healthz и /readyz в mux
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.Handle("/healthz", c.LiveHandler())
+	mux.Handle("/readyz", c.ReadyHandler())
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
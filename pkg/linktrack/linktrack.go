@@ -0,0 +1,116 @@
+// pkg/linktrack/linktrack.go
+
+package linktrack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	BaseURL string // публичный адрес GoBlast, например https://goblast.example.com
+	Secret  string // ключ подписи кодов ссылок
+)
+
+// Initialize задаёт адрес редиректа и ключ подписи
+func Initialize(baseURL, secret string) {
+	BaseURL = strings.TrimRight(baseURL, "/")
+	Secret = secret
+}
+
+// Enabled сообщает, настроено ли отслеживание кликов
+func Enabled() bool {
+	return BaseURL != "" && Secret != ""
+}
+
+const signatureSize = 8
+
+var ErrInvalidCode = errors.New("invalid link code")
+
+// Encode упаковывает ID ссылки и получателя в подписанный код для /r/:code
+func Encode(linkID uint, recipient int64) string {
+	buf := make([]byte, 0, 2*binary.MaxVarintLen64+signatureSize)
+	buf = binary.AppendUvarint(buf, uint64(linkID))
+	buf = binary.AppendVarint(buf, recipient)
+	buf = append(buf, sign(buf)...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// Decode проверяет подпись кода и возвращает ID ссылки и получателя
+func Decode(code string) (uint, int64, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil || len(buf) <= signatureSize {
+		return 0, 0, ErrInvalidCode
+	}
+	payload, signature := buf[:len(buf)-signatureSize], buf[len(buf)-signatureSize:]
+	if !hmac.Equal(signature, sign(payload)) {
+		return 0, 0, ErrInvalidCode
+	}
+
+	linkID, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return 0, 0, ErrInvalidCode
+	}
+	recipient, m := binary.Varint(payload[n:])
+	if m <= 0 || n+m != len(payload) {
+		return 0, 0, ErrInvalidCode
+	}
+	return uint(linkID), recipient, nil
+}
+
+// URL возвращает ссылку-редирект для получателя
+func URL(linkID uint, recipient int64) string {
+	return BaseURL + "/r/" + Encode(linkID, recipient)
+}
+
+func sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(Secret))
+	mac.Write(payload)
+	return mac.Sum(nil)[:signatureSize]
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
+// splitURL отделяет от найденного URL завершающую пунктуацию предложения
+func splitURL(match string) (string, string) {
+	trimmed := strings.TrimRight(match, ".,;:!?)")
+	return trimmed, match[len(trimmed):]
+}
+
+// ExtractURLs возвращает уникальные http(s)-ссылки из текста (HTML-сущности раскрыты)
+func ExtractURLs(text string) []string {
+	var (
+		urls []string
+		seen = make(map[string]bool)
+	)
+	for _, match := range urlPattern.FindAllString(text, -1) {
+		u, _ := splitURL(match)
+		u = html.UnescapeString(u)
+		if !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// Rewrite заменяет в тексте ссылки из links (url -> ID ссылки) на редиректы для получателя
+func Rewrite(text string, links map[string]uint, recipient int64) string {
+	if len(links) == 0 {
+		return text
+	}
+	return urlPattern.ReplaceAllStringFunc(text, func(match string) string {
+		u, tail := splitURL(match)
+		linkID, ok := links[html.UnescapeString(u)]
+		if !ok {
+			return match
+		}
+		return URL(linkID, recipient) + tail
+	})
+}
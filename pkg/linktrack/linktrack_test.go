@@ -0,0 +1,58 @@
+// pkg/linktrack/linktrack_test.go
+
+package linktrack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	Initialize("https://goblast.example.com/", "secret")
+
+	code := Encode(42, -1001234567890)
+	linkID, recipient, err := Decode(code)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if linkID != 42 || recipient != -1001234567890 {
+		t.Fatalf("got (%d, %d), want (42, -1001234567890)", linkID, recipient)
+	}
+}
+
+func TestDecodeRejectsTampered(t *testing.T) {
+	Initialize("https://goblast.example.com", "secret")
+
+	code := Encode(1, 100)
+	tampered := []byte(code)
+	tampered[0] ^= 1
+	if _, _, err := Decode(string(tampered)); err == nil {
+		t.Fatal("Decode accepted a tampered code")
+	}
+
+	Initialize("https://goblast.example.com", "other-secret")
+	if _, _, err := Decode(code); err == nil {
+		t.Fatal("Decode accepted a code signed with another secret")
+	}
+}
+
+func TestExtractAndRewrite(t *testing.T) {
+	Initialize("https://goblast.example.com", "secret")
+
+	text := `Скидки: <a href="https://shop.example.com/?a=1&amp;b=2">тут</a>, подробнее на https://example.com/sale.`
+	urls := ExtractURLs(text)
+	if len(urls) != 2 || urls[0] != "https://shop.example.com/?a=1&b=2" || urls[1] != "https://example.com/sale" {
+		t.Fatalf("unexpected urls: %v", urls)
+	}
+
+	rewritten := Rewrite(text, map[string]uint{urls[0]: 1, urls[1]: 2}, 7)
+	if strings.Contains(rewritten, "shop.example.com") || strings.Contains(rewritten, "example.com/sale") {
+		t.Fatalf("urls were not rewritten: %s", rewritten)
+	}
+	if !strings.HasSuffix(rewritten, ".") {
+		t.Fatalf("trailing punctuation lost: %s", rewritten)
+	}
+	if !strings.Contains(rewritten, URL(1, 7)) || !strings.Contains(rewritten, URL(2, 7)) {
+		t.Fatalf("redirect links missing: %s", rewritten)
+	}
+}
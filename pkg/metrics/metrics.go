@@ -53,6 +53,135 @@ var (
 		[]string{"method", "endpoint"},
 	)
 
+	BotHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bot_healthy",
+			Help: "Состояние бота по последней проверке getMe (1 — токен действует, 0 — отклонён)",
+		},
+		[]string{"bot"}, // bot:<id> или user:<id> для бота по умолчанию
+	)
+
+	BotHealthChecks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bot_health_checks_total",
+			Help: "Количество проверок ботов через getMe",
+		},
+		[]string{"result"}, // ok, unauthorized, error
+	)
+
+	BotsDisabledCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "bots_unauthorized_total",
+			Help: "Количество ботов, отключённых из-за отозванного токена",
+		},
+	)
+
+	DeadLetterCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "tasks_dead_lettered_total",
+			Help: "Количество сообщений задач, отправленных воркером в tasks.dlq",
+		},
+	)
+
+	ActiveBotWorkers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "worker_active_bots",
+			Help: "Количество запущенных воркеров ботов в экземпляре",
+		},
+	)
+
+	// Метрики воркера рассылки. Метка bot — bot:<id> или user:<id> для бота по умолчанию,
+	// error_class — код ошибки из статистики задачи (NOT_FOUND, BLOCKED, FLOOD_WAIT, UNAUTHORIZED, other)
+
+	WorkerMessagesSent = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_messages_sent_total",
+			Help: "Количество сообщений, успешно отправленных (отредактированных, удалённых) воркером",
+		},
+		[]string{"bot", "content_type"},
+	)
+
+	WorkerMessagesFailed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_messages_failed_total",
+			Help: "Количество получателей, которым сообщение не доставлено",
+		},
+		[]string{"bot", "content_type", "error_class"},
+	)
+
+	WorkerRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_retries_total",
+			Help: "Количество повторных попыток отправки",
+		},
+		[]string{"bot"},
+	)
+
+	WorkerFloodWaits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_flood_waits_total",
+			Help: "Количество ответов Telegram 429 (FLOOD_WAIT)",
+		},
+		[]string{"bot"},
+	)
+
+	WorkerFloodWaitSeconds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_flood_wait_seconds_total",
+			Help: "Суммарная пауза по retry_after из ответов FLOOD_WAIT",
+		},
+		[]string{"bot"},
+	)
+
+	WorkerQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "worker_queue_depth",
+			Help: "Получателей в очереди (TaskChan) воркера бота",
+		},
+		[]string{"bot"},
+	)
+
+	WorkerSendGoroutines = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "worker_send_goroutines",
+			Help: "Горутин отправки воркера бота (конкурентность)",
+		},
+		[]string{"bot"},
+	)
+
+	WorkerLimiterWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "worker_limiter_wait_seconds",
+			Help:    "Ожидание бюджета отправки (чата и бота) перед запросом к Telegram",
+			Buckets: []float64{0.001, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"bot"},
+	)
+
+	TelegramRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "worker_telegram_request_duration_seconds",
+			Help:    "Длительность запросов воркера к Telegram Bot API",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"bot", "action", "result"}, // action: send, edit, recall; result: ok, error
+	)
+
+	WorkerTasksFinished = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "worker_tasks_finished_total",
+			Help: "Количество задач, завершённых воркером",
+		},
+		[]string{"bot", "status"}, // complete, partially_failed, failed
+	)
+
+	RateLimitFallbacks = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ratelimit_fallbacks_total",
+			Help: "Количество запросов бюджета отправки, обслуженных локальным лимитером вместо общего",
+		},
+	)
+
 	// Use sync.Once to ensure metrics are registered only once.
 	registerOnce sync.Once
 )
@@ -65,10 +194,28 @@ func InitMetrics() {
 		prometheus.MustRegister(TaskProcessingDuration)
 		prometheus.MustRegister(RequestCounter)
 		prometheus.MustRegister(RequestDuration)
+		prometheus.MustRegister(BotHealthy)
+		prometheus.MustRegister(BotHealthChecks)
+		prometheus.MustRegister(BotsDisabledCounter)
+		prometheus.MustRegister(DeadLetterCounter)
+		prometheus.MustRegister(ActiveBotWorkers)
+		prometheus.MustRegister(WorkerMessagesSent)
+		prometheus.MustRegister(WorkerMessagesFailed)
+		prometheus.MustRegister(WorkerRetries)
+		prometheus.MustRegister(WorkerFloodWaits)
+		prometheus.MustRegister(WorkerFloodWaitSeconds)
+		prometheus.MustRegister(WorkerQueueDepth)
+		prometheus.MustRegister(WorkerSendGoroutines)
+		prometheus.MustRegister(WorkerLimiterWait)
+		prometheus.MustRegister(TelegramRequestDuration)
+		prometheus.MustRegister(WorkerTasksFinished)
+		prometheus.MustRegister(RateLimitFallbacks)
 	})
 }
 
-// RegisterMetricsEndpoint регистрирует endpoint для метрик
-func RegisterMetricsEndpoint() {
-	http.Handle("/metrics", promhttp.Handler())
+// DeleteWorkerGauges убирает состояние остановленного воркера бота, чтобы на графиках
+// не оставались последние значения
+func DeleteWorkerGauges(bot string) {
+	WorkerQueueDepth.DeleteLabelValues(bot)
+	WorkerSendGoroutines.DeleteLabelValues(bot)
 }
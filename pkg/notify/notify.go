@@ -0,0 +1,182 @@
+// Package notify рассылает оповещения администраторам по нескольким каналам
+// (Telegram, SMTP, вебхуки) с подавлением повторов одного и того же оповещения.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"GoBlast/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Уровни важности оповещений
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert — одно оповещение
+type Alert struct {
+	Key        string    `json:"key"`  // оповещения с одним ключом подавляются в пределах окна throttle
+	Rule       string    `json:"rule"` // правило, сработавшее для оповещения
+	Severity   string    `json:"severity"`
+	Title      string    `json:"title"`
+	Message    string    `json:"message"`
+	Suppressed int       `json:"suppressed,omitempty"` // сколько таких же оповещений подавлено с прошлой отправки
+	Time       time.Time `json:"time"`
+}
+
+// Text возвращает оповещение одним текстом (для Telegram и почты)
+func (a Alert) Text() string {
+	text := fmt.Sprintf("[%s] %s\n%s", a.Severity, a.Title, a.Message)
+	if a.Suppressed > 0 {
+		text += fmt.Sprintf("\n(ещё %d таких же оповещений подавлено)", a.Suppressed)
+	}
+	return text
+}
+
+// Notifier — канал доставки оповещений
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alert Alert) error
+}
+
+const (
+	queueSize     = 256
+	notifyTimeout = 15 * time.Second
+	maxThrottled  = 10000 // после этого размера устаревшие ключи вычищаются
+)
+
+type throttleState struct {
+	lastSent   time.Time
+	suppressed int
+}
+
+// Dispatcher доставляет оповещения во все каналы в фоне.
+// Оповещение с ключом, уже отправленным в пределах окна throttle, не доставляется,
+// а учитывается в Suppressed следующего оповещения с тем же ключом.
+type Dispatcher struct {
+	notifiers []Notifier
+	throttle  time.Duration
+	queue     chan Alert
+	done      chan struct{}
+	wg        sync.WaitGroup
+
+	mu   sync.Mutex
+	seen map[string]*throttleState
+	now  func() time.Time
+}
+
+func NewDispatcher(throttle time.Duration, notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{
+		notifiers: notifiers,
+		throttle:  throttle,
+		queue:     make(chan Alert, queueSize),
+		done:      make(chan struct{}),
+		seen:      make(map[string]*throttleState),
+		now:       time.Now,
+	}
+}
+
+// Start запускает фоновую доставку
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for {
+			select {
+			case <-d.done:
+				return
+			case alert := <-d.queue:
+				d.deliver(alert)
+			}
+		}
+	}()
+}
+
+// Stop останавливает доставку; оповещения, оставшиеся в очереди, теряются
+func (d *Dispatcher) Stop() {
+	close(d.done)
+	d.wg.Wait()
+}
+
+// Send ставит оповещение в очередь, не блокируя вызывающего.
+// Безопасен для nil: тогда оповещение только пишется в лог.
+func (d *Dispatcher) Send(alert Alert) {
+	if alert.Time.IsZero() {
+		alert.Time = time.Now()
+	}
+	logger.Log.Warn("[Alert] Оповещение администратора",
+		zap.String("rule", alert.Rule),
+		zap.String("key", alert.Key),
+		zap.String("title", alert.Title),
+		zap.String("message", alert.Message))
+
+	if d == nil || len(d.notifiers) == 0 {
+		return
+	}
+	if !d.admit(&alert) {
+		return
+	}
+	select {
+	case d.queue <- alert:
+	default:
+		logger.Log.Error("[Alert] Очередь оповещений переполнена, оповещение отброшено",
+			zap.String("key", alert.Key))
+	}
+}
+
+// admit решает, отправлять ли оповещение, и дописывает в него число подавленных повторов
+func (d *Dispatcher) admit(alert *Alert) bool {
+	if alert.Key == "" || d.throttle <= 0 {
+		return true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	state, ok := d.seen[alert.Key]
+	if ok && now.Sub(state.lastSent) < d.throttle {
+		state.suppressed++
+		return false
+	}
+	if !ok {
+		if len(d.seen) >= maxThrottled {
+			d.prune(now)
+		}
+		state = &throttleState{}
+		d.seen[alert.Key] = state
+	}
+	alert.Suppressed = state.suppressed
+	state.lastSent = now
+	state.suppressed = 0
+	return true
+}
+
+// prune удаляет ключи, окно которых истекло. Вызывается под d.mu.
+func (d *Dispatcher) prune(now time.Time) {
+	for key, state := range d.seen {
+		if now.Sub(state.lastSent) >= d.throttle {
+			delete(d.seen, key)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(alert Alert) {
+	for _, n := range d.notifiers {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		if err := n.Notify(ctx, alert); err != nil {
+			logger.Log.Error("[Alert] Ошибка доставки оповещения",
+				zap.String("notifier", n.Name()),
+				zap.String("key", alert.Key),
+				zap.Error(err))
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"GoBlast/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func TestAdmitThrottlesByKey(t *testing.T) {
+	d := NewDispatcher(10*time.Minute, nil)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	first := Alert{Key: "bot_unauthorized:bot:1"}
+	if !d.admit(&first) {
+		t.Fatal("first alert must be sent")
+	}
+
+	// Повторы в пределах окна подавляются
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Minute)
+		a := Alert{Key: "bot_unauthorized:bot:1"}
+		if d.admit(&a) {
+			t.Fatalf("repeat %d must be suppressed", i)
+		}
+	}
+
+	// Другой ключ не затронут
+	other := Alert{Key: "bot_unauthorized:bot:2"}
+	if !d.admit(&other) {
+		t.Fatal("alert with another key must be sent")
+	}
+
+	// После окна оповещение уходит с числом подавленных
+	now = now.Add(10 * time.Minute)
+	next := Alert{Key: "bot_unauthorized:bot:1"}
+	if !d.admit(&next) {
+		t.Fatal("alert after throttle window must be sent")
+	}
+	if next.Suppressed != 3 {
+		t.Fatalf("Suppressed = %d, want 3", next.Suppressed)
+	}
+}
+
+func TestAdmitWithoutKey(t *testing.T) {
+	d := NewDispatcher(time.Hour, nil)
+	for i := 0; i < 3; i++ {
+		a := Alert{}
+		if !d.admit(&a) {
+			t.Fatal("alerts without key are never throttled")
+		}
+	}
+}
+
+func TestWebhookNotifierSignsBody(t *testing.T) {
+	var (
+		gotBody []byte
+		gotSig  string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get(SignatureHeader)
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(srv.URL, "s3cret")
+	alert := Alert{Key: "k", Rule: "task_failed", Title: "Task failed", Message: "50% failed"}
+	if err := n.Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if gotSig != Sign("s3cret", gotBody) {
+		t.Fatalf("signature %q does not match body", gotSig)
+	}
+	var decoded Alert
+	if err := json.Unmarshal(gotBody, &decoded); err != nil || decoded.Rule != "task_failed" {
+		t.Fatalf("unexpected body %s (%v)", gotBody, err)
+	}
+}
+
+func TestWebhookNotifierReportsHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhookNotifier(srv.URL, "").Notify(context.Background(), Alert{}); err == nil {
+		t.Fatal("expected error for 502 response")
+	}
+}
+
+func TestSMTPMessage(t *testing.T) {
+	n := NewSMTPNotifier("smtp.example.com", 587, "", "", "goblast@example.com", []string{"a@example.com", "b@example.com"})
+	msg := string(n.message(Alert{Severity: SeverityCritical, Title: "Бот отключён", Message: "строка 1\nстрока 2"}))
+
+	if !strings.Contains(msg, "To: a@example.com, b@example.com\r\n") {
+		t.Errorf("missing To header: %q", msg)
+	}
+	if !strings.Contains(msg, "Subject: =?utf-8?q?") {
+		t.Errorf("subject is not RFC 2047 encoded: %q", msg)
+	}
+	if !strings.Contains(msg, "строка 1\r\nстрока 2") {
+		t.Errorf("body lines must end with CRLF: %q", msg)
+	}
+}
+
+func TestSMTPNotifyHonoursContext(t *testing.T) {
+	// Сервер принимает соединение, но не отвечает приветствием
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	n := NewSMTPNotifier("127.0.0.1", addr.Port, "", "", "goblast@example.com", []string{"a@example.com"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := n.Notify(ctx, Alert{Title: "test"}); err == nil {
+		t.Fatal("expected error from a silent SMTP server")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Notify ignored the context deadline: took %v", elapsed)
+	}
+}
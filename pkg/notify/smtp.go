@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// smtpTimeout — предел на отправку письма, если у ctx нет своего срока
+const smtpTimeout = 30 * time.Second
+
+// SMTPNotifier отправляет оповещения письмом
+type SMTPNotifier struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewSMTPNotifier создаёт канал почты; без username письма отправляются без авторизации
+func NewSMTPNotifier(host string, port int, username, password, from string, to []string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		host: host,
+		auth: auth,
+		from: from,
+		to:   to,
+	}
+}
+
+func (n *SMTPNotifier) Name() string { return "smtp" }
+
+// Notify отправляет письмо как smtp.SendMail (STARTTLS, если сервер его поддерживает),
+// но соединение и весь обмен ограничены сроком ctx и прерываются его отменой
+func (n *SMTPNotifier) Notify(ctx context.Context, alert Alert) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := client.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+	for _, rcpt := range n.to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.message(alert)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message собирает письмо: тема в кодировке RFC 2047, текст в UTF-8
+func (n *SMTPNotifier) message(alert Alert) []byte {
+	subject := fmt.Sprintf("[GoBlast %s] %s", alert.Severity, alert.Title)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(alert.Text(), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
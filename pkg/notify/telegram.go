@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// TelegramNotifier пишет оповещения в чат администраторов от имени системного бота
+type TelegramNotifier struct {
+	bot    *tele.Bot
+	chatID int64
+}
+
+func NewTelegramNotifier(botToken string, chatID int64) (*TelegramNotifier, error) {
+	// Offline: getMe при старте не нужен, токен проверится первой отправкой
+	bot, err := tele.NewBot(tele.Settings{
+		Token:   botToken,
+		Offline: true,
+		Client:  &http.Client{Timeout: notifyTimeout},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TelegramNotifier{bot: bot, chatID: chatID}, nil
+}
+
+func (n *TelegramNotifier) Name() string { return "telegram" }
+
+func (n *TelegramNotifier) Notify(_ context.Context, alert Alert) error {
+	_, err := n.bot.Send(tele.ChatID(n.chatID), alert.Text(), &tele.SendOptions{DisableWebPagePreview: true})
+	return err
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// fallbackLogEvery — как часто напоминать в логе, что работает локальный режим
+	fallbackLogEvery = time.Minute
+	// primaryTimeout — сколько ждать ответа общего лимитера: медленный NATS не должен задерживать отправку
+	primaryTimeout = 500 * time.Millisecond
+	// fallbackCooldown — сколько после сбоя общего лимитера использовать только локальный
+	fallbackCooldown = 30 * time.Second
+)
+
+// FallbackLimiter использует общий лимитер, а при его ошибке или таймауте (например, NATS недоступен)
+// — локальный, чтобы рассылка не останавливалась. После сбоя общий лимитер не опрашивается
+// fallbackCooldown. В локальном режиме лимиты верны только в пределах одной реплики.
+type FallbackLimiter struct {
+	primary Limiter
+	local   Limiter
+	now     func() time.Time
+
+	mu        sync.Mutex
+	openUntil time.Time // до этого момента запросы идут только в локальный лимитер
+	lastWarn  time.Time
+	fallbacks int
+}
+
+func NewFallbackLimiter(primary, local Limiter) *FallbackLimiter {
+	return &FallbackLimiter{primary: primary, local: local, now: time.Now}
+}
+
+func (l *FallbackLimiter) Take(ctx context.Context, key string, rate Rate) (time.Duration, error) {
+	if l.open() {
+		metrics.RateLimitFallbacks.Inc()
+		return l.local.Take(ctx, key, rate)
+	}
+
+	primaryCtx, cancel := context.WithTimeout(ctx, primaryTimeout)
+	wait, err := l.primary.Take(primaryCtx, key, rate)
+	cancel()
+	if err == nil || ctx.Err() != nil {
+		return wait, err
+	}
+	l.trip(err)
+	metrics.RateLimitFallbacks.Inc()
+	return l.local.Take(ctx, key, rate)
+}
+
+// open сообщает, что общий лимитер недавно отказал и пропускается
+func (l *FallbackLimiter) open() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.now().Before(l.openUntil)
+}
+
+// trip переключает лимитер на локальный на fallbackCooldown
+func (l *FallbackLimiter) trip(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.openUntil = now.Add(fallbackCooldown)
+	l.fallbacks++
+	if now.Sub(l.lastWarn) < fallbackLogEvery {
+		return
+	}
+	logger.Log.Warn("[RateLimit] Общий лимитер недоступен, используются локальные лимиты",
+		zap.Int("fallbacks", l.fallbacks),
+		zap.Duration("cooldown", fallbackCooldown),
+		zap.Error(err))
+	l.lastWarn = now
+	l.fallbacks = 0
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// localIdleTTL — бюджет, не использованный дольше, считается полным и удаляется
+const localIdleTTL = 10 * time.Minute
+
+type localBucket struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// LocalLimiter хранит бюджеты в памяти процесса (rate.Limiter на ключ):
+// лимиты верны только для одной реплики
+type LocalLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*localBucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+func NewLocalLimiter() *LocalLimiter {
+	return &LocalLimiter{
+		buckets: make(map[string]*localBucket),
+		now:     time.Now,
+	}
+}
+
+func (l *LocalLimiter) Take(_ context.Context, key string, r Rate) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	limit, burst := rate.Limit(r.Limit), r.Burst
+	if burst < 1 {
+		burst = 1
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &localBucket{limiter: rate.NewLimiter(limit, burst)}
+		l.buckets[key] = b
+	} else if b.limiter.Limit() != limit || b.limiter.Burst() != burst {
+		// Скорость бюджета поменялась (например, приоритет задачи)
+		b.limiter.SetLimitAt(now, limit)
+		b.limiter.SetBurstAt(now, burst)
+	}
+	b.lastUsed = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if wait := reservation.DelayFrom(now); wait > 0 {
+		reservation.CancelAt(now)
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// prune удаляет давно не использованные бюджеты (например, чатов, которым больше не пишут)
+func (l *LocalLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < localIdleTTL {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastUsed) > localIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
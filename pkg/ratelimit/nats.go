@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// natsBucketTTL — бюджет без обращений дольше TTL удаляется из KV (и считается полным)
+	natsBucketTTL = 10 * time.Minute
+	// maxCASAttempts — сколько раз повторять обновление при гонке с другой репликой
+	maxCASAttempts = 10
+)
+
+// NATSLimiter хранит бюджеты в NATS JetStream KV, поэтому их делят все реплики.
+// Обновление — compare-and-swap по ревизии ключа. Время берётся с часов реплики:
+// они должны быть синхронизированы (NTP).
+type NATSLimiter struct {
+	kv  jetstream.KeyValue
+	now func() time.Time
+}
+
+// NewNATSLimiter создаёт (или открывает) KV bucket с бюджетами
+func NewNATSLimiter(ctx context.Context, nc *nats.Conn, bucketName string) (*NATSLimiter, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      bucketName,
+		Description: "GoBlast rate limiter buckets",
+		TTL:         natsBucketTTL,
+		History:     1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create kv bucket %s: %w", bucketName, err)
+	}
+	return &NATSLimiter{kv: kv, now: time.Now}, nil
+}
+
+func (l *NATSLimiter) Take(ctx context.Context, key string, rate Rate) (time.Duration, error) {
+	for attempt := 0; attempt < maxCASAttempts; attempt++ {
+		now := l.now()
+
+		var (
+			b        bucket
+			revision uint64
+		)
+		entry, err := l.kv.Get(ctx, key)
+		switch {
+		case errors.Is(err, jetstream.ErrKeyNotFound):
+			b = full(now, rate)
+		case err != nil:
+			return 0, err
+		default:
+			if err := json.Unmarshal(entry.Value(), &b); err != nil {
+				b = full(now, rate)
+			}
+			revision = entry.Revision()
+		}
+
+		b, wait := b.take(now, rate)
+		if wait > 0 {
+			// Токена нет: состояние не меняем, пересчёт сделает следующая попытка
+			return wait, nil
+		}
+
+		data, _ := json.Marshal(b)
+		if revision == 0 {
+			_, err = l.kv.Create(ctx, key, data)
+		} else {
+			_, err = l.kv.Update(ctx, key, data, revision)
+		}
+		if err == nil {
+			return 0, nil
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) {
+			return 0, err
+		}
+		// Другая реплика успела изменить бюджет — перечитываем
+	}
+	return 0, fmt.Errorf("rate limiter: too much contention on %s", key)
+}
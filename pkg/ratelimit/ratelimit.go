@@ -0,0 +1,89 @@
+// Package ratelimit — бюджеты отправок (token bucket), общие для всех реплик воркера.
+// Ключ задаёт бюджет (например, бот или чат бота), Rate — его скорость.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Rate — скорость пополнения бюджета и его ёмкость
+type Rate struct {
+	Limit float64 // токенов в секунду
+	Burst int     // максимум накопленных токенов
+}
+
+// Every возвращает Rate: один токен раз в interval, без накопления
+func Every(interval time.Duration) Rate {
+	return Rate{Limit: float64(time.Second) / float64(interval), Burst: 1}
+}
+
+// Limiter выдаёт токены из бюджетов по ключам
+type Limiter interface {
+	// Take берёт один токен из бюджета key. Если токена нет, ничего не берёт
+	// и возвращает, через сколько он появится.
+	Take(ctx context.Context, key string, rate Rate) (time.Duration, error)
+}
+
+// Wait ждёт, пока из бюджета key удастся взять токен
+func Wait(ctx context.Context, l Limiter, key string, rate Rate) error {
+	for {
+		wait, err := l.Take(ctx, key, rate)
+		if err != nil {
+			return err
+		}
+		if wait <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// bucket — состояние token bucket
+type bucket struct {
+	Tokens float64 `json:"t"`
+	Stamp  int64   `json:"s"` // время последнего пересчёта, unix nano
+}
+
+// take пересчитывает бюджет на момент now и пытается взять токен.
+// Возвращает новое состояние и ожидание (0 — токен взят).
+func (b bucket) take(now time.Time, rate Rate) (bucket, time.Duration) {
+	burst := float64(rate.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	elapsed := now.Sub(time.Unix(0, b.Stamp)).Seconds()
+	if elapsed > 0 {
+		b.Tokens += elapsed * rate.Limit
+	}
+	if b.Tokens > burst {
+		b.Tokens = burst
+	}
+	b.Stamp = now.UnixNano()
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return b, 0
+	}
+	if rate.Limit <= 0 {
+		return b, time.Second
+	}
+	return b, time.Duration((1 - b.Tokens) / rate.Limit * float64(time.Second))
+}
+
+// full — полный бюджет на момент now
+func full(now time.Time, rate Rate) bucket {
+	burst := rate.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return bucket{Tokens: float64(burst), Stamp: now.UnixNano()}
+}
@@ -0,0 +1,249 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"GoBlast/pkg/logger"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// fakeClock — управляемые часы для лимитеров
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newClock() *fakeClock {
+	return &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// takeN берёт токены, пока они выдаются без ожидания, и возвращает их число
+func takeN(t *testing.T, l Limiter, key string, rate Rate, max int) int {
+	t.Helper()
+	for i := 0; i < max; i++ {
+		wait, err := l.Take(context.Background(), key, rate)
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		if wait > 0 {
+			return i
+		}
+	}
+	return max
+}
+
+func TestLocalLimiterBurstAndRefill(t *testing.T) {
+	clock := newClock()
+	l := NewLocalLimiter()
+	l.now = clock.now
+	rate := Rate{Limit: 10, Burst: 3}
+
+	if got := takeN(t, l, "bot", rate, 10); got != 3 {
+		t.Fatalf("burst: took %d, want 3", got)
+	}
+
+	wait, _ := l.Take(context.Background(), "bot", rate)
+	if wait != 100*time.Millisecond {
+		t.Fatalf("wait = %v, want 100ms", wait)
+	}
+
+	clock.advance(200 * time.Millisecond)
+	if got := takeN(t, l, "bot", rate, 10); got != 2 {
+		t.Fatalf("after refill: took %d, want 2", got)
+	}
+
+	// Бюджеты разных ключей независимы
+	if got := takeN(t, l, "chat", Every(time.Second), 10); got != 1 {
+		t.Fatalf("other key: took %d, want 1", got)
+	}
+}
+
+func TestWaitHonoursContext(t *testing.T) {
+	l := NewLocalLimiter()
+	rate := Rate{Limit: 0.001, Burst: 1}
+	if err := Wait(context.Background(), l, "k", rate); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := Wait(ctx, l, "k", rate); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait = %v, want deadline exceeded", err)
+	}
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Take(context.Context, string, Rate) (time.Duration, error) {
+	return 0, errors.New("nats: connection closed")
+}
+
+func TestFallbackLimiterUsesLocal(t *testing.T) {
+	clock := newClock()
+	local := NewLocalLimiter()
+	local.now = clock.now
+	l := NewFallbackLimiter(failingLimiter{}, local)
+
+	if got := takeN(t, l, "bot", Rate{Limit: 1, Burst: 2}, 10); got != 2 {
+		t.Fatalf("fallback: took %d, want 2", got)
+	}
+}
+
+// hangingLimiter отвечает только по отмене контекста и считает обращения
+type hangingLimiter struct{ calls int }
+
+func (h *hangingLimiter) Take(ctx context.Context, _ string, _ Rate) (time.Duration, error) {
+	h.calls++
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func TestFallbackLimiterTimeoutAndCooldown(t *testing.T) {
+	clock := newClock()
+	primary := &hangingLimiter{}
+	l := NewFallbackLimiter(primary, NewLocalLimiter())
+	l.now = clock.now
+	rate := Rate{Limit: 100, Burst: 100}
+
+	start := time.Now()
+	if _, err := l.Take(context.Background(), "bot", rate); err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*primaryTimeout {
+		t.Fatalf("hanging primary delayed Take by %v", elapsed)
+	}
+
+	// Во время cool-down общий лимитер не опрашивается
+	for i := 0; i < 5; i++ {
+		if _, err := l.Take(context.Background(), "bot", rate); err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+	}
+	if primary.calls != 1 {
+		t.Fatalf("primary called %d times during cooldown, want 1", primary.calls)
+	}
+
+	clock.advance(fallbackCooldown)
+	_, _ = l.Take(context.Background(), "bot", rate)
+	if primary.calls != 2 {
+		t.Fatalf("primary called %d times after cooldown, want 2", primary.calls)
+	}
+}
+
+// runNATS запускает встроенный nats-server с JetStream
+func runNATS(t *testing.T) *nats.Conn {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("nats-server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats-server is not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+func TestNATSLimiterSharedAcrossReplicas(t *testing.T) {
+	nc := runNATS(t)
+	ctx := context.Background()
+	clock := newClock()
+
+	// Две «реплики» с общим бюджетом
+	a, err := NewNATSLimiter(ctx, nc, "test_ratelimit")
+	if err != nil {
+		t.Fatalf("NewNATSLimiter: %v", err)
+	}
+	b, err := NewNATSLimiter(ctx, nc, "test_ratelimit")
+	if err != nil {
+		t.Fatalf("NewNATSLimiter: %v", err)
+	}
+	a.now, b.now = clock.now, clock.now
+
+	rate := Rate{Limit: 5, Burst: 4}
+	taken := takeN(t, a, "tg-1", rate, 3) + takeN(t, b, "tg-1", rate, 10)
+	if taken != 4 {
+		t.Fatalf("replicas took %d tokens together, want burst 4", taken)
+	}
+
+	wait, err := a.Take(ctx, "tg-1", rate)
+	if err != nil || wait != 200*time.Millisecond {
+		t.Fatalf("Take = %v, %v; want 200ms wait", wait, err)
+	}
+
+	clock.advance(time.Second)
+	if got := takeN(t, b, "tg-1", rate, 10); got != 4 {
+		t.Fatalf("after refill: took %d, want 4", got)
+	}
+
+	// Бюджет чата независим от бюджета бота
+	if got := takeN(t, a, "tg-1.chat.-100500", Every(time.Second), 10); got != 1 {
+		t.Fatalf("chat budget: took %d, want 1", got)
+	}
+}
+
+func TestNATSLimiterConcurrentTakes(t *testing.T) {
+	nc := runNATS(t)
+	ctx := context.Background()
+	clock := newClock()
+
+	const replicas = 4
+	limiters := make([]*NATSLimiter, replicas)
+	for i := range limiters {
+		l, err := NewNATSLimiter(ctx, nc, "test_concurrent")
+		if err != nil {
+			t.Fatalf("NewNATSLimiter: %v", err)
+		}
+		l.now = clock.now
+		limiters[i] = l
+	}
+
+	rate := Rate{Limit: 1, Burst: 20}
+	results := make(chan int, replicas)
+	for _, l := range limiters {
+		go func(l *NATSLimiter) {
+			n := 0
+			for i := 0; i < 20; i++ {
+				wait, err := l.Take(ctx, "tg-2", rate)
+				if err == nil && wait == 0 {
+					n++
+				}
+			}
+			results <- n
+		}(l)
+	}
+
+	total := 0
+	for i := 0; i < replicas; i++ {
+		total += <-results
+	}
+	if total != 20 {
+		t.Fatalf("concurrent replicas took %d tokens, want exactly 20", total)
+	}
+}
@@ -1,28 +1,24 @@
 package db
 
 import (
-	"GoBlast/pkg/logger"
-	"GoBlast/pkg/storage/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
+// InitDB подключается к БД и применяет миграции
 func InitDB(dsn string) error {
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
+	if err := Connect(dsn); err != nil {
 		return err
 	}
-	err = DB.AutoMigrate(
+	_, err := MigrateUp(DB)
+	return err
+}
 
-		&models.AuthUser{},
-		&models.Task{},
-	)
-	if err != nil {
-		return err
-	}
-	logger.Log.Info("Миграция прошла успешно")
-	return nil
+// Connect подключается к БД без изменения схемы
+func Connect(dsn string) error {
+	var err error
+	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	return err
 }
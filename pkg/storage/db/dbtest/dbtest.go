@@ -0,0 +1,50 @@
+// Package dbtest даёт тестам репозиториев отдельную схему на локальном Postgres
+// с применёнными миграциями
+package dbtest
+
+import (
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/storage/db"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// DSNEnv — DSN локального Postgres, например
+// "host=localhost port=5432 user=postgres password=3215 dbname=goblast sslmode=disable"
+const DSNEnv = "GOBLAST_TEST_POSTGRES_DSN"
+
+// Open создаёт схему, применяет к ней миграции и возвращает соединение с ней.
+// Схема удаляется после теста; без GOBLAST_TEST_POSTGRES_DSN тест пропускается.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", DSNEnv)
+	}
+	logger.Log = zap.NewNop()
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("goblast_test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	conn, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.MigrateUp(conn); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return conn
+}
@@ -0,0 +1,238 @@
+package db
+
+import (
+	"GoBlast/pkg/logger"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Миграции схемы — пары файлов NNNN_name.up.sql и NNNN_name.down.sql.
+// Применённые версии записываются в schema_migrations.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID — ключ advisory-блокировки: реплики, стартующие одновременно,
+// применяют миграции по очереди
+const migrationLockID = 7242690461
+
+var migrationName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// baselineVersion — исходная схема; её откат удаляет все таблицы с данными
+const baselineVersion = 1
+
+// ErrBaselineDown — откат исходной схемы без явного разрешения
+var ErrBaselineDown = errors.New("reverting the baseline migration drops all tables; pass dropSchema to confirm")
+
+// Migration — одна версия схемы
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState — миграция и время её применения (nil — не применена)
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration — строка таблицы версий схемы
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// Migrations возвращает встроенные миграции по возрастанию версии
+func Migrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles, "migrations")
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		m := migrationName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		body, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has different names: %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", mig.Version, mig.Name)
+		}
+		list = append(list, *mig)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// MigrateUp применяет все ещё не применённые миграции; каждая — в своей транзакции
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureSchemaTable(db); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, mig := range migrations {
+		done, err := runLocked(db, func(tx *gorm.DB, current map[int64]bool) (bool, error) {
+			if current[mig.Version] {
+				return false, nil
+			}
+			if err := tx.Exec(mig.Up).Error; err != nil {
+				return false, err
+			}
+			return true, tx.Create(&schemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+		}
+		if done {
+			logger.Log.Info("Миграция применена", zap.Int64("version", mig.Version), zap.String("name", mig.Name))
+			applied = append(applied, mig)
+		}
+	}
+	return applied, nil
+}
+
+// MigrateDown откатывает steps последних применённых миграций. Исходная схема
+// откатывается только при dropSchema, иначе откат останавливается на ней с ErrBaselineDown.
+func MigrateDown(db *gorm.DB, steps int, dropSchema bool) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureSchemaTable(db); err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		mig := migrations[i]
+		done, err := runLocked(db, func(tx *gorm.DB, current map[int64]bool) (bool, error) {
+			if !current[mig.Version] {
+				return false, nil
+			}
+			if mig.Version == baselineVersion && !dropSchema {
+				return false, ErrBaselineDown
+			}
+			if err := tx.Exec(mig.Down).Error; err != nil {
+				return false, err
+			}
+			return true, tx.Delete(&schemaMigration{}, "version = ?", mig.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+		}
+		if done {
+			logger.Log.Info("Миграция откачена", zap.Int64("version", mig.Version), zap.String("name", mig.Name))
+			reverted = append(reverted, mig)
+		}
+	}
+	return reverted, nil
+}
+
+// MigrationStatus возвращает все миграции с отметкой о применении
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureSchemaTable(db); err != nil {
+		return nil, err
+	}
+
+	var rows []schemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		appliedAt[row.Version] = row.AppliedAt
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, mig := range migrations {
+		state := MigrationState{Migration: mig}
+		if at, ok := appliedAt[mig.Version]; ok {
+			state.AppliedAt = &at
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func ensureSchemaTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       varchar(255) NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`).Error
+}
+
+// runLocked выполняет fn в транзакции под advisory-блокировкой, передавая
+// набор применённых версий, прочитанный уже после получения блокировки
+func runLocked(db *gorm.DB, fn func(tx *gorm.DB, applied map[int64]bool) (bool, error)) (bool, error) {
+	var done bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return err
+		}
+
+		var versions []int64
+		if err := tx.Model(&schemaMigration{}).Pluck("version", &versions).Error; err != nil {
+			return err
+		}
+		applied := make(map[int64]bool, len(versions))
+		for _, v := range versions {
+			applied[v] = true
+		}
+
+		var err error
+		done, err = fn(tx, applied)
+		return err
+	})
+	return done, err
+}
@@ -0,0 +1,237 @@
+package db
+
+import (
+	"GoBlast/pkg/logger"
+	"GoBlast/pkg/storage/models"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// testDSNEnv — DSN локального Postgres для проверки миграций, например
+// "host=localhost port=5432 user=postgres password=3215 dbname=goblast sslmode=disable"
+const testDSNEnv = "GOBLAST_TEST_POSTGRES_DSN"
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, mig := range migrations {
+		if mig.Version != int64(i+1) {
+			t.Fatalf("migration versions must be sequential: got %d at position %d", mig.Version, i)
+		}
+	}
+}
+
+func TestLoadMigrationsRequiresDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0001_init.up.sql":   {Data: []byte("SELECT 1;")},
+		"m/0001_init.down.sql": {Data: []byte("SELECT 1;")},
+		"m/0002_more.up.sql":   {Data: []byte("SELECT 2;")},
+	}
+	if _, err := loadMigrations(fsys, "m"); err == nil {
+		t.Fatal("expected error for migration without down file")
+	}
+}
+
+// testSchema открывает соединение с отдельной схемой на локальном Postgres;
+// без GOBLAST_TEST_POSTGRES_DSN тест пропускается
+func testSchema(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+	logger.Log = zap.NewNop()
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("goblast_migrate_test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	conn, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// checkSchema сверяет таблицы, колонки и индексы с моделями
+func checkSchema(t *testing.T, conn *gorm.DB) {
+	t.Helper()
+	for _, model := range []interface{}{
+		&models.Organization{}, &models.AuthUser{}, &models.Task{}, &models.TaskMessage{},
+		&models.TaskLink{}, &models.LinkClick{}, &models.Audience{}, &models.AudienceMember{},
+		&models.Subscriber{}, &models.Bot{}, &models.APIKey{}, &models.RefreshToken{},
+		&models.RevokedToken{}, &models.BotLease{}, &models.BotLimit{}, &models.TaskStatusHistory{},
+	} {
+		stmt := &gorm.Statement{DB: conn}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatal(err)
+		}
+		if !conn.Migrator().HasTable(model) {
+			t.Errorf("table %s is missing", stmt.Schema.Table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !conn.Migrator().HasColumn(model, field.DBName) {
+				t.Errorf("column %s.%s is missing", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+	for _, index := range []string{"idx_tasks_user_id_created_at", "idx_tasks_status", "idx_tasks_schedule"} {
+		if !conn.Migrator().HasIndex(&models.Task{}, index) {
+			t.Errorf("index %s is missing", index)
+		}
+	}
+	if !conn.Migrator().HasIndex(&models.TaskStatusHistory{}, "idx_task_status_history_task_id_created_at") {
+		t.Error("index idx_task_status_history_task_id_created_at is missing")
+	}
+	if !conn.Migrator().HasConstraint(&models.Task{}, "chk_tasks_status") {
+		t.Error("constraint chk_tasks_status is missing")
+	}
+}
+
+// TestMigrationsPostgres применяет и откатывает миграции на локальном Postgres
+// в отдельной схеме и сверяет таблицы с моделями
+func TestMigrationsPostgres(t *testing.T) {
+	conn := testSchema(t)
+
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := MigrateUp(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(migrations))
+	}
+
+	// Повторный запуск ничего не меняет
+	if again, err := MigrateUp(conn); err != nil || len(again) != 0 {
+		t.Fatalf("second up: applied %d, err %v", len(again), err)
+	}
+
+	checkSchema(t, conn)
+
+	states, err := MigrationStatus(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range states {
+		if st.AppliedAt == nil {
+			t.Errorf("migration %d is not marked as applied", st.Version)
+		}
+	}
+
+	// Без разрешения откат останавливается на исходной схеме
+	reverted, err := MigrateDown(conn, len(migrations), false)
+	if !errors.Is(err, ErrBaselineDown) {
+		t.Fatalf("down without dropSchema: err %v, want ErrBaselineDown", err)
+	}
+	if len(reverted) != len(migrations)-1 {
+		t.Fatalf("reverted %d migrations, want %d", len(reverted), len(migrations)-1)
+	}
+	if !conn.Migrator().HasTable(&models.Task{}) {
+		t.Fatal("tasks table must survive down without dropSchema")
+	}
+
+	reverted, err = MigrateDown(conn, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 {
+		t.Fatalf("reverted %d migrations, want 1", len(reverted))
+	}
+	if conn.Migrator().HasTable(&models.Task{}) {
+		t.Error("tasks table must be dropped after down")
+	}
+}
+
+// baselineAuthUser и baselineTask — таблицы, которые создавал AutoMigrate
+// до версионных миграций
+type baselineAuthUser struct {
+	ID        uint      `gorm:"primaryKey"`
+	Username  string    `gorm:"unique;not null"`
+	Token     string    `gorm:"type:varchar(512)"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (baselineAuthUser) TableName() string { return "auth_users" }
+
+type baselineTask struct {
+	ID          string         `gorm:"primaryKey"`
+	UserID      uint           `gorm:"not null"`
+	MessageType string         `gorm:"type:varchar(20);not null"`
+	Content     string         `gorm:"type:jsonb;not null"`
+	Priority    string         `gorm:"type:varchar(10);default:'medium'"`
+	Schedule    *time.Time     `gorm:"type:timestamp"`
+	Status      string         `gorm:"type:varchar(20);not null"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	Stats       *string        `gorm:"type:jsonb"`
+}
+
+func (baselineTask) TableName() string { return "tasks" }
+
+// TestMigrationsPostgresFromBaseline применяет миграции к базе, созданной прежней
+// версией через AutoMigrate, и проверяет, что данные сохранились, а схема дополнена
+func TestMigrationsPostgresFromBaseline(t *testing.T) {
+	conn := testSchema(t)
+
+	if err := conn.AutoMigrate(&baselineAuthUser{}, &baselineTask{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Create(&baselineAuthUser{Username: "legacy", Token: "encrypted"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	stats := `"{\"total_sent\":3,\"total_failed\":1,\"by_content_type\":{\"text\":3},\"time_spent\":1.5}"`
+	legacyTask := baselineTask{ID: "legacy-task", UserID: 1, MessageType: "text", Content: `{"text":"hi"}`, Status: "complete", Stats: &stats}
+	if err := conn.Create(&legacyTask).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateUp(conn); err != nil {
+		t.Fatal(err)
+	}
+	checkSchema(t, conn)
+
+	var user models.AuthUser
+	if err := conn.Where("username = ?", "legacy").First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != models.RoleAdmin {
+		t.Errorf("legacy user role = %q, want %q", user.Role, models.RoleAdmin)
+	}
+
+	var task models.Task
+	if err := conn.First(&task, "id = ?", "legacy-task").Error; err != nil {
+		t.Fatal(err)
+	}
+	if task.Action != "send" || task.Status != models.TaskComplete {
+		t.Errorf("legacy task action %q status %q", task.Action, task.Status)
+	}
+	if task.Stats == nil || task.Stats.TotalSent != 3 || task.Stats.ExpectedCount != 4 {
+		t.Errorf("legacy task stats = %+v", task.Stats)
+	}
+}
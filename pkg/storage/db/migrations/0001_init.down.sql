@@ -0,0 +1,18 @@
+-- Разрушающий откат: удаляет все таблицы вместе с данными, включая auth_users и tasks,
+-- которые существовали до версионных миграций. MigrateDown выполняет его только
+-- с явным разрешением (goblast migrate down -drop-schema).
+DROP TABLE IF EXISTS bot_limits;
+DROP TABLE IF EXISTS bot_leases;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS bots;
+DROP TABLE IF EXISTS subscribers;
+DROP TABLE IF EXISTS audience_members;
+DROP TABLE IF EXISTS audiences;
+DROP TABLE IF EXISTS link_clicks;
+DROP TABLE IF EXISTS task_links;
+DROP TABLE IF EXISTS task_messages;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS auth_users;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,229 @@
+-- Исходная схема: повторяет таблицы, которые раньше создавал AutoMigrate.
+-- В базе, созданной прежней версией, уже есть auth_users и tasks без новых колонок:
+-- CREATE TABLE IF NOT EXISTS их пропускает, поэтому колонки добавляются отдельно.
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id         bigserial PRIMARY KEY,
+    name       varchar(255) NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS auth_users (
+    id                  bigserial PRIMARY KEY,
+    username            text NOT NULL,
+    token               varchar(512),
+    created_at          timestamptz,
+    updated_at          timestamptz,
+    bot_telegram_id     bigint,
+    bot_username        varchar(64),
+    bot_name            varchar(255),
+    bot_status          varchar(16) NOT NULL DEFAULT 'active',
+    updates_mode        varchar(16) NOT NULL DEFAULT 'off',
+    webhook_secret      varchar(64),
+    password_hash       varchar(100),
+    org_id              bigint,
+    role                varchar(16) NOT NULL DEFAULT 'admin',
+    totp_secret         varchar(64),
+    totp_enabled        boolean NOT NULL DEFAULT false,
+    sessions_revoked_at timestamptz,
+    CONSTRAINT uni_auth_users_username UNIQUE (username)
+);
+ALTER TABLE auth_users
+    ADD COLUMN IF NOT EXISTS bot_telegram_id     bigint,
+    ADD COLUMN IF NOT EXISTS bot_username        varchar(64),
+    ADD COLUMN IF NOT EXISTS bot_name            varchar(255),
+    ADD COLUMN IF NOT EXISTS bot_status          varchar(16) NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS updates_mode        varchar(16) NOT NULL DEFAULT 'off',
+    ADD COLUMN IF NOT EXISTS webhook_secret      varchar(64),
+    ADD COLUMN IF NOT EXISTS password_hash       varchar(100),
+    ADD COLUMN IF NOT EXISTS org_id              bigint,
+    ADD COLUMN IF NOT EXISTS role                varchar(16) NOT NULL DEFAULT 'admin',
+    ADD COLUMN IF NOT EXISTS totp_secret         varchar(64),
+    ADD COLUMN IF NOT EXISTS totp_enabled        boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS sessions_revoked_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_auth_users_org_id ON auth_users (org_id);
+CREATE INDEX IF NOT EXISTS idx_auth_users_bot_telegram_id ON auth_users (bot_telegram_id);
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id             text PRIMARY KEY,
+    user_id        bigint NOT NULL,
+    org_id         bigint,
+    bot_id         bigint,
+    action         varchar(20) NOT NULL DEFAULT 'send',
+    parent_id      text,
+    message_type   varchar(20) NOT NULL,
+    content        jsonb NOT NULL,
+    variants       jsonb,
+    holdout        jsonb,
+    audience_id    bigint,
+    tag_expr       text,
+    to_subscribers boolean NOT NULL DEFAULT false,
+    language_code  varchar(16),
+    priority       varchar(10) DEFAULT 'medium',
+    schedule       timestamp,
+    status         varchar(20) NOT NULL,
+    status_reason  text,
+    created_at     timestamptz,
+    updated_at     timestamptz,
+    deleted_at     timestamptz,
+    stats          jsonb
+);
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS org_id         bigint,
+    ADD COLUMN IF NOT EXISTS bot_id         bigint,
+    ADD COLUMN IF NOT EXISTS action         varchar(20) NOT NULL DEFAULT 'send',
+    ADD COLUMN IF NOT EXISTS parent_id      text,
+    ADD COLUMN IF NOT EXISTS variants       jsonb,
+    ADD COLUMN IF NOT EXISTS holdout        jsonb,
+    ADD COLUMN IF NOT EXISTS audience_id    bigint,
+    ADD COLUMN IF NOT EXISTS tag_expr       text,
+    ADD COLUMN IF NOT EXISTS to_subscribers boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS language_code  varchar(16),
+    ADD COLUMN IF NOT EXISTS status_reason  text;
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_audience_id ON tasks (audience_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks (parent_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_bot_id ON tasks (bot_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_org_id ON tasks (org_id);
+
+CREATE TABLE IF NOT EXISTS task_messages (
+    id         bigserial PRIMARY KEY,
+    task_id    varchar(36) NOT NULL,
+    recipient  bigint NOT NULL,
+    message_id bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_task_messages_task_id ON task_messages (task_id);
+
+CREATE TABLE IF NOT EXISTS task_links (
+    id         bigserial PRIMARY KEY,
+    task_id    varchar(36) NOT NULL,
+    variant    varchar(64),
+    url        text NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_task_links_task_id ON task_links (task_id);
+
+CREATE TABLE IF NOT EXISTS link_clicks (
+    id         bigserial PRIMARY KEY,
+    link_id    bigint NOT NULL,
+    task_id    varchar(36) NOT NULL,
+    recipient  bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_link_clicks_task_id ON link_clicks (task_id);
+CREATE INDEX IF NOT EXISTS idx_link_clicks_link_id ON link_clicks (link_id);
+
+CREATE TABLE IF NOT EXISTS audiences (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    name       varchar(255) NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audiences_user_id ON audiences (user_id);
+
+CREATE TABLE IF NOT EXISTS audience_members (
+    id          bigserial PRIMARY KEY,
+    audience_id bigint NOT NULL,
+    chat_id     bigint NOT NULL,
+    tags        jsonb NOT NULL DEFAULT '[]',
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audience_member ON audience_members (audience_id, chat_id);
+
+CREATE TABLE IF NOT EXISTS subscribers (
+    id            bigserial PRIMARY KEY,
+    user_id       bigint NOT NULL,
+    chat_id       bigint NOT NULL,
+    username      varchar(64),
+    first_name    varchar(255),
+    language_code varchar(16),
+    status        varchar(16) NOT NULL,
+    started_at    timestamptz,
+    left_at       timestamptz,
+    updated_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_subscribers_status ON subscribers (status);
+CREATE INDEX IF NOT EXISTS idx_subscribers_language_code ON subscribers (language_code);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriber_chat ON subscribers (user_id, chat_id);
+
+CREATE TABLE IF NOT EXISTS bots (
+    id               bigserial PRIMARY KEY,
+    user_id          bigint NOT NULL,
+    org_id           bigint NOT NULL DEFAULT 0,
+    name             varchar(255) NOT NULL,
+    token            varchar(512) NOT NULL,
+    username         varchar(64),
+    status           varchar(16) NOT NULL DEFAULT 'active',
+    created_at       timestamptz,
+    updated_at       timestamptz,
+    deleted_at       timestamptz,
+    telegram_id      bigint,
+    telegram_name    varchar(255),
+    status_reason    text,
+    last_checked_at  timestamptz,
+    last_check_error text
+);
+CREATE INDEX IF NOT EXISTS idx_bots_telegram_id ON bots (telegram_id);
+CREATE INDEX IF NOT EXISTS idx_bots_deleted_at ON bots (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_bots_org_id ON bots (org_id);
+CREATE INDEX IF NOT EXISTS idx_bots_user_id ON bots (user_id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           bigserial PRIMARY KEY,
+    user_id      bigint NOT NULL,
+    org_id       bigint NOT NULL,
+    name         varchar(255) NOT NULL,
+    prefix       varchar(16) NOT NULL,
+    hash         varchar(64) NOT NULL,
+    scopes       varchar(255) NOT NULL,
+    last_used_at timestamptz,
+    expires_at   timestamptz,
+    revoked_at   timestamptz,
+    created_at   timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_org_id ON api_keys (org_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id          bigserial PRIMARY KEY,
+    user_id     bigint NOT NULL,
+    hash        varchar(64) NOT NULL,
+    access_jti  varchar(36),
+    expires_at  timestamptz NOT NULL,
+    revoked_at  timestamptz,
+    replaced_by bigint,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_access_jti ON refresh_tokens (access_jti);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_hash ON refresh_tokens (hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti        varchar(36) PRIMARY KEY,
+    expires_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS bot_leases (
+    bot_key    varchar(32) PRIMARY KEY,
+    owner      varchar(128) NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_bot_leases_expires_at ON bot_leases (expires_at);
+CREATE INDEX IF NOT EXISTS idx_bot_leases_owner ON bot_leases (owner);
+
+CREATE TABLE IF NOT EXISTS bot_limits (
+    bot_id             bigint PRIMARY KEY,
+    concurrency        bigint,
+    queue_size         bigint,
+    rate_high          decimal,
+    rate_medium        decimal,
+    rate_low           decimal,
+    retry_max_attempts bigint,
+    updated_at         timestamptz
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_schedule;
+DROP INDEX IF EXISTS idx_tasks_status;
+DROP INDEX IF EXISTS idx_tasks_user_id_created_at;
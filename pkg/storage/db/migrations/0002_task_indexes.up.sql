@@ -0,0 +1,4 @@
+-- Список задач пользователя по дате создания, выборки по статусу и отложенные задачи
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id_created_at ON tasks (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks (status);
+CREATE INDEX IF NOT EXISTS idx_tasks_schedule ON tasks (schedule) WHERE schedule IS NOT NULL;
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS task_status_history;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS chk_tasks_status;
+ALTER TABLE tasks DROP COLUMN IF EXISTS version;
+
+-- Прежний код знает только scheduled, awaiting_recipients, complete и failed
+UPDATE tasks SET status = 'awaiting_recipients' WHERE status = 'draft';
+UPDATE tasks SET status = 'scheduled' WHERE status IN ('queued', 'running', 'paused');
+UPDATE tasks SET status = 'complete' WHERE status = 'partially_failed';
+UPDATE tasks SET status = 'failed' WHERE status = 'cancelled';
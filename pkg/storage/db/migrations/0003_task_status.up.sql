@@ -0,0 +1,19 @@
+-- Статусы задачи как конечный автомат: версия для оптимистичной блокировки и история смен
+UPDATE tasks SET status = 'draft' WHERE status = 'awaiting_recipients';
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD CONSTRAINT chk_tasks_status CHECK (status IN (
+    'draft', 'scheduled', 'queued', 'running', 'paused',
+    'cancelled', 'complete', 'partially_failed', 'failed'
+));
+
+CREATE TABLE IF NOT EXISTS task_status_history (
+    id          bigserial PRIMARY KEY,
+    task_id     text NOT NULL,
+    from_status varchar(20),
+    to_status   varchar(20) NOT NULL,
+    actor       varchar(64) NOT NULL,
+    reason      text,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_task_status_history_task_id_created_at ON task_status_history (task_id, created_at);
@@ -0,0 +1,2 @@
+-- Прежний код игнорирует новые поля статистики: откатывать нечего
+SELECT 1;
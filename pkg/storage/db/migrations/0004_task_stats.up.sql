@@ -0,0 +1,12 @@
+-- Статистика, сохранённая строкой JSON, становится объектом
+UPDATE tasks SET stats = (stats #>> '{}')::jsonb WHERE jsonb_typeof(stats) = 'string';
+
+-- Прежняя статистика не хранила счётчики получателей и ошибки: восстанавливаем счётчики
+-- по отправленным и неудачным, пустые ошибки и повторы
+UPDATE tasks SET stats = stats || jsonb_build_object(
+    'expected_count',  COALESCE((stats->>'total_sent')::bigint, 0) + COALESCE((stats->>'total_failed')::bigint, 0),
+    'processed_count', COALESCE((stats->>'total_sent')::bigint, 0) + COALESCE((stats->>'total_failed')::bigint, 0),
+    'total_retried',   0,
+    'error_counts',    '{}'::jsonb
+)
+WHERE jsonb_typeof(stats) = 'object' AND stats->'expected_count' IS NULL;
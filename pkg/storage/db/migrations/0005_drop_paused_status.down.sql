@@ -0,0 +1,5 @@
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS chk_tasks_status;
+ALTER TABLE tasks ADD CONSTRAINT chk_tasks_status CHECK (status IN (
+    'draft', 'scheduled', 'queued', 'running', 'paused',
+    'cancelled', 'complete', 'partially_failed', 'failed'
+));
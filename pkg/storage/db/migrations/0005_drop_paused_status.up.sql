@@ -0,0 +1,8 @@
+-- Статус paused убран: задачу нельзя приостановить, только отменить (cancelled)
+UPDATE tasks SET status = 'queued' WHERE status = 'paused';
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS chk_tasks_status;
+ALTER TABLE tasks ADD CONSTRAINT chk_tasks_status CHECK (status IN (
+    'draft', 'scheduled', 'queued', 'running',
+    'cancelled', 'complete', 'partially_failed', 'failed'
+));
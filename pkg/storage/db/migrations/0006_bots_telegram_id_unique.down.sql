@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_bots_telegram_id_active;
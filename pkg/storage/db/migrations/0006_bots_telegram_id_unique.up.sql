@@ -0,0 +1,13 @@
+-- Один Telegram-бот подключается только один раз: проверка дубликатов в API не защищает
+-- от параллельных запросов. Из уже задвоенных ботов остаётся самый ранний, остальные
+-- удаляются мягко (задачи продолжают на них ссылаться).
+UPDATE bots SET deleted_at = now()
+WHERE deleted_at IS NULL
+  AND telegram_id <> 0
+  AND id <> (
+      SELECT min(b.id) FROM bots b
+      WHERE b.telegram_id = bots.telegram_id AND b.deleted_at IS NULL
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bots_telegram_id_active ON bots (telegram_id)
+    WHERE deleted_at IS NULL AND telegram_id <> 0;
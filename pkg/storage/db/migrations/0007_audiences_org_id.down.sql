@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_audiences_org_id;
+ALTER TABLE audiences DROP COLUMN IF EXISTS org_id;
@@ -0,0 +1,8 @@
+-- Аудитории, как и задачи, видят все участники организации автора.
+ALTER TABLE audiences ADD COLUMN IF NOT EXISTS org_id bigint;
+
+UPDATE audiences SET org_id = auth_users.org_id
+FROM auth_users
+WHERE auth_users.id = audiences.user_id AND audiences.org_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audiences_org_id ON audiences (org_id);
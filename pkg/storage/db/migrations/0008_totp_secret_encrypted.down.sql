@@ -0,0 +1,5 @@
+-- Зашифрованные секреты не помещаются в прежний размер столбца: 2FA у таких
+-- пользователей отключается, её нужно будет настроить заново.
+UPDATE auth_users SET totp_secret = '', totp_enabled = false
+WHERE length(totp_secret) > 64;
+ALTER TABLE auth_users ALTER COLUMN totp_secret TYPE varchar(64);
@@ -0,0 +1,2 @@
+-- Секрет TOTP хранится зашифрованным (AES-GCM + base64), в varchar(64) он не помещается.
+ALTER TABLE auth_users ALTER COLUMN totp_secret TYPE varchar(255);
@@ -0,0 +1,7 @@
+-- Подписчики ботов из /api/bots в прежней схеме не различаются и удаляются
+DELETE FROM subscribers WHERE bot_id <> 0;
+
+DROP INDEX IF EXISTS idx_subscribers_bot_id;
+DROP INDEX IF EXISTS idx_subscriber_chat;
+ALTER TABLE subscribers DROP COLUMN IF EXISTS bot_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriber_chat ON subscribers (user_id, chat_id);
@@ -0,0 +1,7 @@
+-- Подписчики учитываются отдельно для каждого бота: bot_id — бот из /api/bots,
+-- 0 — бот по умолчанию аккаунта user_id. У подписчиков ботов из /api/bots user_id = 0.
+ALTER TABLE subscribers ADD COLUMN IF NOT EXISTS bot_id bigint NOT NULL DEFAULT 0;
+
+DROP INDEX IF EXISTS idx_subscriber_chat;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriber_chat ON subscribers (user_id, bot_id, chat_id);
+CREATE INDEX IF NOT EXISTS idx_subscribers_bot_id ON subscribers (bot_id);
@@ -0,0 +1,3 @@
+ALTER TABLE bots
+    DROP COLUMN IF EXISTS webhook_secret,
+    DROP COLUMN IF EXISTS updates_mode;
@@ -0,0 +1,4 @@
+-- Сбор подписчиков (polling или webhook) включается для каждого бота из /api/bots
+ALTER TABLE bots
+    ADD COLUMN IF NOT EXISTS updates_mode   varchar(16) NOT NULL DEFAULT 'off',
+    ADD COLUMN IF NOT EXISTS webhook_secret varchar(64);
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Scopes API-ключей
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+)
+
+// APIKey — долгоживущий ключ для доступа сервисов к API.
+// Сам ключ не хранится: только его префикс для поиска и SHA-256 хеш.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"` // от чьего имени действует ключ
+	OrgID      uint       `gorm:"not null;index" json:"org_id"`
+	Name       string     `gorm:"type:varchar(255);not null" json:"name"`
+	Prefix     string     `gorm:"type:varchar(16);not null;uniqueIndex" json:"prefix"` // видимая часть ключа: gb_<prefix>_...
+	Hash       string     `gorm:"type:varchar(64);not null" json:"-"`
+	Scopes     string     `gorm:"type:varchar(255);not null" json:"scopes"` // через пробел, например "tasks:read tasks:write"
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
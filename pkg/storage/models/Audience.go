@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Audience — сохранённый список получателей пользователя
+type Audience struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	OrgID       *uint     `gorm:"index" json:"org_id,omitempty"` // организация автора: аудиторию видят все её участники
+	Name        string    `gorm:"type:varchar(255);not null" json:"name"`
+	MemberCount int64     `gorm:"-" json:"member_count"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// AudienceMember — получатель в аудитории с набором тегов
+type AudienceMember struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	AudienceID uint      `gorm:"not null;uniqueIndex:idx_audience_member" json:"audience_id"`
+	ChatID     int64     `gorm:"not null;uniqueIndex:idx_audience_member" json:"chat_id"`
+	Tags       string    `gorm:"type:jsonb;not null;default:'[]'" json:"tags"` // JSON-массив строк
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -9,7 +9,24 @@ import (
 type AuthUser struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	Username  string    `gorm:"unique;not null" json:"username"`  // Уникальное имя пользователя
-	Token     string    `gorm:"type:varchar(512)" json:"token"`   // Зашифрованный токен (увеличили длину)
+	Token     string    `gorm:"type:varchar(512)" json:"-"`       // Зашифрованный токен бота по умолчанию (необязателен)
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"` // Время создания записи
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"` // Время последнего обновления
+
+	// Данные бота по умолчанию из getMe
+	BotTelegramID int64  `gorm:"index" json:"bot_telegram_id,omitempty"`
+	BotUsername   string `gorm:"type:varchar(64)" json:"bot_username,omitempty"`
+	BotName       string `gorm:"type:varchar(255)" json:"bot_name,omitempty"`
+	BotStatus     string `gorm:"type:varchar(16);not null;default:'active'" json:"bot_status,omitempty"` // active, unauthorized
+
+	UpdatesMode   string `gorm:"type:varchar(16);not null;default:'off'" json:"updates_mode"` // off, polling, webhook
+	WebhookSecret string `gorm:"type:varchar(64)" json:"-"`                                   // секрет X-Telegram-Bot-Api-Secret-Token
+
+	PasswordHash string `gorm:"type:varchar(100)" json:"-"` // bcrypt; пусто у старых аккаунтов, входивших по токену бота
+	OrgID        *uint  `gorm:"index" json:"org_id"`
+	Role         string `gorm:"type:varchar(16);not null;default:'admin'" json:"role"` // admin, editor, viewer
+	TOTPSecret   string `gorm:"type:varchar(255)" json:"-"`                            // зашифрован тем же ключом, что и токены ботов
+	TOTPEnabled  bool   `gorm:"not null;default:false" json:"totp_enabled"`
+
+	SessionsRevokedAt *time.Time `json:"-"` // access-токены, выпущенные раньше, недействительны ("выйти везде")
 }
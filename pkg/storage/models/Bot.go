@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Статусы бота
+const (
+	BotActive       = "active"
+	BotDisabled     = "disabled"
+	BotUnauthorized = "unauthorized" // токен отозван в @BotFather: Telegram отвечает 401
+)
+
+// Bot — Telegram-бот организации, от имени которого идут рассылки
+type Bot struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"` // кто добавил бота
+	OrgID     uint           `gorm:"not null;default:0;index" json:"org_id"`
+	Name      string         `gorm:"type:varchar(255);not null" json:"name"`
+	Token     string         `gorm:"type:varchar(512);not null" json:"-"` // зашифрованный токен (base64)
+	Username  string         `gorm:"type:varchar(64)" json:"username"`    // @username из getMe
+	Status    string         `gorm:"type:varchar(16);not null;default:'active'" json:"status"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Данные из getMe: по TelegramID обнаруживается один бот, добавленный дважды.
+	// Среди неудалённых ботов TelegramID уникален (индекс idx_bots_telegram_id_active в миграции 0006).
+	TelegramID   int64  `gorm:"index" json:"telegram_id"`
+	TelegramName string `gorm:"type:varchar(255)" json:"telegram_name"`
+
+	// Сбор подписчиков из обновлений бота
+	UpdatesMode   string `gorm:"type:varchar(16);not null;default:'off'" json:"updates_mode"` // off, polling, webhook
+	WebhookSecret string `gorm:"type:varchar(64)" json:"-"`                                   // секрет X-Telegram-Bot-Api-Secret-Token
+
+	// Результаты проверки здоровья (getMe)
+	StatusReason   string     `gorm:"type:text" json:"status_reason,omitempty"`
+	LastCheckedAt  *time.Time `json:"last_checked_at,omitempty"`
+	LastCheckError string     `gorm:"type:text" json:"last_check_error,omitempty"` // сбой связи с Telegram при последней проверке
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// BotLease — владение ботом экземпляром воркера: задачи бота обрабатывает
+// только владелец, поэтому лимит скорости бота не умножается на число реплик
+type BotLease struct {
+	BotKey    string    `gorm:"primaryKey;type:varchar(32)" json:"bot_key"` // bot-<id> или user-<id> (бот по умолчанию)
+	Owner     string    `gorm:"type:varchar(128);not null;index" json:"owner"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+}
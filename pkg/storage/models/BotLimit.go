@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// BotLimit — ограничения воркера отдельного бота (например, бота с платными рассылками).
+// Пустое поле — значение из секции worker конфигурации.
+type BotLimit struct {
+	BotID            uint      `gorm:"primaryKey;autoIncrement:false" json:"bot_id"`
+	Concurrency      *int      `json:"concurrency,omitempty"`        // горутин отправки
+	QueueSize        *int      `json:"queue_size,omitempty"`         // буфер очереди получателей
+	RateHigh         *float64  `json:"rate_high,omitempty"`          // msg/sec при приоритете high
+	RateMedium       *float64  `json:"rate_medium,omitempty"`        // msg/sec при приоритете medium
+	RateLow          *float64  `json:"rate_low,omitempty"`           // msg/sec при приоритете low
+	RetryMaxAttempts *int      `json:"retry_max_attempts,omitempty"` // попыток отправки одному получателю
+	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Роли пользователей в организации
+const (
+	RoleAdmin  = "admin"  // всё, включая ботов и участников
+	RoleEditor = "editor" // создание и изменение рассылок, аудиторий
+	RoleViewer = "viewer" // только чтение
+)
+
+// roleRank упорядочивает роли по объёму прав
+var roleRank = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// ValidRole сообщает, известна ли роль
+func ValidRole(role string) bool {
+	return roleRank[role] > 0
+}
+
+// RoleAtLeast сообщает, что роль role даёт права не меньше required
+func RoleAtLeast(role, required string) bool {
+	return roleRank[role] >= roleRank[required] && roleRank[role] > 0
+}
+
+// Organization — организация, которой принадлежат пользователи и боты
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
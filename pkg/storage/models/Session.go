@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RefreshToken — refresh-токен сессии. Хранится только SHA-256 хеш.
+// При обновлении токен отзывается и заменяется новым (ReplacedBy).
+type RefreshToken struct {
+	ID         uint      `gorm:"primaryKey"`
+	UserID     uint      `gorm:"not null;index"`
+	Hash       string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	AccessJTI  string    `gorm:"type:varchar(36);index"` // jti access-токена, выданного вместе с ним
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	ReplacedBy *uint
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// RevokedToken — отозванный до истечения access-токен (по jti)
+type RevokedToken struct {
+	JTI       string    `gorm:"type:varchar(36);primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"` // после этого момента запись не нужна
+}
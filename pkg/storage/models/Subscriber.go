@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Статусы подписчика бота
+const (
+	SubscriberActive  = "active"  // нажал /start и не уходил
+	SubscriberStopped = "stopped" // отправил /stop
+	SubscriberBlocked = "blocked" // заблокировал бота
+)
+
+// Subscriber — пользователь, запустивший бота (собирается из обновлений Telegram).
+// Учитывается отдельно для каждого бота: BotID — бот из /api/bots (тогда UserID = 0),
+// иначе бот по умолчанию аккаунта UserID.
+type Subscriber struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;uniqueIndex:idx_subscriber_chat" json:"user_id"`                // владелец бота по умолчанию
+	BotID        uint       `gorm:"not null;default:0;uniqueIndex:idx_subscriber_chat;index" json:"bot_id"` // бот из /api/bots
+	ChatID       int64      `gorm:"not null;uniqueIndex:idx_subscriber_chat" json:"chat_id"`
+	Username     string     `gorm:"type:varchar(64)" json:"username,omitempty"`
+	FirstName    string     `gorm:"type:varchar(255)" json:"first_name,omitempty"`
+	LanguageCode string     `gorm:"type:varchar(16);index" json:"language_code,omitempty"`
+	Status       string     `gorm:"type:varchar(16);not null;index" json:"status"`
+	StartedAt    time.Time  `json:"started_at"`
+	LeftAt       *time.Time `json:"left_at,omitempty"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
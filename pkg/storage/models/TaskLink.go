@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// TaskLink — ссылка из рассылки, клики по которой отслеживаются
+type TaskLink struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TaskID    string    `gorm:"type:varchar(36);not null;index" json:"task_id"`
+	Variant   string    `gorm:"type:varchar(64)" json:"variant,omitempty"` // вариант A/B-теста
+	URL       string    `gorm:"type:text;not null" json:"url"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// LinkClick — переход получателя по отслеживаемой ссылке
+type LinkClick struct {
+	ID        uint      `gorm:"primaryKey"`
+	LinkID    uint      `gorm:"not null;index"`
+	TaskID    string    `gorm:"type:varchar(36);not null;index"`
+	Recipient int64     `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// ClickStats — агрегированные клики по задаче
+type ClickStats struct {
+	TotalClicks      int64            `json:"total_clicks"`
+	UniqueRecipients int64            `json:"unique_recipients"`
+	ByURL            map[string]int64 `json:"by_url"`
+	ByVariant        map[string]int64 `json:"by_variant,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TaskMessage — сообщение, отправленное получателю в рамках задачи.
+// Нужен, чтобы потом отредактировать или удалить рассылку.
+type TaskMessage struct {
+	ID        uint      `gorm:"primaryKey"`
+	TaskID    string    `gorm:"type:varchar(36);not null;index"`
+	Recipient int64     `gorm:"not null"`
+	MessageID int       `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
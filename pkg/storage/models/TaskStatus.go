@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// TaskStatus — статус задачи рассылки
+type TaskStatus string
+
+// Статусы задачи
+const (
+	TaskDraft           TaskStatus = "draft"     // создана, получатели ещё загружаются
+	TaskScheduled       TaskStatus = "scheduled" // принята, ещё не передана воркерам
+	TaskQueued          TaskStatus = "queued"    // опубликована в NATS
+	TaskRunning         TaskStatus = "running"   // воркер рассылает сообщения
+	TaskCancelled       TaskStatus = "cancelled" // отменена пользователем (POST /tasks/{id}/cancel)
+	TaskComplete        TaskStatus = "complete"
+	TaskPartiallyFailed TaskStatus = "partially_failed" // часть получателей не получила сообщение
+	TaskFailed          TaskStatus = "failed"           // причина в status_reason
+)
+
+// taskTransitions — допустимые переходы между статусами; из завершённых статусов выхода нет
+var taskTransitions = map[TaskStatus][]TaskStatus{
+	TaskDraft:     {TaskScheduled, TaskCancelled, TaskFailed},
+	TaskScheduled: {TaskQueued, TaskDraft, TaskCancelled, TaskFailed},
+	TaskQueued:    {TaskRunning, TaskCancelled, TaskFailed},
+	TaskRunning:   {TaskComplete, TaskPartiallyFailed, TaskFailed, TaskQueued, TaskCancelled},
+}
+
+// Terminal — задача завершена и больше не меняет статус
+func (s TaskStatus) Terminal() bool {
+	switch s {
+	case TaskCancelled, TaskComplete, TaskPartiallyFailed, TaskFailed:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo проверяет, допустим ли переход из s в to
+func (s TaskStatus) CanTransitionTo(to TaskStatus) bool {
+	for _, next := range taskTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskStatusHistory — смена статуса задачи: кто или что её изменил и когда
+type TaskStatusHistory struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TaskID     string     `gorm:"not null;index:idx_task_status_history_task_id_created_at,priority:1" json:"task_id"`
+	FromStatus TaskStatus `gorm:"type:varchar(20)" json:"from_status,omitempty"` // пусто — задача создана
+	ToStatus   TaskStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	Actor      string     `gorm:"type:varchar(64);not null" json:"actor"` // user:<id>, worker или system
+	Reason     string     `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index:idx_task_status_history_task_id_created_at,priority:2" json:"created_at"`
+}
+
+func (TaskStatusHistory) TableName() string { return "task_status_history" }
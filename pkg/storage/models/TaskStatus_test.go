@@ -0,0 +1,46 @@
+package models
+
+import "testing"
+
+func TestTaskStatusTransitions(t *testing.T) {
+	cases := []struct {
+		from, to TaskStatus
+		ok       bool
+	}{
+		{TaskDraft, TaskScheduled, true},
+		{TaskScheduled, TaskDraft, true},
+		{TaskScheduled, TaskQueued, true},
+		{TaskQueued, TaskRunning, true},
+		{TaskRunning, TaskComplete, true},
+		{TaskRunning, TaskPartiallyFailed, true},
+		{TaskQueued, TaskCancelled, true},
+		{TaskRunning, TaskCancelled, true},
+		{TaskDraft, TaskRunning, false},
+		{TaskScheduled, TaskComplete, false},
+		{TaskRunning, TaskRunning, false},
+		{TaskComplete, TaskRunning, false},
+		{TaskFailed, TaskScheduled, false},
+		{TaskCancelled, TaskQueued, false},
+	}
+	for _, tc := range cases {
+		if got := tc.from.CanTransitionTo(tc.to); got != tc.ok {
+			t.Errorf("%s -> %s: got %v, want %v", tc.from, tc.to, got, tc.ok)
+		}
+	}
+}
+
+func TestTaskStatusTerminal(t *testing.T) {
+	for status := range taskTransitions {
+		if status.Terminal() {
+			t.Errorf("%s has transitions but is terminal", status)
+		}
+	}
+	for _, status := range []TaskStatus{TaskCancelled, TaskComplete, TaskPartiallyFailed, TaskFailed} {
+		if !status.Terminal() {
+			t.Errorf("%s must be terminal", status)
+		}
+		if len(taskTransitions[status]) != 0 {
+			t.Errorf("%s must have no transitions", status)
+		}
+	}
+}
@@ -7,16 +7,29 @@ import (
 )
 
 type Task struct {
-	ID          string         `gorm:"primaryKey"`
-	UserID      uint           `gorm:"not null"`
-	MessageType string         `gorm:"type:varchar(20);not null"`
-	Content     string         `gorm:"type:jsonb;not null"`
-	Priority    string         `gorm:"type:varchar(10);default:'medium'"`
-	Schedule    *time.Time     `gorm:"type:timestamp"`
-	Status      string         `gorm:"type:varchar(20);not null"`
-	CreatedAt   time.Time      `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	ID            string         `gorm:"primaryKey"`
+	UserID        uint           `gorm:"not null"`
+	OrgID         *uint          `gorm:"index" json:"org_id,omitempty"`            // организация автора: задачу видят все её участники
+	BotID         *uint          `gorm:"index" json:"bot_id,omitempty"`            // бот рассылки; nil — бот из AuthUser.Token
+	Action        string         `gorm:"type:varchar(20);not null;default:'send'"` // send, edit, recall
+	ParentID      *string        `gorm:"index"`                                    // для edit/recall и победителя A/B-теста: исходная задача
+	MessageType   string         `gorm:"type:varchar(20);not null"`
+	Content       string         `gorm:"type:jsonb;not null"`
+	Variants      *string        `gorm:"type:jsonb" json:"variants,omitempty"` // варианты A/B-теста
+	Holdout       *string        `gorm:"type:jsonb" json:"-"`                  // получатели, ждущие победителя A/B-теста
+	AudienceID    *uint          `gorm:"index" json:"audience_id,omitempty"`   // получатели из аудитории
+	TagExpr       string         `gorm:"type:text" json:"tag_expr,omitempty"`
+	ToSubscribers bool           `gorm:"not null;default:false" json:"to_subscribers,omitempty"` // получатели — подписчики бота
+	LanguageCode  string         `gorm:"type:varchar(16)" json:"language_code,omitempty"`
+	Priority      string         `gorm:"type:varchar(10);default:'medium'"`
+	Schedule      *time.Time     `gorm:"type:timestamp"`
+	Status        TaskStatus     `gorm:"type:varchar(20);not null"`
+	StatusReason  string         `gorm:"type:text" json:"status_reason,omitempty"` // почему задача прервана
+	Version       int64          `gorm:"not null;default:0" json:"version"`        // растёт с каждой сменой статуса
+	CreatedAt     time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
 
-	Stats *string `gorm:"type:jsonb" json:"stats,omitempty"`
+	Stats  *Stats      `gorm:"type:jsonb" json:"stats,omitempty"`
+	Clicks *ClickStats `gorm:"-" json:"clicks,omitempty"` // заполняется при чтении, если отслеживались клики
 }
@@ -1,15 +1,68 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
+// Stats — статистика доставки задачи; хранится объектом в колонке tasks.stats (jsonb)
 type Stats struct {
-	TotalSent     int64            `json:"total_sent"`
-	TotalFailed   int64            `json:"total_failed"`
-	ByContentType map[string]int64 `json:"by_content_type"`
-	StartTime     time.Time        `json:"-"`
-	TimeSpent     float64          `json:"time_spent"`
-
-	ProcessedCount int64            `json:"-"`
-	ExpectedCount  int64            `json:"-"`
-	ErrorCounts    map[string]int64 `json:"-"`
+	ExpectedCount  int64 `json:"expected_count"`  // получателей выложено в очередь
+	ProcessedCount int64 `json:"processed_count"` // из них обработано (отправлено или с ошибкой)
+	TotalSent      int64 `json:"total_sent"`
+	TotalFailed    int64 `json:"total_failed"`
+	TotalSkipped   int64 `json:"total_skipped"` // остановили или заблокировали бота
+	TotalRetried   int64 `json:"total_retried"` // повторных попыток отправки
+
+	ByContentType map[string]int64         `json:"by_content_type"`
+	ErrorCounts   map[string]int64         `json:"error_counts"`         // по кодам ошибок Telegram
+	ByVariant     map[string]*VariantStats `json:"by_variant,omitempty"` // для A/B-тестов
+
+	StartTime  time.Time  `json:"start_time"`
+	EndTime    *time.Time `json:"end_time,omitempty"`
+	TimeSpent  float64    `json:"time_spent"` // секунды
+	Throughput float64    `json:"throughput"` // отправлено сообщений в секунду
+}
+
+// VariantStats — результаты доставки одного варианта A/B-теста
+type VariantStats struct {
+	Sent        int64            `json:"sent"`
+	Failed      int64            `json:"failed"`
+	ErrorCounts map[string]int64 `json:"error_counts"`
+}
+
+// Finish фиксирует время окончания задачи, длительность и скорость отправки
+func (s *Stats) Finish(now time.Time) {
+	s.EndTime = &now
+	s.TimeSpent = now.Sub(s.StartTime).Seconds()
+	s.Throughput = 0
+	if s.TimeSpent > 0 {
+		s.Throughput = float64(s.TotalSent) / s.TimeSpent
+	}
+}
+
+// Value сохраняет статистику в jsonb
+func (s Stats) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan читает статистику из jsonb
+func (s *Stats) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = Stats{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("unsupported stats type %T", value)
+	}
 }
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsValueScan(t *testing.T) {
+	start := time.Date(2025, 1, 5, 10, 0, 0, 0, time.UTC)
+	st := Stats{
+		ExpectedCount:  3,
+		ProcessedCount: 3,
+		TotalSent:      2,
+		TotalFailed:    1,
+		TotalRetried:   4,
+		ByContentType:  map[string]int64{"text": 2},
+		ErrorCounts:    map[string]int64{"NOT_FOUND": 1},
+		StartTime:      start,
+	}
+	st.Finish(start.Add(4 * time.Second))
+
+	value, err := st.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Stats
+	if err := got.Scan([]byte(value.(string))); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ExpectedCount != 3 || got.ProcessedCount != 3 || got.TotalRetried != 4 {
+		t.Errorf("counters are lost: %+v", got)
+	}
+	if got.ErrorCounts["NOT_FOUND"] != 1 || got.ByContentType["text"] != 2 {
+		t.Errorf("breakdowns are lost: %+v", got)
+	}
+	if !got.StartTime.Equal(start) || got.EndTime == nil || !got.EndTime.Equal(start.Add(4*time.Second)) {
+		t.Errorf("times are lost: start %v, end %v", got.StartTime, got.EndTime)
+	}
+	if got.TimeSpent != 4 || got.Throughput != 0.5 {
+		t.Errorf("time_spent %v, throughput %v; want 4 and 0.5", got.TimeSpent, got.Throughput)
+	}
+}
+
+func TestStatsScanNull(t *testing.T) {
+	st := Stats{TotalSent: 1}
+	if err := st.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+	if st.TotalSent != 0 {
+		t.Errorf("scan of NULL must reset stats, got %+v", st)
+	}
+	if err := st.Scan(42); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
@@ -0,0 +1,52 @@
+// pkg/totp/totp_test.go
+
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestCodeRFC6238(t *testing.T) {
+	// Тестовые векторы RFC 6238 (SHA1), последние 6 цифр
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := Code(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatalf("Code failed: %v", err)
+		}
+		if got != want {
+			t.Fatalf("Code at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+	now := time.Now()
+
+	code, _ := Code(secret, now)
+	if !Validate(secret, code, now) {
+		t.Fatal("current code must be valid")
+	}
+	if !Validate(secret, code, now.Add(period*time.Second)) {
+		t.Fatal("code from previous interval must be accepted")
+	}
+	if Validate(secret, code, now.Add(3*period*time.Second)) {
+		t.Fatal("stale code must be rejected")
+	}
+	if Validate(secret, "12345", now) {
+		t.Fatal("short code must be rejected")
+	}
+}